@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// credentialFile é o arquivo (em stateDir) com a credencial própria do agent
+const credentialFile = "credential"
+
+// loadCredential lê a credencial salva em stateDir ("" se o agent ainda não foi inscrito)
+func loadCredential(stateDir string) string {
+	if stateDir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, credentialFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// saveCredential grava a credencial em stateDir com permissão restrita
+func saveCredential(stateDir, credential string) error {
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return errorf("agent.err.state_dir", err)
+	}
+
+	path := filepath.Join(stateDir, credentialFile)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(credential+"\n"), 0600); err != nil {
+		return errorf("agent.err.credential_write", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return errorf("agent.err.credential_write", err)
+	}
+
+	return nil
+}
+
+// enroll inscreve o agent no servidor com o token de inscrição, salva a credencial
+// recebida e passa a usá-la no lugar do token compartilhado
+func enroll(config *Config) error {
+	if config.StateDir == "" {
+		return errorf("agent.err.enroll_state_dir")
+	}
+
+	jsonData, err := json.Marshal(map[string]string{
+		"machine_uuid": config.MachineUUID,
+		"hostname":     config.MachineName,
+	})
+	if err != nil {
+		return errorf("agent.err.enroll_encode", err)
+	}
+
+	req, err := http.NewRequest("POST", config.ServerURL+"/api/agents/enroll", bytes.NewReader(jsonData))
+	if err != nil {
+		return errorf("agent.err.request_create", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", lang)
+	req.Header.Set("Authorization", "Bearer "+config.EnrollToken)
+
+	resp, err := config.HTTPClient.Do(req)
+	if err != nil {
+		return errorf("agent.err.enroll_send", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Credential string `json:"credential"`
+		Message    string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	if resp.StatusCode != http.StatusCreated {
+		if body.Message != "" {
+			return errorf("agent.err.enroll_refused_message", resp.StatusCode, body.Message)
+		}
+		return errorf("agent.err.enroll_refused", resp.StatusCode)
+	}
+	if body.Credential == "" {
+		return errorf("agent.err.enroll_no_credential")
+	}
+
+	if err := saveCredential(config.StateDir, body.Credential); err != nil {
+		return err
+	}
+
+	config.Token = body.Credential
+	config.Enrolled = true
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentialRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		stateDir string
+		save     string // vazio: não grava
+		want     string
+	}{
+		{"sem state dir", "", "", ""},
+		{"ainda não inscrito", "state", "", ""},
+		{"credencial salva", "state", "mia_0123456789abcdef", "mia_0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tt.stateDir
+			if dir != "" {
+				dir = filepath.Join(t.TempDir(), dir)
+			}
+			if tt.save != "" {
+				if err := saveCredential(dir, tt.save); err != nil {
+					t.Fatal(err)
+				}
+				info, err := os.Stat(filepath.Join(dir, credentialFile))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != 0600 {
+					t.Errorf("permissão = %v, esperado 0600", info.Mode().Perm())
+				}
+			}
+
+			if got := loadCredential(dir); got != tt.want {
+				t.Errorf("loadCredential = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
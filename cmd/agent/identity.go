@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// machineIDPath é o arquivo do systemd usado como semente da identidade
+const machineIDPath = "/etc/machine-id"
+
+// loadMachineUUID retorna o UUID estável da máquina, criando-o na primeira execução.
+// O UUID é derivado de /etc/machine-id (quando existe) e persistido em stateDir,
+// de forma que renomear o host não muda a identidade da máquina no servidor.
+func loadMachineUUID(stateDir string) (string, error) {
+	var path string
+	if stateDir != "" {
+		path = filepath.Join(stateDir, "machine-uuid")
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id, nil
+			}
+		}
+	}
+
+	id, err := newMachineUUID()
+	if err != nil {
+		return "", err
+	}
+
+	if path == "" {
+		return id, nil
+	}
+
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return id, errorf("agent.err.state_dir", err)
+	}
+	if err := os.WriteFile(path, []byte(id+"\n"), 0600); err != nil {
+		return id, errorf("agent.err.identity_write", err)
+	}
+
+	return id, nil
+}
+
+// newMachineUUID gera um UUID a partir de /etc/machine-id (hash, para não expor o valor original)
+// ou aleatório se o arquivo não existir
+func newMachineUUID() (string, error) {
+	var b [16]byte
+
+	if data, err := os.ReadFile(machineIDPath); err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		sum := sha256.Sum256([]byte("monitor-infra:" + strings.TrimSpace(string(data))))
+		copy(b[:], sum[:16])
+		b[6] = (b[6] & 0x0f) | 0x50 // versão 5 (baseado em nome)
+	} else {
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", errorf("agent.err.identity_generate", err)
+		}
+		b[6] = (b[6] & 0x0f) | 0x40 // versão 4 (aleatório)
+	}
+	b[8] = (b[8] & 0x3f) | 0x80 // variante RFC 4122
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
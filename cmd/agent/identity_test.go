@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[45][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestLoadMachineUUID(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored string // conteúdo prévio de machine-uuid (vazio: sem arquivo)
+		want   string // vazio: um UUID novo
+	}{
+		{"primeira execução", "", ""},
+		{"identidade salva", "0b8e3f7a-1c2d-4e5f-8a9b-0c1d2e3f4a5b\n", "0b8e3f7a-1c2d-4e5f-8a9b-0c1d2e3f4a5b"},
+		{"arquivo vazio gera outra", "\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "state")
+			if tt.stored != "" {
+				os.MkdirAll(dir, 0700)
+				os.WriteFile(filepath.Join(dir, "machine-uuid"), []byte(tt.stored), 0600)
+			}
+
+			id, err := loadMachineUUID(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && id != tt.want {
+				t.Errorf("id = %s, esperado %s", id, tt.want)
+			}
+			if !uuidPattern.MatchString(id) {
+				t.Errorf("id %q não é um UUID válido", id)
+			}
+
+			// A segunda execução lê a mesma identidade
+			again, err := loadMachineUUID(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != id {
+				t.Errorf("identidade mudou entre execuções: %s -> %s", id, again)
+			}
+		})
+	}
+}
+
+func TestLoadMachineUUIDWithoutStateDir(t *testing.T) {
+	id, err := loadMachineUUID("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !uuidPattern.MatchString(id) {
+		t.Errorf("id %q não é um UUID válido", id)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// labelFlags acumula os labels de --label chave=valor; a flag pode se repetir
+// e cada valor pode ter vários labels separados por vírgula
+type labelFlags map[string]string
+
+func (l labelFlags) String() string {
+	items := make([]string, 0, len(l))
+	for key, value := range l {
+		items = append(items, key+"="+value)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// Set adiciona os labels de value ("env=prod" ou "env=prod,role=web")
+func (l labelFlags) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return errorf("agent.err.label_invalid", item)
+		}
+		l[key] = strings.TrimSpace(val)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestLabelFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+		fails  bool
+	}{
+		{"um label", []string{"env=prod"}, "env=prod", false},
+		{"vários por valor", []string{"env=prod, role=web"}, "env=prod,role=web", false},
+		{"flag repetida", []string{"env=staging", "env=prod"}, "env=prod", false},
+		{"valor vazio", []string{"gpu="}, "gpu=", false},
+		{"sem igual", []string{"gpu"}, "", true},
+		{"sem chave", []string{"=prod"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := labelFlags{}
+			var err error
+			for _, v := range tt.values {
+				if err = labels.Set(v); err != nil {
+					break
+				}
+			}
+			if tt.fails {
+				if err == nil {
+					t.Error("esperado erro")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := labels.String(); got != tt.want {
+				t.Errorf("labels = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+
+	"monitor-infra/internal/i18n"
+)
+
+// lang é o idioma dos logs do agent (--lang, AGENT_LANG ou o locale do sistema)
+var lang = systemLanguage()
+
+// systemLanguage escolhe o idioma a partir de AGENT_LANG ou das variáveis de locale
+// (LC_ALL, LC_MESSAGES, LANG); sem nenhuma suportada, usa o idioma padrão
+func systemLanguage() string {
+	if value := i18n.Match(os.Getenv("AGENT_LANG")); value != "" {
+		return value
+	}
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := i18n.FromLocale(os.Getenv(key)); value != "" {
+			return value
+		}
+	}
+	return i18n.Default
+}
+
+// t traduz uma mensagem de log do agent
+func t(key string, args ...interface{}) string {
+	return i18n.T(lang, key, args...)
+}
+
+// agentError é um erro com a mensagem do catálogo, traduzida para lang ao ser exibida;
+// um error entre os argumentos é o erro encapsulado (errors.Is/As)
+type agentError struct {
+	key  string
+	args []interface{}
+}
+
+func (e *agentError) Error() string {
+	return t(e.key, e.args...)
+}
+
+func (e *agentError) Unwrap() error {
+	for i := len(e.args) - 1; i >= 0; i-- {
+		if err, ok := e.args[i].(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorf cria um erro com a mensagem key do catálogo, como fmt.Errorf com %w
+func errorf(key string, args ...interface{}) error {
+	return &agentError{key: key, args: args}
+}
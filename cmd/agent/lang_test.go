@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"monitor-infra/internal/i18n"
+)
+
+func TestErrorf(t *testing.T) {
+	defer func(previous string) { lang = previous }(lang)
+	err := errorf("agent.err.spool_write", os.ErrPermission)
+
+	for _, l := range i18n.Supported() {
+		t.Run(l, func(t *testing.T) {
+			lang = l
+			want := i18n.T(l, "agent.err.spool_write", os.ErrPermission)
+			if err.Error() != want || !strings.Contains(want, "permission denied") {
+				t.Errorf("mensagem %q, esperado %q", err.Error(), want)
+			}
+		})
+	}
+
+	if !errors.Is(err, os.ErrPermission) {
+		t.Error("errors.Is não encontra o erro encapsulado")
+	}
+	if errors.Unwrap(errorf("agent.err.http_status", 503)) != nil {
+		t.Error("erro sem causa encapsulada")
+	}
+}
+
+func TestSystemLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"AGENT_LANG", map[string]string{"AGENT_LANG": "en", "LANG": "pt_BR.UTF-8"}, i18n.En},
+		{"LC_ALL antes de LANG", map[string]string{"LC_ALL": "en_US.UTF-8", "LANG": "pt_BR.UTF-8"}, i18n.En},
+		{"LANG", map[string]string{"LANG": "pt_BR.UTF-8"}, i18n.PtBR},
+		{"locale não suportado", map[string]string{"LANG": "de_DE.UTF-8"}, i18n.Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"AGENT_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := systemLanguage(); got != tt.want {
+				t.Errorf("systemLanguage = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCatalogKeys garante que toda mensagem usada pelo agent existe no catálogo
+func TestCatalogKeys(t *testing.T) {
+	usage := regexp.MustCompile(`\b(?:t|errorf)\("(agent\.[^"]+)"`)
+	files, _ := filepath.Glob("*.go")
+	found := 0
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range usage.FindAllStringSubmatch(string(data), -1) {
+			found++
+			for _, l := range i18n.Supported() {
+				if i18n.T(l, m[1]) == m[1] {
+					t.Errorf("%s: chave %q sem mensagem em %s", file, m[1], l)
+				}
+			}
+		}
+	}
+	if found == 0 {
+		t.Error("nenhuma mensagem encontrada no código do agent")
+	}
+}
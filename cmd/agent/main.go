@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -10,10 +12,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"monitor-infra/internal/collector"
+	"monitor-infra/internal/i18n"
 )
 
 var (
@@ -25,35 +29,131 @@ var (
 type Config struct {
 	ServerURL    string
 	Token        string
+	EnrollToken  string
+	Enrolled     bool
+	MachineUUID  string
 	MachineName  string
 	GroupName    string
+	Labels       map[string]string
 	IntervalMins int
+	StateDir     string
+	SpoolMax     int
+	SampleSecs   int
+	CACert       string
+	ClientCert   string
+	ClientKey    string
+	SigningKey   string
+	HTTPClient   *http.Client
+	BackoffUntil time.Time
+}
+
+// spoolRetryInterval define a frequência de reenvio enquanto houver payloads pendentes
+const spoolRetryInterval = time.Minute
+
+// batchMaxItems limita a quantidade de coletas por request de lote
+const batchMaxItems = 500
+
+// errBatchUnsupported indica que o servidor não possui o endpoint de lote
+var errBatchUnsupported = errorf("agent.err.batch_unsupported")
+
+// errBatchTooLarge indica que o lote excedeu o tamanho máximo aceito pelo servidor
+var errBatchTooLarge = errorf("agent.err.batch_too_large")
+
+// errBatchRejected indica que o servidor recusou o lote inteiro (400 ou 422), em geral por
+// uma coleta inválida
+var errBatchRejected = errorf("agent.err.batch_rejected")
+
+// batchItemResult representa o resultado de um item do lote retornado pelo servidor
+type batchItemResult struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// httpStatusError representa uma resposta do servidor com status de erro
+type httpStatusError struct {
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return t("agent.err.http_status", e.StatusCode)
+}
+
+// isPermanent indica se o servidor rejeitou o payload de forma definitiva
+// (reenviar o mesmo conteúdo não vai funcionar). Erros de autenticação não
+// contam: as coletas ficam guardadas até o token ser corrigido.
+func isPermanent(err error) bool {
+	statusErr, ok := err.(*httpStatusError)
+	if !ok {
+		return false
+	}
+
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
 }
 
 // MetricPayload representa o payload enviado ao servidor
 type MetricPayload struct {
-	Hostname      string  `json:"hostname"`
-	IP            string  `json:"ip"`
-	GroupName     string  `json:"group"`
-	SwarmRole     string  `json:"swarm_role"`
-	CPUPercent    float64 `json:"cpu_percent"`
-	MemoryPercent float64 `json:"memory_percent"`
-	DiskPercent   float64 `json:"disk_percent"`
-	DockerRunning int     `json:"docker_running"`
-	DockerStopped int     `json:"docker_stopped"`
+	MachineUUID   string    `json:"machine_uuid"`
+	Hostname      string    `json:"hostname"`
+	IP            string    `json:"ip"`
+	GroupName     string    `json:"group"`
+	SwarmRole     string    `json:"swarm_role"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	DiskPercent   float64   `json:"disk_percent"`
+	DockerRunning int       `json:"docker_running"`
+	DockerStopped int       `json:"docker_stopped"`
+	CollectedAt   time.Time `json:"collected_at"`
+	SentAt        time.Time `json:"sent_at"`
+
+	// Labels sempre enviados (mesmo vazios) para o servidor remover os que saíram da configuração
+	Labels map[string]string `json:"labels"`
+
+	// Agregados da amostragem de alta resolução (cpu/memory/disk_percent passam a ser a média)
+	SampleCount int     `json:"sample_count,omitempty"`
+	CPUMin      float64 `json:"cpu_min,omitempty"`
+	CPUMax      float64 `json:"cpu_max,omitempty"`
+	CPUP95      float64 `json:"cpu_p95,omitempty"`
+	MemoryMin   float64 `json:"memory_min,omitempty"`
+	MemoryMax   float64 `json:"memory_max,omitempty"`
+	MemoryP95   float64 `json:"memory_p95,omitempty"`
+	DiskMin     float64 `json:"disk_min,omitempty"`
+	DiskMax     float64 `json:"disk_max,omitempty"`
+	DiskP95     float64 `json:"disk_p95,omitempty"`
 }
 
 func main() {
 	// Flags de linha de comando
 	serverURL := flag.String("server", getEnv("SERVER_URL", "http://localhost:8080"), "URL do servidor de monitoramento")
 	token := flag.String("token", getEnv("AUTH_TOKEN", ""), "Token de autenticação")
+	enrollToken := flag.String("enroll-token", getEnv("ENROLL_TOKEN", ""), "Token de inscrição (obtém uma credencial própria do agent)")
 	machineName := flag.String("name", getEnv("MACHINE_NAME", ""), "Nome da máquina (default: hostname)")
 	groupName := flag.String("group", getEnv("GROUP_NAME", "default"), "Nome do grupo")
 	intervalMins := flag.Int("interval", getEnvInt("INTERVAL_MINUTES", 60), "Intervalo de coleta em minutos")
+	stateDir := flag.String("state-dir", getEnv("STATE_DIR", "/var/lib/monitor-agent"), "Diretório de estado do agent (vazio desativa o spool)")
+	spoolMax := flag.Int("spool-max", getEnvInt("SPOOL_MAX", 1000), "Máximo de coletas pendentes guardadas em disco")
+	sampleSecs := flag.Int("sample-interval", getEnvInt("SAMPLE_INTERVAL_SECONDS", 10), "Intervalo de amostragem entre relatórios em segundos (0 desativa)")
+	caCert := flag.String("ca-cert", getEnv("TLS_CA_CERT", ""), "CA para verificar o certificado do servidor (PEM)")
+	clientCert := flag.String("client-cert", getEnv("TLS_CLIENT_CERT", ""), "Certificado de cliente do agent (PEM)")
+	clientKey := flag.String("client-key", getEnv("TLS_CLIENT_KEY", ""), "Chave privada do certificado de cliente (PEM)")
+	signingKey := flag.String("signing-key", getEnv("SIGNING_KEY", ""), "Chave HMAC para assinar os envios de métricas")
+	labels := labelFlags{}
+	if err := labels.Set(getEnv("LABELS", "")); err != nil {
+		log.Fatal(t("agent.labels_env_invalid", err))
+	}
+	flag.Var(labels, "label", "Label chave=valor da máquina (pode repetir; soma-se a LABELS)")
+	langFlag := flag.String("lang", lang, "Idioma dos logs: pt-BR ou en (default: AGENT_LANG ou o locale do sistema)")
 	version := flag.Bool("version", false, "Mostrar versão")
 	once := flag.Bool("once", false, "Executar apenas uma vez e sair")
 
 	flag.Parse()
+	if lang = i18n.Match(*langFlag); lang == "" {
+		lang = i18n.Default
+	}
 
 	if *version {
 		fmt.Printf("Monitor-Infra Agent v%s (build: %s)\n", Version, BuildTime)
@@ -62,7 +162,7 @@ func main() {
 
 	// Validar configuração
 	if *serverURL == "" {
-		log.Fatal("Erro: URL do servidor é obrigatória (--server ou SERVER_URL)")
+		log.Fatal(t("agent.server_required"))
 	}
 
 	// Obter hostname se não especificado
@@ -71,22 +171,79 @@ func main() {
 		var err error
 		hostname, err = os.Hostname()
 		if err != nil {
-			log.Fatalf("Erro ao obter hostname: %v", err)
+			log.Fatal(t("agent.hostname_failed", err))
 		}
 	}
 
 	config := &Config{
 		ServerURL:    *serverURL,
 		Token:        *token,
+		EnrollToken:  *enrollToken,
 		MachineName:  hostname,
 		GroupName:    *groupName,
+		Labels:       labels,
 		IntervalMins: *intervalMins,
+		StateDir:     *stateDir,
+		SpoolMax:     *spoolMax,
+		SampleSecs:   *sampleSecs,
+		CACert:       *caCert,
+		ClientCert:   *clientCert,
+		ClientKey:    *clientKey,
+		SigningKey:   *signingKey,
 	}
 
-	log.Printf("Monitor-Infra Agent v%s iniciando...", Version)
-	log.Printf("Servidor: %s", config.ServerURL)
-	log.Printf("Máquina: %s (grupo: %s)", config.MachineName, config.GroupName)
-	log.Printf("Intervalo: %d minutos", config.IntervalMins)
+	// Cliente HTTP (TLS com CA própria e certificado de cliente, se configurados)
+	httpClient, err := newHTTPClient(config)
+	if err != nil {
+		log.Fatal(t("agent.error", err))
+	}
+	config.HTTPClient = httpClient
+
+	// Identidade estável da máquina (independente do hostname)
+	machineUUID, err := loadMachineUUID(config.StateDir)
+	if err != nil {
+		if machineUUID == "" {
+			log.Fatal(t("agent.error", err))
+		}
+		log.Print(t("agent.identity_not_saved", err))
+	}
+	config.MachineUUID = machineUUID
+
+	// Credencial própria do agent tem prioridade sobre o token compartilhado
+	if credential := loadCredential(config.StateDir); credential != "" {
+		config.Token = credential
+		config.Enrolled = true
+	} else if config.EnrollToken != "" {
+		if err := enroll(config); err != nil {
+			log.Print(t("agent.enroll_failed", err))
+		} else {
+			log.Print(t("agent.enrolled", filepath.Join(config.StateDir, credentialFile)))
+		}
+	}
+
+	log.Print(t("agent.starting", Version))
+	log.Print(t("agent.server", config.ServerURL))
+	log.Print(t("agent.machine", config.MachineName, config.GroupName, config.MachineUUID))
+	if len(labels) > 0 {
+		log.Print(t("agent.labels", labels))
+	}
+	log.Print(t("agent.interval", config.IntervalMins))
+	if config.SampleSecs > 0 {
+		log.Print(t("agent.sampling", config.SampleSecs))
+	}
+
+	// Criar spool em disco (opcional)
+	var spool *Spool
+	if config.StateDir != "" {
+		var err error
+		spool, err = NewSpool(filepath.Join(config.StateDir, "spool"), config.SpoolMax)
+		if err != nil {
+			log.Print(t("agent.spool_disabled", err))
+			spool = nil
+		} else if pending := spool.Len(); pending > 0 {
+			log.Print(t("agent.spool_pending", pending))
+		}
+	}
 
 	// Criar collector
 	coll := collector.New()
@@ -94,56 +251,92 @@ func main() {
 
 	// Se modo "once", executar uma vez e sair
 	if *once {
-		if err := collectAndSend(config, coll); err != nil {
-			log.Fatalf("Erro: %v", err)
+		if err := collectAndSend(config, coll, spool, nil); err != nil {
+			log.Fatal(t("agent.error", err))
 		}
-		log.Println("Métricas enviadas com sucesso!")
+		log.Print(t("agent.sent"))
 		return
 	}
 
 	// Enviar primeira métrica imediatamente
-	log.Println("Enviando primeira coleta...")
-	if err := collectAndSend(config, coll); err != nil {
-		log.Printf("Aviso: falha na primeira coleta: %v", err)
+	log.Print(t("agent.first_collect"))
+	if err := collectAndSend(config, coll, spool, nil); err != nil {
+		log.Print(t("agent.first_collect_failed", err))
 	} else {
-		log.Println("Primeira coleta enviada com sucesso!")
+		log.Print(t("agent.first_collect_sent"))
 	}
 
 	// Configurar ticker para coletas periódicas
 	ticker := time.NewTicker(time.Duration(config.IntervalMins) * time.Minute)
 	defer ticker.Stop()
 
+	// Ticker para reenvio de coletas pendentes no spool
+	retryTicker := time.NewTicker(spoolRetryInterval)
+	defer retryTicker.Stop()
+
+	// Amostragem de alta resolução entre relatórios (opcional)
+	var sampler *collector.Sampler
+	var sampleC <-chan time.Time
+	if config.SampleSecs > 0 {
+		sampler = collector.NewSampler(coll)
+		sampleTicker := time.NewTicker(time.Duration(config.SampleSecs) * time.Second)
+		defer sampleTicker.Stop()
+		sampleC = sampleTicker.C
+	}
+
 	// Configurar signal handler para graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	log.Printf("Agent rodando. Próxima coleta em %d minutos...", config.IntervalMins)
+	log.Print(t("agent.running", config.IntervalMins))
 
 	// Loop principal
 	for {
 		select {
 		case <-ticker.C:
-			log.Println("Iniciando coleta...")
-			if err := collectAndSend(config, coll); err != nil {
-				log.Printf("Erro na coleta: %v", err)
+			log.Print(t("agent.collecting"))
+			if err := collectAndSend(config, coll, spool, sampler); err != nil {
+				log.Print(t("agent.collect_failed", err))
 			} else {
-				log.Println("Métricas enviadas com sucesso!")
+				log.Print(t("agent.sent"))
+			}
+			log.Print(t("agent.next_collect", config.IntervalMins))
+
+		case <-sampleC:
+			if err := sampler.Sample(); err != nil {
+				log.Print(t("agent.sample_failed", err))
+			}
+
+		case <-retryTicker.C:
+			if !config.Enrolled && config.EnrollToken != "" {
+				if err := enroll(config); err != nil {
+					log.Print(t("agent.enroll_failed", err))
+					continue
+				}
+				log.Print(t("agent.enrolled", filepath.Join(config.StateDir, credentialFile)))
+			}
+			if spool == nil || spool.Len() == 0 {
+				continue
+			}
+			if err := flushSpool(config, spool); err != nil {
+				log.Print(t("agent.spool_deferred", err))
 			}
-			log.Printf("Próxima coleta em %d minutos...", config.IntervalMins)
 
 		case sig := <-sigChan:
-			log.Printf("Sinal recebido: %v. Encerrando...", sig)
+			log.Print(t("agent.signal", sig))
 			return
 		}
 	}
 }
 
-// collectAndSend coleta métricas e envia para o servidor
-func collectAndSend(config *Config, coll *collector.Collector) error {
+// collectAndSend coleta métricas e envia para o servidor.
+// Se houver sampler com amostras, CPU/memória/disco são a média do período
+// e o payload leva também mínimo, máximo e p95.
+func collectAndSend(config *Config, coll *collector.Collector, spool *Spool, sampler *collector.Sampler) error {
 	// Coletar métricas
 	metrics, err := coll.CollectAll()
 	if err != nil {
-		return fmt.Errorf("erro ao coletar métricas: %w", err)
+		return errorf("agent.err.collect", err)
 	}
 
 	// Obter IP local
@@ -151,54 +344,283 @@ func collectAndSend(config *Config, coll *collector.Collector) error {
 
 	// Montar payload
 	payload := &MetricPayload{
+		MachineUUID:   config.MachineUUID,
 		Hostname:      config.MachineName,
 		IP:            ip,
 		GroupName:     config.GroupName,
+		Labels:        config.Labels,
 		SwarmRole:     metrics.SwarmRole,
 		CPUPercent:    metrics.CPUPercent,
 		MemoryPercent: metrics.MemoryPercent,
 		DiskPercent:   metrics.DiskPercent,
 		DockerRunning: metrics.DockerRunning,
 		DockerStopped: metrics.DockerStopped,
+		CollectedAt:   time.Now().UTC(),
+	}
+
+	if sampler != nil {
+		if summary := sampler.Flush(); summary != nil {
+			payload.SampleCount = summary.Count
+			payload.CPUPercent, payload.CPUMin, payload.CPUMax, payload.CPUP95 =
+				summary.CPU.Avg, summary.CPU.Min, summary.CPU.Max, summary.CPU.P95
+			payload.MemoryPercent, payload.MemoryMin, payload.MemoryMax, payload.MemoryP95 =
+				summary.Memory.Avg, summary.Memory.Min, summary.Memory.Max, summary.Memory.P95
+			payload.DiskPercent, payload.DiskMin, payload.DiskMax, payload.DiskP95 =
+				summary.Disk.Avg, summary.Disk.Min, summary.Disk.Max, summary.Disk.P95
+		}
+	}
+
+	// Sem spool, envio direto (coleta é perdida em caso de falha)
+	if spool == nil {
+		return sendMetrics(config, payload)
+	}
+
+	// Enfileirar antes de enviar garante que a coleta sobrevive a falhas e é reenviada em ordem
+	if err := spool.Push(payload); err != nil {
+		log.Print(t("agent.warning", err))
+		return sendMetrics(config, payload)
+	}
+
+	return flushSpool(config, spool)
+}
+
+// flushSpool reenvia os payloads pendentes em ordem de coleta, parando na primeira falha temporária.
+// Com mais de uma coleta pendente usa o endpoint de lote.
+func flushSpool(config *Config, spool *Spool) error {
+	names, err := spool.Pending()
+	if err != nil {
+		return err
+	}
+
+	total := len(names)
+	batchSize := batchMaxItems
+	singles := 0 // coletas de um lote recusado, reenviadas uma a uma
+	for len(names) > 0 {
+		if len(names) > 1 && batchSize > 1 && singles == 0 {
+			chunk := names[:min(len(names), batchSize)]
+			err := flushBatch(config, spool, chunk)
+			if err == nil {
+				names = names[len(chunk):]
+				continue
+			}
+			if errors.Is(err, errBatchTooLarge) {
+				// Lote grande demais para o servidor: tentar com metade
+				batchSize /= 2
+				continue
+			}
+			if errors.Is(err, errBatchRejected) {
+				// Lote recusado inteiro: enviar as coletas dele uma a uma, descartando só as recusadas
+				log.Print(t("agent.batch_rejected", len(chunk)))
+				singles = len(chunk)
+				continue
+			}
+			if !errors.Is(err, errBatchUnsupported) {
+				return errorf("agent.err.spool_pending", err, len(names))
+			}
+			// Servidor sem suporte a lote: seguir item a item
+		}
+
+		name := names[0]
+		names = names[1:]
+		if singles > 0 {
+			singles--
+		}
+
+		payload, err := spool.Load(name)
+		if err != nil {
+			log.Print(t("agent.discarded", err))
+			spool.Remove(name)
+			continue
+		}
+
+		if err := sendMetrics(config, payload); err != nil {
+			if isPermanent(err) {
+				log.Print(t("agent.payload_rejected", payload.CollectedAt.Format(time.RFC3339), err))
+				spool.Remove(name)
+				continue
+			}
+			return errorf("agent.err.spool_pending", err, len(names)+1)
+		}
+
+		if err := spool.Remove(name); err != nil {
+			return err
+		}
+	}
+
+	if total > 1 {
+		log.Print(t("agent.spool_resent", total))
+	}
+
+	return nil
+}
+
+// flushBatch envia um lote de coletas do spool e remove as que o servidor processou
+func flushBatch(config *Config, spool *Spool, names []string) error {
+	var payloads []*MetricPayload
+	var loaded []string
+	for _, name := range names {
+		payload, err := spool.Load(name)
+		if err != nil {
+			log.Print(t("agent.discarded", err))
+			spool.Remove(name)
+			continue
+		}
+		payloads = append(payloads, payload)
+		loaded = append(loaded, name)
+	}
+
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	results, err := sendBatch(config, payloads)
+	if err != nil {
+		return err
 	}
 
-	// Enviar para servidor
-	return sendMetrics(config, payload)
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(loaded) {
+			continue
+		}
+		if result.Status != "ok" {
+			log.Print(t("agent.payload_rejected",
+				payloads[result.Index].CollectedAt.Format(time.RFC3339), result.Message))
+		}
+		if err := spool.Remove(loaded[result.Index]); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // sendMetrics envia métricas para o servidor
 func sendMetrics(config *Config, payload *MetricPayload) error {
+	// Horário de envio permite ao servidor medir a diferença de relógio
+	payload.SentAt = time.Now().UTC()
+
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("erro ao serializar payload: %w", err)
+		return errorf("agent.err.payload_encode", err)
+	}
+
+	resp, err := postJSON(config, "/api/metrics", jsonData, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Verificar resposta
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return &httpStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// sendBatch envia vários payloads de uma vez (gzip) e retorna o resultado de cada item
+func sendBatch(config *Config, payloads []*MetricPayload) ([]batchItemResult, error) {
+	sentAt := time.Now().UTC()
+	for _, payload := range payloads {
+		payload.SentAt = sentAt
+	}
+
+	jsonData, err := json.Marshal(payloads)
+	if err != nil {
+		return nil, errorf("agent.err.batch_encode", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(jsonData); err != nil {
+		return nil, errorf("agent.err.batch_compress", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, errorf("agent.err.batch_compress", err)
+	}
+
+	resp, err := postJSON(config, "/api/metrics/batch", buf.Bytes(), true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, errBatchUnsupported
+	case resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return nil, errBatchTooLarge
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+		return nil, errBatchRejected
+	case resp.StatusCode != http.StatusOK:
+		return nil, &httpStatusError{StatusCode: resp.StatusCode}
+	}
+
+	var body struct {
+		Results []batchItemResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errorf("agent.err.batch_response", err)
+	}
+
+	return body.Results, nil
+}
+
+// postJSON envia um corpo JSON (opcionalmente gzip) para o servidor
+func postJSON(config *Config, path string, body []byte, gzipped bool) (*http.Response, error) {
+	// Respeitar o Retry-After de uma resposta 429 anterior
+	if wait := time.Until(config.BackoffUntil); wait > 0 {
+		return nil, errorf("agent.err.send_deferred", wait.Round(time.Second))
 	}
 
 	// Criar request
-	url := config.ServerURL + "/api/metrics"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	url := config.ServerURL + path
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("erro ao criar request: %w", err)
+		return nil, errorf("agent.err.request_create", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", lang)
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 	if config.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+config.Token)
 	}
+	if config.SigningKey != "" {
+		if err := signRequest(req, body, config.SigningKey); err != nil {
+			return nil, err
+		}
+	}
 
-	// Enviar com timeout
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := config.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("erro ao enviar request: %w", err)
+		return nil, errorf("agent.err.request_send", err)
 	}
-	defer resp.Body.Close()
 
-	// Verificar resposta
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("servidor retornou status %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := retryAfter(resp)
+		config.BackoffUntil = time.Now().Add(wait)
+		log.Print(t("agent.rate_limited", wait))
 	}
 
-	return nil
+	return resp, nil
+}
+
+// retryAfter lê o header Retry-After (segundos ou data HTTP); sem header válido,
+// aguarda o intervalo de reenvio do spool
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := parseInt(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return spoolRetryInterval
 }
 
 // getLocalIP obtém o IP local da máquina
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		err       error
+		permanent bool
+	}{
+		{&httpStatusError{StatusCode: http.StatusBadRequest}, true},
+		{&httpStatusError{StatusCode: http.StatusUnprocessableEntity}, true},
+		{&httpStatusError{StatusCode: http.StatusUnauthorized}, false},
+		{&httpStatusError{StatusCode: http.StatusForbidden}, false},
+		{&httpStatusError{StatusCode: http.StatusTooManyRequests}, false},
+		{&httpStatusError{StatusCode: http.StatusInternalServerError}, false},
+		{errors.New("conexão recusada"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isPermanent(tt.err); got != tt.permanent {
+			t.Errorf("isPermanent(%v) = %v, esperado %v", tt.err, got, tt.permanent)
+		}
+	}
+}
+
+// fakeIngest simula o servidor: o lote responde batchStatus (200 processa todos os itens) e
+// o envio individual recusa com 422 as coletas do minuto invalid
+type fakeIngest struct {
+	batchStatus int
+	invalid     int
+	batches     int
+	singles     []int // minutos recebidos item a item
+}
+
+func (f *fakeIngest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/metrics/batch":
+		f.batches++
+		if f.batchStatus != http.StatusOK {
+			w.WriteHeader(f.batchStatus)
+			return
+		}
+		gz, _ := gzip.NewReader(r.Body)
+		var payloads []MetricPayload
+		json.NewDecoder(gz).Decode(&payloads)
+		results := make([]batchItemResult, len(payloads))
+		for i := range payloads {
+			results[i] = batchItemResult{Index: i, Status: "ok"}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+	case "/api/metrics":
+		var payload MetricPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		minute := payload.CollectedAt.Minute()
+		f.singles = append(f.singles, minute)
+		if minute == f.invalid {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+func TestFlushSpoolBatchFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		batchStatus int
+		singles     int // envios item a item esperados
+		pending     int // coletas que ficam no spool
+		fails       bool
+	}{
+		{"lote aceito", http.StatusOK, 0, 0, false},
+		{"lote recusado (400)", http.StatusBadRequest, 3, 0, false},
+		{"lote recusado (422)", http.StatusUnprocessableEntity, 3, 0, false},
+		{"servidor sem lote", http.StatusNotFound, 3, 0, false},
+		{"erro do servidor mantém o spool", http.StatusInternalServerError, 0, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeIngest{batchStatus: tt.batchStatus, invalid: 2}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			spool, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+			for minute := 1; minute <= 3; minute++ {
+				spool.Push(&MetricPayload{CollectedAt: base.Add(time.Duration(minute) * time.Minute)})
+			}
+
+			config := &Config{ServerURL: server.URL, HTTPClient: server.Client()}
+			err = flushSpool(config, spool)
+			if (err != nil) != tt.fails {
+				t.Fatalf("flushSpool = %v", err)
+			}
+			if len(fake.singles) != tt.singles {
+				t.Errorf("envios item a item = %v, esperado %d", fake.singles, tt.singles)
+			}
+			if spool.Len() != tt.pending {
+				t.Errorf("%d coletas no spool, esperado %d", spool.Len(), tt.pending)
+			}
+		})
+	}
+}
+
+func TestFlushSpoolRejectedBatchResumesBatching(t *testing.T) {
+	fake := &fakeIngest{batchStatus: http.StatusBadRequest, invalid: -1}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	spool, err := NewSpool(filepath.Join(t.TempDir(), "spool"), 2*batchMaxItems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < batchMaxItems+2; i++ {
+		spool.Push(&MetricPayload{CollectedAt: base.Add(time.Duration(i) * time.Second)})
+	}
+
+	// Só o primeiro lote é reenviado item a item; o restante volta a usar o lote
+	if err := flushSpool(&Config{ServerURL: server.URL, HTTPClient: server.Client()}, spool); err != nil {
+		t.Fatal(err)
+	}
+	if fake.batches != 2 {
+		t.Errorf("%d lotes enviados, esperado 2", fake.batches)
+	}
+	if got, want := len(fake.singles), batchMaxItems+2; got != want {
+		t.Errorf("%d envios item a item, esperado %d", got, want)
+	}
+	if spool.Len() != 0 {
+		t.Errorf("%d coletas no spool", spool.Len())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// signRequest assina o corpo com HMAC-SHA256 (timestamp + nonce + método + caminho + corpo),
+// permitindo ao servidor rejeitar payloads forjados ou repetidos
+func signRequest(req *http.Request, body []byte, key string) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return errorf("agent.err.nonce", err)
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "%s\n%s\n%s\n%s\n", timestamp, nonce, req.Method, req.URL.Path)
+	msg.Write(body)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(msg.Bytes())
+
+	req.Header.Set("X-Monitor-Timestamp", timestamp)
+	req.Header.Set("X-Monitor-Nonce", nonce)
+	req.Header.Set("X-Monitor-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignRequest(t *testing.T) {
+	body := []byte(`{"hostname": "web-01"}`)
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+	}{
+		{"envio individual", http.MethodPost, "https://monitor.example.com/api/metrics"},
+		{"envio em lote", http.MethodPost, "https://monitor.example.com/api/metrics/batch?x=1"},
+	}
+
+	nonces := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.url, nil)
+			if err := signRequest(req, body, "chave"); err != nil {
+				t.Fatal(err)
+			}
+
+			timestamp := req.Header.Get("X-Monitor-Timestamp")
+			nonce := req.Header.Get("X-Monitor-Nonce")
+			unix, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil || time.Since(time.Unix(unix, 0)) > time.Minute {
+				t.Errorf("timestamp inesperado %q", timestamp)
+			}
+			if len(nonce) != 32 || nonces[nonce] {
+				t.Errorf("nonce inesperado ou repetido %q", nonce)
+			}
+			nonces[nonce] = true
+
+			// Mesma mensagem que o servidor verifica: o caminho não inclui a query string
+			mac := hmac.New(sha256.New, []byte("chave"))
+			fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n", timestamp, nonce, tt.method, req.URL.Path)
+			mac.Write(body)
+			if got := req.Header.Get("X-Monitor-Signature"); got != hex.EncodeToString(mac.Sum(nil)) {
+				t.Errorf("assinatura %s não confere", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Spool é uma fila em disco para payloads que ainda não foram aceitos pelo servidor.
+// Cada payload é gravado em um arquivo próprio cujo nome deriva do horário de coleta,
+// de forma que a ordem lexicográfica dos arquivos é a ordem de reenvio.
+type Spool struct {
+	dir        string
+	maxEntries int
+	seq        int
+}
+
+// NewSpool cria (se necessário) o diretório da fila e retorna o spool
+func NewSpool(dir string, maxEntries int) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errorf("agent.err.spool_dir", err)
+	}
+
+	if maxEntries <= 0 {
+		maxEntries = 1
+	}
+
+	return &Spool{dir: dir, maxEntries: maxEntries}, nil
+}
+
+// Push grava um payload no final da fila, descartando os mais antigos se o limite for excedido
+func (s *Spool) Push(payload *MetricPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errorf("agent.err.payload_encode", err)
+	}
+
+	// Sequencial evita colisão de nomes para coletas no mesmo nanossegundo
+	s.seq = (s.seq + 1) % 1000000
+	name := fmt.Sprintf("%020d-%06d.json", payload.CollectedAt.UnixNano(), s.seq)
+
+	// Escrita atômica: arquivo temporário + rename
+	tmpPath := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return errorf("agent.err.spool_write", err)
+	}
+	if err := os.Rename(tmpPath, filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmpPath)
+		return errorf("agent.err.spool_write", err)
+	}
+
+	return s.trim()
+}
+
+// Pending retorna os nomes dos payloads pendentes, do mais antigo para o mais novo
+func (s *Spool) Pending() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errorf("agent.err.spool_read", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Len retorna a quantidade de payloads pendentes
+func (s *Spool) Len() int {
+	names, err := s.Pending()
+	if err != nil {
+		return 0
+	}
+	return len(names)
+}
+
+// Load lê um payload pendente
+func (s *Spool) Load(name string) (*MetricPayload, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, errorf("agent.err.spool_load", err)
+	}
+
+	var payload MetricPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errorf("agent.err.spool_corrupt", name, err)
+	}
+
+	return &payload, nil
+}
+
+// Remove apaga um payload da fila
+func (s *Spool) Remove(name string) error {
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		return errorf("agent.err.spool_remove", err)
+	}
+	return nil
+}
+
+// trim remove os payloads mais antigos quando a fila passa do limite
+func (s *Spool) trim() error {
+	names, err := s.Pending()
+	if err != nil {
+		return err
+	}
+
+	excess := len(names) - s.maxEntries
+	for i := 0; i < excess; i++ {
+		log.Print(t("agent.spool_full", s.maxEntries, names[i]))
+		if err := s.Remove(names[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
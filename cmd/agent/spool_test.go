@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSpoolOrderAndLimit(t *testing.T) {
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		max     int
+		pushed  []int // minutos após base, na ordem de gravação
+		pending []int // minutos esperados, na ordem de reenvio
+	}{
+		{"vazio", 10, nil, nil},
+		{"ordem de coleta", 10, []int{3, 1, 2}, []int{1, 2, 3}},
+		{"descarta os mais antigos", 2, []int{1, 2, 3, 4}, []int{3, 4}},
+		{"mesmo horário", 10, []int{5, 5}, []int{5, 5}},
+		{"limite mínimo", 0, []int{1, 2}, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spool, err := NewSpool(filepath.Join(t.TempDir(), "spool"), tt.max)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range tt.pushed {
+				if err := spool.Push(&MetricPayload{CollectedAt: base.Add(time.Duration(m) * time.Minute)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			names, err := spool.Pending()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(names) != len(tt.pending) {
+				t.Fatalf("pendentes = %d, esperado %d", len(names), len(tt.pending))
+			}
+			for i, name := range names {
+				payload, err := spool.Load(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := base.Add(time.Duration(tt.pending[i]) * time.Minute); !payload.CollectedAt.Equal(want) {
+					t.Errorf("pendente %d coletado em %s, esperado %s", i, payload.CollectedAt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSpoolIgnoresTemporaryAndCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewSpool(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Escrita interrompida (temporário) e arquivos estranhos não entram na fila
+	for _, name := range []string{".00000000000000000001-000001.json.tmp", "notas.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000002-000001.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := spool.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("pendentes = %v, esperado só o payload corrompido", names)
+	}
+	if _, err := spool.Load(names[0]); err == nil {
+		t.Error("Load de payload corrompido não retornou erro")
+	}
+
+	if err := spool.Remove(names[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := spool.Remove(names[0]); err != nil {
+		t.Errorf("remover de novo deveria ser ignorado: %v", err)
+	}
+	if spool.Len() != 0 {
+		t.Errorf("Len = %d depois de remover", spool.Len())
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.pem")
+	os.WriteFile(invalid, []byte("não é um certificado"), 0600)
+
+	tests := []struct {
+		name   string
+		config Config
+		fails  bool
+	}{
+		{"sem TLS próprio", Config{}, false},
+		{"CA inexistente", Config{CACert: filepath.Join(dir, "ausente.pem")}, true},
+		{"CA sem certificados", Config{CACert: invalid}, true},
+		{"certificado sem chave", Config{ClientCert: invalid}, true},
+		{"chave sem certificado", Config{ClientKey: invalid}, true},
+		{"par inválido", Config{ClientCert: invalid, ClientKey: invalid}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := newHTTPClient(&tt.config)
+			if tt.fails {
+				if err == nil {
+					t.Error("esperado erro")
+				}
+				return
+			}
+			if err != nil || client == nil {
+				t.Fatalf("newHTTPClient = %v, %v", client, err)
+			}
+		})
+	}
+}
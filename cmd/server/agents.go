@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+// agentTokenPrefix identifica visualmente as credenciais emitidas para agents
+const agentTokenPrefix = "mia_"
+
+// contextKey é o tipo das chaves guardadas no contexto do request
+type contextKey string
+
+// agentCredentialKey guarda a credencial do agent autenticado
+const agentCredentialKey contextKey = "agent_credential"
+
+// agentFromContext retorna a credencial do agent autenticado (nil se autenticado de outra forma)
+func agentFromContext(ctx context.Context) *storage.AgentCredential {
+	cred, _ := ctx.Value(agentCredentialKey).(*storage.AgentCredential)
+	return cred
+}
+
+// bearerToken extrai o token do header Authorization
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// tokenEquals compara tokens em tempo constante
+func tokenEquals(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// hashToken retorna o hash SHA-256 (hex) de um token; tokens têm alta entropia,
+// então um hash rápido é suficiente
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateToken cria um token aleatório com o prefixo informado
+func generateToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("erro ao gerar token: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// displayPrefix retorna o início do token, usado para identificá-lo sem expô-lo
+func displayPrefix(token string) string {
+	if len(token) < 12 {
+		return token
+	}
+	return token[:12]
+}
+
+// authMiddleware verifica a autenticação dos agents no envio de métricas: certificado de
+// cliente verificado, credencial própria do agent ou, se permitido, o AUTH_TOKEN compartilhado
+func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Certificado de cliente verificado identifica a máquina por si só
+		if identity := verifiedClientCert(r); identity != nil {
+			next(w, r.WithContext(context.WithValue(r.Context(), clientCertKey, identity)))
+			return
+		}
+		if s.config.RequireClientCert {
+			s.auditFailure(r, "auth.failure", "certificado de cliente ausente")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// Se não há token configurado nem inscrição de agents, permitir acesso
+		if s.config.Token == "" && s.config.EnrollToken == "" {
+			next(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			s.auditFailure(r, "auth.failure", "token ausente")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if s.config.SharedTokenIngest && s.config.Token != "" && tokenEquals(token, s.config.Token) {
+			next(w, r)
+			return
+		}
+
+		cred, err := s.storage.FindAgentCredentialByHash(hashToken(token))
+		if err != nil {
+			log.Printf("Erro ao verificar credencial: %v", err)
+			jsonError(w, r, "verify_credential_failed", http.StatusInternalServerError)
+			return
+		}
+		if cred == nil {
+			s.auditFailure(r, "auth.failure", "token inválido ou revogado")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := s.storage.TouchAgentCredential(cred.ID); err != nil {
+			log.Printf("Aviso: %v", err)
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), agentCredentialKey, cred)))
+	}
+}
+
+// bindAgentIdentity garante que um agent autenticado por credencial própria ou
+// certificado de cliente só envia métricas da própria máquina. Retorna um *apiError
+// quando o payload não pertence ao agent, ou o erro do banco.
+func (s *Server) bindAgentIdentity(r *http.Request, payload *storage.MetricPayload) error {
+	if identity := clientCertFromContext(r); identity != nil {
+		if identity.UUID == "" {
+			if !strings.EqualFold(payload.Hostname, identity.Hostname) {
+				return newAPIError("cert_hostname_mismatch")
+			}
+			// Certificado por hostname: o machine_uuid informado precisa ser o da máquina
+			// desse hostname, senão o agent escreveria no histórico de outra máquina
+			if payload.MachineUUID == "" {
+				return nil
+			}
+			owns, err := s.storage.HostnameOwnsUUID(identity.Hostname, payload.MachineUUID)
+			if err != nil {
+				return err
+			}
+			if !owns {
+				return newAPIError("cert_uuid_mismatch")
+			}
+			return nil
+		}
+		return bindUUID(payload, identity.UUID, "cert_uuid_mismatch")
+	}
+
+	if cred := agentFromContext(r.Context()); cred != nil {
+		return bindUUID(payload, cred.MachineUUID, "credential_uuid_mismatch")
+	}
+
+	return nil
+}
+
+// identityError responde a um erro de bindAgentIdentity: 403 quando o payload não pertence
+// ao agent, 500 quando a verificação falhou
+func identityError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		jsonError(w, r, apiErr.Code, http.StatusForbidden, apiErr.Args...)
+		return
+	}
+	log.Printf("Erro ao verificar identidade do agent: %v", err)
+	jsonError(w, r, "verify_identity_failed", http.StatusInternalServerError)
+}
+
+// bindUUID preenche o machine_uuid ausente ou verifica se corresponde ao esperado
+// (mismatch é o código do erro quando não corresponde)
+func bindUUID(payload *storage.MetricPayload, expected, mismatch string) error {
+	if payload.MachineUUID == "" {
+		payload.MachineUUID = expected
+		return nil
+	}
+
+	if !strings.EqualFold(payload.MachineUUID, expected) {
+		return newAPIError(mismatch)
+	}
+
+	return nil
+}
+
+// handleAgentEnroll inscreve um agent usando o token de inscrição e devolve sua credencial própria.
+// Corpo: {"machine_uuid": "...", "hostname": "..."}
+func (s *Server) handleAgentEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.config.EnrollToken == "" {
+		jsonError(w, r, "enroll_disabled", http.StatusForbidden)
+		return
+	}
+
+	if !tokenEquals(bearerToken(r), s.config.EnrollToken) {
+		s.auditFailure(r, "auth.failure", "token de inscrição inválido")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var body struct {
+		MachineUUID string `json:"machine_uuid"`
+		Hostname    string `json:"hostname"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		payloadError(w, r, err, "invalid_json")
+		return
+	}
+
+	if !isUUID(body.MachineUUID) {
+		jsonError(w, r, "invalid_machine_uuid", http.StatusBadRequest)
+		return
+	}
+	body.MachineUUID = strings.ToLower(body.MachineUUID)
+
+	// Uma máquina só se inscreve uma vez; credenciais perdidas ou revogadas são reemitidas via rotate
+	exists, err := s.storage.HasAgentCredential(body.MachineUUID)
+	if err != nil {
+		log.Printf("Erro ao verificar inscrição: %v", err)
+		jsonError(w, r, "enroll_failed", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		jsonError(w, r, "already_enrolled", http.StatusConflict)
+		return
+	}
+
+	token, err := generateToken(agentTokenPrefix)
+	if err != nil {
+		log.Printf("Erro ao inscrever agent: %v", err)
+		jsonError(w, r, "enroll_failed", http.StatusInternalServerError)
+		return
+	}
+
+	cred, err := s.storage.CreateAgentCredential(body.MachineUUID, body.Hostname, hashToken(token), displayPrefix(token))
+	if err != nil {
+		log.Printf("Erro ao inscrever agent: %v", err)
+		jsonError(w, r, "enroll_failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Agent inscrito: %s (uuid: %s, credencial: %d)", body.Hostname, body.MachineUUID, cred.ID)
+	s.audit(r, storage.AuditEntry{
+		Actor:  "enroll:" + body.MachineUUID,
+		Action: "agent.enroll",
+		Target: fmt.Sprintf("agent:%d", cred.ID),
+	}, nil, cred)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "ok",
+		"agent_id":   cred.ID,
+		"credential": token,
+	})
+}
+
+// handleAgents lista as credenciais de agents
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	agents, err := s.storage.ListAgentCredentials()
+	if err != nil {
+		log.Printf("Erro ao listar agents: %v", err)
+		jsonError(w, r, "list_agents_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agents": agents,
+	})
+}
+
+// handleAgentDetail trata /api/agents/{id} (DELETE revoga) e /api/agents/{id}/rotate (POST)
+func (s *Server) handleAgentDetail(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/agents/")
+	parts := strings.Split(path, "/")
+
+	agentID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		jsonError(w, r, "invalid_id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		cred, err := s.storage.GetAgentCredential(agentID)
+		if err != nil {
+			log.Printf("Erro ao buscar agent: %v", err)
+			jsonError(w, r, "get_agent_failed", http.StatusInternalServerError)
+			return
+		}
+		if cred == nil {
+			jsonError(w, r, "agent_not_found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cred)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		before, _ := s.storage.GetAgentCredential(agentID)
+		if err := s.storage.RevokeAgentCredential(agentID); err != nil {
+			s.agentError(w, r, "revoke_agent_failed", err)
+			return
+		}
+		log.Printf("Credencial de agent revogada: %d", agentID)
+		after, _ := s.storage.GetAgentCredential(agentID)
+		s.audit(r, storage.AuditEntry{Action: "agent.revoke", Target: fmt.Sprintf("agent:%d", agentID)}, before, after)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":   "ok",
+			"agent_id": agentID,
+		})
+
+	case len(parts) == 2 && parts[1] == "rotate" && r.Method == http.MethodPost:
+		token, err := generateToken(agentTokenPrefix)
+		if err != nil {
+			s.agentError(w, r, "rotate_agent_failed", err)
+			return
+		}
+		before, _ := s.storage.GetAgentCredential(agentID)
+		if err := s.storage.RotateAgentCredential(agentID, hashToken(token), displayPrefix(token)); err != nil {
+			s.agentError(w, r, "rotate_agent_failed", err)
+			return
+		}
+		log.Printf("Credencial de agent rotacionada: %d", agentID)
+		after, _ := s.storage.GetAgentCredential(agentID)
+		s.audit(r, storage.AuditEntry{Action: "agent.rotate", Target: fmt.Sprintf("agent:%d", agentID)}, before, after)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":     "ok",
+			"agent_id":   agentID,
+			"credential": token,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// agentError responde erros das operações sobre credenciais
+func (s *Server) agentError(w http.ResponseWriter, r *http.Request, code string, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		jsonError(w, r, "agent_not_found", http.StatusNotFound)
+		return
+	}
+	log.Printf("%s: %v", i18n.T(i18n.Default, code), err)
+	jsonError(w, r, code, http.StatusInternalServerError)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	const machine = "8a3c9f1e-0000-4000-8000-000000000001"
+	body := func(uuid string) string {
+		return `{"machine_uuid": "` + uuid + `", "hostname": "web-01", "ip": "10.0.0.1", "cpu_percent": 10}`
+	}
+
+	tests := []struct {
+		name    string
+		shared  bool // --shared-token-ingest
+		token   string
+		revoked bool
+		uuid    string
+		status  int
+	}{
+		{"sem token", true, "", false, machine, http.StatusUnauthorized},
+		{"token compartilhado", true, "token-de-teste", false, machine, http.StatusCreated},
+		{"token compartilhado desativado", false, "token-de-teste", false, machine, http.StatusUnauthorized},
+		{"token de administração não envia métricas", true, "admin-de-teste", false, machine, http.StatusUnauthorized},
+		{"credencial do agent", false, "agent", false, machine, http.StatusCreated},
+		{"credencial revogada", false, "agent", true, machine, http.StatusUnauthorized},
+		{"credencial de outra máquina", false, "agent", false, "8a3c9f1e-0000-4000-8000-000000000002", http.StatusForbidden},
+		{"token desconhecido", false, "mia_desconhecido", false, machine, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(c *Config) { c.SharedTokenIngest = tt.shared })
+
+			token := tt.token
+			if token == "agent" {
+				var err error
+				if token, err = generateToken(agentTokenPrefix); err != nil {
+					t.Fatal(err)
+				}
+				cred, err := s.storage.CreateAgentCredential(machine, "web-01", hashToken(token), displayPrefix(token))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.revoked {
+					if err := s.storage.RevokeAgentCredential(cred.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			header := map[string]string{}
+			if token != "" {
+				header = bearer(token)
+			}
+			w := serve(s, http.MethodPost, "/api/metrics", body(tt.uuid), header)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	a, err := generateToken(agentTokenPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := generateToken(agentTokenPrefix)
+
+	if !strings.HasPrefix(a, agentTokenPrefix) || len(a) != len(agentTokenPrefix)+64 {
+		t.Errorf("token %q fora do formato", a)
+	}
+	if a == b {
+		t.Error("tokens repetidos")
+	}
+	if p := displayPrefix(a); len(p) != 12 || !strings.HasPrefix(a, p) {
+		t.Errorf("displayPrefix = %q", p)
+	}
+	if hashToken(a) == hashToken(b) || len(hashToken(a)) != 64 {
+		t.Error("hashToken inesperado")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+const (
+	// anomalyCheckInterval é o intervalo da verificação dos relatórios recebidos
+	anomalyCheckInterval = 5 * time.Minute
+	// anomalyBaselineRefresh é o intervalo do recálculo das baselines
+	anomalyBaselineRefresh = time.Hour
+	// anomalyMinWeeks é quantas semanas de histórico uma hora da semana precisa para ter baseline
+	anomalyMinWeeks = 2
+	// anomalyMinStdDev é o desvio padrão mínimo considerado, em pontos percentuais: métricas
+	// quase constantes não viram anomalia por variações pequenas
+	anomalyMinStdDev = 2.0
+	// anomalyMaxAge ignora relatórios coletados há mais tempo (lotes reenviados após uma queda)
+	anomalyMaxAge = time.Hour
+	// anomalyBatch é quantos relatórios são lidos por consulta
+	anomalyBatch = 5000
+
+	// alertsMaxLimit é o máximo de ?limit= em /api/alerts
+	alertsMaxLimit = 1000
+)
+
+// anomalyTracker guarda as métricas de cada máquina que estão fora do padrão
+type anomalyTracker struct {
+	mu     sync.Mutex
+	active map[int64]map[string]storage.Anomaly
+}
+
+// newAnomalyTracker cria o registro de anomalias
+func newAnomalyTracker() *anomalyTracker {
+	return &anomalyTracker{active: make(map[int64]map[string]storage.Anomaly)}
+}
+
+// get retorna as anomalias ativas da máquina, na ordem de storage.ThresholdMetrics
+func (t *anomalyTracker) get(machineID int64) []storage.Anomaly {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var anomalies []storage.Anomaly
+	for _, metric := range storage.ThresholdMetrics {
+		if a, ok := t.active[machineID][metric]; ok {
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies
+}
+
+// set registra a anomalia da métrica (nil: a métrica voltou ao padrão) e indica se ela começou
+// agora e se o estado da máquina mudou. Uma anomalia que continua mantém o início.
+func (t *anomalyTracker) set(machineID int64, metric string, a *storage.Anomaly) (started, changed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, active := t.active[machineID][metric]
+	if a == nil {
+		if active {
+			delete(t.active[machineID], metric)
+		}
+		return false, active
+	}
+
+	if active {
+		a.Since = current.Since
+	}
+	if t.active[machineID] == nil {
+		t.active[machineID] = make(map[string]storage.Anomaly)
+	}
+	t.active[machineID][metric] = *a
+	return !active, true
+}
+
+// forget descarta as anomalias de uma máquina removida
+func (t *anomalyTracker) forget(machineID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.active, machineID)
+}
+
+// detectAnomaly compara o valor com a baseline; retorna nil se está dentro de k desvios padrão
+func detectAnomaly(metric string, value float64, b storage.Baseline, k float64, at time.Time) *storage.Anomaly {
+	sigma := (value - b.Mean) / math.Max(b.StdDev, anomalyMinStdDev)
+	if math.Abs(sigma) <= k {
+		return nil
+	}
+	return &storage.Anomaly{
+		Metric:   metric,
+		Value:    value,
+		Expected: math.Round(b.Mean*10) / 10,
+		StdDev:   math.Round(b.StdDev*10) / 10,
+		Sigma:    math.Round(sigma*10) / 10,
+		Since:    at,
+	}
+}
+
+// watchAnomalies compara os relatórios recebidos com a baseline de cada máquina, métrica e hora
+// da semana (média e desvio padrão dos últimos --anomaly-history dias). Um valor a mais de
+// --anomaly-sigma desvios padrão da média é uma anomalia: ela aparece na máquina enquanto durar
+// e dispara o alerta anomaly.<métrica> uma vez, ao começar.
+func (s *Server) watchAnomalies() {
+	lastID, err := s.storage.LastMetricID()
+	if err != nil {
+		log.Printf("Erro ao iniciar detecção de anomalias: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(anomalyCheckInterval)
+	defer ticker.Stop()
+
+	var baselines storage.Baselines
+	var refreshed time.Time
+	for {
+		if time.Since(refreshed) >= anomalyBaselineRefresh {
+			now := time.Now()
+			updated, err := s.storage.ComputeBaselines(now.AddDate(0, 0, -s.config.AnomalyHistory), now, anomalyMinWeeks)
+			if err != nil {
+				log.Printf("Erro ao calcular baselines: %v", err)
+			} else {
+				baselines, refreshed = updated, now
+			}
+		}
+		if baselines != nil {
+			lastID = s.checkAnomalies(baselines, lastID)
+		}
+		<-ticker.C
+	}
+}
+
+// checkAnomalies verifica os relatórios recebidos depois de afterID e retorna o último verificado
+func (s *Server) checkAnomalies(baselines storage.Baselines, afterID int64) int64 {
+	changed := make(map[int64]bool)
+	var started []storage.Anomaly
+	var startedOn []int64
+
+	for {
+		samples, err := s.storage.MetricsAfter(afterID, anomalyBatch)
+		if err != nil {
+			log.Printf("Erro ao verificar anomalias: %v", err)
+			break
+		}
+		for _, sample := range samples {
+			afterID = sample.ID
+			if time.Since(sample.CollectedAt) > anomalyMaxAge {
+				continue
+			}
+			hour := storage.HourOfWeek(sample.CollectedAt)
+			for _, metric := range storage.ThresholdMetrics {
+				var anomaly *storage.Anomaly
+				if b, ok := baselines[storage.BaselineKey{MachineID: sample.MachineID, Metric: metric, HourOfWeek: hour}]; ok {
+					anomaly = detectAnomaly(metric, sample.Values[metric], b, s.config.AnomalySigma, sample.CollectedAt)
+				}
+				isNew, isChanged := s.anomalies.set(sample.MachineID, metric, anomaly)
+				if isChanged {
+					changed[sample.MachineID] = true
+				}
+				if isNew {
+					started = append(started, *anomaly)
+					startedOn = append(startedOn, sample.MachineID)
+				}
+			}
+		}
+		if len(samples) < anomalyBatch {
+			break
+		}
+	}
+
+	for i, a := range started {
+		log.Printf("Anomalia na máquina %d: %s em %.1f%% (esperado %.1f%% ± %.1f)", startedOn[i], a.Metric, a.Value, a.Expected, a.StdDev)
+		s.publishAlert(startedOn[i], "anomaly."+a.Metric, a.Value, a.Expected, a.StdDev)
+	}
+	for machineID := range changed {
+		s.publishMachine(machineID)
+	}
+	return afterID
+}
+
+// alertEntry é um alerta em /api/alerts, com a mensagem no idioma do request
+type alertEntry struct {
+	storage.Alert
+	Message string `json:"message"`
+}
+
+// handleAlerts consulta os alertas registrados das máquinas do escopo (anomalias, disco
+// enchendo, conflitos, relógio e payloads recusados), do mais recente para o mais antigo.
+// Filtros: machine_id, kind (prefixo, ex.: "anomaly."), since/until (RFC3339), before (ID), limit
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := storage.AlertFilter{
+		Kind:  q.Get("kind"),
+		Limit: 100,
+	}
+
+	for name, dest := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				jsonError(w, r, "invalid_time_param", http.StatusBadRequest, name)
+				return
+			}
+			*dest = t
+		}
+	}
+	if v := q.Get("machine_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			jsonError(w, r, "invalid_id", http.StatusBadRequest)
+			return
+		}
+		filter.MachineID = id
+	}
+	if v := q.Get("before"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			jsonError(w, r, "invalid_before", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			jsonError(w, r, "invalid_limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(limit, alertsMaxLimit)
+	}
+
+	alerts, err := s.storage.ListAlerts(scopeFor(r), filter)
+	if err != nil {
+		log.Printf("Erro ao buscar alertas: %v", err)
+		jsonError(w, r, "list_alerts_failed", http.StatusInternalServerError)
+		return
+	}
+
+	lang := requestLanguage(r)
+	entries := make([]alertEntry, len(alerts))
+	for i, a := range alerts {
+		entries[i] = alertEntry{Alert: a, Message: i18n.T(lang, "alert."+a.Kind, a.Args...)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alerts": entries,
+	})
+}
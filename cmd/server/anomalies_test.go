@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestDetectAnomaly(t *testing.T) {
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	baseline := storage.Baseline{Mean: 20, StdDev: 5, Samples: 10}
+
+	tests := []struct {
+		name     string
+		value    float64
+		baseline storage.Baseline
+		sigma    float64 // 0: sem anomalia
+	}{
+		{"dentro do padrão", 30, baseline, 0},
+		{"no limite", 35, baseline, 0},
+		{"acima", 50, baseline, 6},
+		{"abaixo", 2, baseline, -3.6},
+		// Desvio padrão abaixo de anomalyMinStdDev: 5 pontos acima são 2,5 desvios, não 10
+		{"desvio mínimo", 25, storage.Baseline{Mean: 20, StdDev: 0.5}, 0},
+		{"desvio mínimo excedido", 30, storage.Baseline{Mean: 20, StdDev: 0.5}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := detectAnomaly(storage.MetricCPU, tt.value, tt.baseline, 3, at)
+			if tt.sigma == 0 {
+				if a != nil {
+					t.Errorf("anomalia inesperada: %+v", a)
+				}
+				return
+			}
+			if a == nil {
+				t.Fatal("anomalia não detectada")
+			}
+			if a.Sigma != tt.sigma || a.Value != tt.value || a.Expected != tt.baseline.Mean || !a.Since.Equal(at) {
+				t.Errorf("anomalia = %+v, esperado %v desvios", a, tt.sigma)
+			}
+		})
+	}
+}
+
+func TestAnomalyTrackerSet(t *testing.T) {
+	tracker := newAnomalyTracker()
+	first := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	anomaly := func(at time.Time) *storage.Anomaly {
+		return &storage.Anomaly{Metric: storage.MetricCPU, Value: 90, Since: at}
+	}
+
+	steps := []struct {
+		name    string
+		metric  string
+		anomaly *storage.Anomaly
+		started bool
+		changed bool
+		active  int
+	}{
+		{"sem anomalia", storage.MetricCPU, nil, false, false, 0},
+		{"começa", storage.MetricCPU, anomaly(first), true, true, 1},
+		{"continua", storage.MetricCPU, anomaly(first.Add(5 * time.Minute)), false, true, 1},
+		{"outra métrica", storage.MetricDisk, &storage.Anomaly{Metric: storage.MetricDisk, Since: first}, true, true, 2},
+		{"volta ao padrão", storage.MetricCPU, nil, false, true, 1},
+		{"continua no padrão", storage.MetricCPU, nil, false, false, 1},
+	}
+
+	for _, step := range steps {
+		started, changed := tracker.set(1, step.metric, step.anomaly)
+		if started != step.started || changed != step.changed {
+			t.Errorf("%s: set = %v, %v, esperado %v, %v", step.name, started, changed, step.started, step.changed)
+		}
+		if active := tracker.get(1); len(active) != step.active {
+			t.Errorf("%s: %d anomalias ativas, esperado %d", step.name, len(active), step.active)
+		}
+		if step.name == "continua" && !tracker.get(1)[0].Since.Equal(first) {
+			t.Errorf("anomalia que continua deveria manter o início %v", first)
+		}
+	}
+
+	tracker.forget(1)
+	if active := tracker.get(1); len(active) != 0 {
+		t.Errorf("forget: %d anomalias ativas", len(active))
+	}
+}
+
+func TestCheckAnomalies(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.AnomalySigma = 3 })
+	now := time.Now().Truncate(time.Second)
+	save := func(cpu float64, at time.Time) int64 {
+		t.Helper()
+		result, err := s.storage.SaveMetrics(&storage.MetricPayload{
+			MachineUUID: "8a3c9f1e-0000-4000-8000-000000000001", Hostname: "web-01", IP: "10.0.0.1",
+			GroupName: "web", CPUPercent: cpu, CollectedAt: at,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.MachineID
+	}
+	id := save(10, now.Add(-2*time.Minute))
+
+	// Baseline só de CPU: memória e disco sem baseline não viram anomalia
+	baselines := make(storage.Baselines)
+	for _, at := range []time.Time{now.Add(-3 * time.Hour), now.Add(-time.Minute), now} {
+		baselines[storage.BaselineKey{MachineID: id, Metric: storage.MetricCPU, HourOfWeek: storage.HourOfWeek(at)}] = storage.Baseline{Mean: 10, StdDev: 2}
+	}
+	lastID := s.checkAnomalies(baselines, 0)
+
+	steps := []struct {
+		name   string
+		cpu    float64
+		at     time.Time
+		active int
+		alerts int
+	}{
+		// Relatórios antigos (lotes reenviados) não contam
+		{"relatório antigo", 95, now.Add(-3 * time.Hour), 0, 0},
+		{"começa", 95, now.Add(-time.Minute), 1, 1},
+		{"continua sem novo alerta", 90, now, 1, 1},
+		{"volta ao padrão", 11, now.Add(time.Second), 0, 1},
+	}
+
+	for _, step := range steps {
+		save(step.cpu, step.at)
+		lastID = s.checkAnomalies(baselines, lastID)
+		if active := s.anomalies.get(id); len(active) != step.active {
+			t.Errorf("%s: %d anomalias ativas, esperado %d", step.name, len(active), step.active)
+		}
+		alerts, err := s.storage.ListAlerts(nil, storage.AlertFilter{Kind: "anomaly.", Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(alerts) != step.alerts {
+			t.Errorf("%s: %d alertas, esperado %d", step.name, len(alerts), step.alerts)
+		}
+	}
+
+	w := serve(s, http.MethodGet, "/api/alerts?kind=anomaly.cpu", "", bearer("admin-de-teste"))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"kind":"anomaly.cpu"`) {
+		t.Errorf("status = %d: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+const (
+	// auditMaxLimit limita a quantidade de entradas por consulta em /api/audit
+	auditMaxLimit = 1000
+
+	// auditFailureWindow é a janela de agregação das falhas de autenticação: por IP e ação,
+	// só a primeira falha da janela é gravada e as demais viram uma entrada com a contagem
+	auditFailureWindow = time.Minute
+)
+
+// failureWindow conta as falhas de um IP e ação na janela atual
+type failureWindow struct {
+	ip, action string
+	start      time.Time
+	suppressed int    // falhas não gravadas depois da primeira
+	reason     string // motivo da última falha suprimida
+}
+
+// failureThrottle agrega as falhas de autenticação por IP e ação, para que credenciais
+// inválidas em massa não gravem uma linha por request no log de auditoria
+type failureThrottle struct {
+	mu      sync.Mutex
+	windows map[string]*failureWindow
+}
+
+// newFailureThrottle cria o agregador de falhas
+func newFailureThrottle() *failureThrottle {
+	return &failureThrottle{windows: make(map[string]*failureWindow)}
+}
+
+// admit indica se a falha deve ser gravada: a primeira do IP e ação na janela é gravada e as
+// demais são apenas contadas. Ao abrir uma nova janela, retorna a anterior se ela teve falhas
+// suprimidas, para que a contagem seja gravada.
+func (t *failureThrottle) admit(ip, action, reason string, now time.Time) (bool, *failureWindow) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := ip + "|" + action
+	w, ok := t.windows[key]
+	if ok && now.Sub(w.start) < auditFailureWindow {
+		w.suppressed++
+		w.reason = reason
+		return false, nil
+	}
+
+	t.windows[key] = &failureWindow{ip: ip, action: action, start: now}
+	if ok && w.suppressed > 0 {
+		return true, w
+	}
+	return true, nil
+}
+
+// expired remove as janelas vencidas e retorna as que tiveram falhas suprimidas
+func (t *failureThrottle) expired(now time.Time) []failureWindow {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var result []failureWindow
+	for key, w := range t.windows {
+		if now.Sub(w.start) < auditFailureWindow {
+			continue
+		}
+		delete(t.windows, key)
+		if w.suppressed > 0 {
+			result = append(result, *w)
+		}
+	}
+	return result
+}
+
+// clientIP retorna o IP de origem do request; X-Forwarded-For só é considerado com --trust-proxy
+func (s *Server) clientIP(r *http.Request) string {
+	if s.config.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// actorFor descreve quem fez o request para o log de auditoria
+func actorFor(r *http.Request) string {
+	if p := principalFromContext(r.Context()); p != nil {
+		switch {
+		case p.User != nil:
+			return "user:" + p.User.Username
+		case p.APIKey != nil:
+			return "apikey:" + p.APIKey.Name
+		case p.AdminToken:
+			return "token"
+		}
+	}
+	if cred := agentFromContext(r.Context()); cred != nil {
+		return "agent:" + cred.MachineUUID
+	}
+	if identity := clientCertFromContext(r); identity != nil {
+		return "cert:" + identity.String()
+	}
+	return "anônimo"
+}
+
+// audit grava um evento no log de auditoria. before e after (opcionais) são gravados em JSON;
+// falhas de gravação são apenas logadas para não interromper o request.
+func (s *Server) audit(r *http.Request, entry storage.AuditEntry, before, after interface{}) {
+	if entry.Actor == "" {
+		entry.Actor = actorFor(r)
+	}
+	entry.IP = s.clientIP(r)
+	entry.Before = auditJSON(before)
+	entry.After = auditJSON(after)
+
+	if err := s.storage.RecordAudit(entry); err != nil {
+		log.Printf("Aviso: %v", err)
+	}
+}
+
+// auditFailure registra uma falha de autenticação ou autorização; repetições do mesmo IP
+// dentro de auditFailureWindow são agregadas por flushAuditFailures
+func (s *Server) auditFailure(r *http.Request, action, reason string) {
+	record, previous := s.failures.admit(s.clientIP(r), action, reason, time.Now())
+	if previous != nil {
+		s.auditSuppressed(*previous)
+	}
+	if !record {
+		return
+	}
+	s.audit(r, storage.AuditEntry{
+		Action:  action,
+		Target:  r.Method + " " + r.URL.Path,
+		Details: reason,
+	}, nil, nil)
+}
+
+// flushAuditFailures grava, a cada janela, a contagem das falhas agregadas de cada IP e ação
+func (s *Server) flushAuditFailures() {
+	ticker := time.NewTicker(auditFailureWindow)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, w := range s.failures.expired(now) {
+			s.auditSuppressed(w)
+		}
+	}
+}
+
+// auditSuppressed grava uma entrada com a contagem das falhas não gravadas de uma janela
+func (s *Server) auditSuppressed(w failureWindow) {
+	entry := storage.AuditEntry{
+		Actor:   "anônimo",
+		Action:  w.action,
+		IP:      w.ip,
+		Details: fmt.Sprintf("%d falhas agregadas desde %s (última: %s)", w.suppressed, w.start.UTC().Format(time.RFC3339), w.reason),
+	}
+	if err := s.storage.RecordAudit(entry); err != nil {
+		log.Printf("Aviso: %v", err)
+	}
+}
+
+// archiveAudit aplica --audit-retention: remove as entradas mais antigas do log de
+// auditoria (anteriores a now menos a retenção), gravando-as antes em JSON Lines em
+// --audit-archive-dir, se configurado
+func (s *Server) archiveAudit(now time.Time) {
+	if s.config.AuditRetention <= 0 {
+		return
+	}
+
+	var archive func(storage.AuditEntry) error
+	if s.config.AuditArchiveDir != "" {
+		if err := os.MkdirAll(s.config.AuditArchiveDir, 0700); err != nil {
+			log.Printf("Erro ao arquivar auditoria: %v", err)
+			return
+		}
+		path := filepath.Join(s.config.AuditArchiveDir, "audit-"+now.Format("2006-01-02")+".jsonl")
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			log.Printf("Erro ao arquivar auditoria: %v", err)
+			return
+		}
+		defer file.Close()
+		encoder := json.NewEncoder(file)
+		archive = func(e storage.AuditEntry) error { return encoder.Encode(e) }
+	}
+
+	deleted, err := s.storage.ArchiveAudit(now.AddDate(0, 0, -s.config.AuditRetention), archive)
+	if err != nil {
+		log.Printf("Erro ao arquivar auditoria: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Auditoria: %d entradas anteriores a %d dias removidas", deleted, s.config.AuditRetention)
+	}
+}
+
+// auditJSON serializa um estado para o log de auditoria (vazio se nil)
+func auditJSON(v interface{}) json.RawMessage {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// handleAudit consulta o log de auditoria.
+// Filtros: actor, action (prefixo), target, since/until (RFC3339), before (ID), limit
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := storage.AuditFilter{
+		Actor:  q.Get("actor"),
+		Action: q.Get("action"),
+		Target: q.Get("target"),
+		Limit:  100,
+	}
+
+	for name, dest := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				jsonError(w, r, "invalid_time_param", http.StatusBadRequest, name)
+				return
+			}
+			*dest = t
+		}
+	}
+	if v := q.Get("before"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			jsonError(w, r, "invalid_before", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			jsonError(w, r, "invalid_limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(limit, auditMaxLimit)
+	}
+
+	entries, err := s.storage.ListAudit(filter)
+	if err != nil {
+		log.Printf("Erro ao buscar auditoria: %v", err)
+		jsonError(w, r, "audit_query_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestFailureThrottle(t *testing.T) {
+	ft := newFailureThrottle()
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		ip         string
+		action     string
+		at         time.Duration // depois de start
+		record     bool
+		suppressed int // falhas suprimidas da janela anterior devolvida (0: nenhuma)
+	}{
+		{"primeira falha", "10.0.0.1", "auth.failure", 0, true, 0},
+		{"repetição na janela", "10.0.0.1", "auth.failure", 10 * time.Second, false, 0},
+		{"outra repetição", "10.0.0.1", "auth.failure", 20 * time.Second, false, 0},
+		{"outro IP", "10.0.0.2", "auth.failure", 20 * time.Second, true, 0},
+		{"outra ação", "10.0.0.1", "auth.forbidden", 20 * time.Second, true, 0},
+		{"nova janela devolve a contagem", "10.0.0.1", "auth.failure", 70 * time.Second, true, 2},
+		{"janela seguinte sem repetições", "10.0.0.1", "auth.failure", 140 * time.Second, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, previous := ft.admit(tt.ip, tt.action, "token inválido", start.Add(tt.at))
+			if record != tt.record {
+				t.Errorf("record = %v, esperado %v", record, tt.record)
+			}
+			got := 0
+			if previous != nil {
+				got = previous.suppressed
+			}
+			if got != tt.suppressed {
+				t.Errorf("suprimidas = %d, esperado %d", got, tt.suppressed)
+			}
+		})
+	}
+
+	ft.admit("10.0.0.3", "auth.failure", "a", start.Add(200*time.Second))
+	ft.admit("10.0.0.3", "auth.failure", "b", start.Add(201*time.Second))
+	windows := ft.expired(start.Add(300 * time.Second))
+	if len(windows) != 1 || windows[0].ip != "10.0.0.3" || windows[0].reason != "b" {
+		t.Errorf("expired = %+v, esperado só a janela de 10.0.0.3", windows)
+	}
+	if len(ft.windows) != 0 {
+		t.Errorf("%d janelas restantes", len(ft.windows))
+	}
+}
+
+func TestAuditFailureAggregation(t *testing.T) {
+	s := newTestServer(t, nil)
+	for i := 0; i < 5; i++ {
+		serve(s, http.MethodGet, "/api/users", "", bearer("token-invalido"))
+	}
+
+	entries, err := s.storage.ListAudit(storage.AuditFilter{Action: "auth.failure", Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d entradas gravadas, esperado 1", len(entries))
+	}
+
+	for _, w := range s.failures.expired(time.Now().Add(auditFailureWindow)) {
+		s.auditSuppressed(w)
+	}
+	entries, _ = s.storage.ListAudit(storage.AuditFilter{Action: "auth.failure", Limit: 100})
+	if len(entries) != 2 || entries[0].Details == "" {
+		t.Errorf("entradas = %+v, esperado a contagem agregada", entries)
+	}
+}
+
+func TestArchiveAuditRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention int
+		archive   bool
+	}{
+		{"retenção desativada", 0, true},
+		{"retenção com arquivamento", 1, true},
+		{"retenção sem arquivamento", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "arquivo")
+			s := newTestServer(t, func(c *Config) {
+				c.AuditRetention = tt.retention
+				if tt.archive {
+					c.AuditArchiveDir = dir
+				}
+			})
+			if err := s.storage.RecordAudit(storage.AuditEntry{Actor: "token", Action: "user.create"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Dois dias depois, a entrada passou da retenção de 1 dia
+			s.archiveAudit(time.Now().AddDate(0, 0, 2))
+
+			entries, err := s.storage.ListAudit(storage.AuditFilter{Limit: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantEntries := 1
+			if tt.retention > 0 {
+				wantEntries = 0
+			}
+			if len(entries) != wantEntries {
+				t.Errorf("%d entradas, esperado %d", len(entries), wantEntries)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+			wantFiles := 0
+			if tt.retention > 0 && tt.archive {
+				wantFiles = 1
+			}
+			if len(files) != wantFiles {
+				t.Fatalf("%d arquivos, esperado %d", len(files), wantFiles)
+			}
+			if wantFiles == 1 {
+				f, _ := os.Open(files[0])
+				defer f.Close()
+				lines := 0
+				for scanner := bufio.NewScanner(f); scanner.Scan(); {
+					lines++
+				}
+				if lines != 1 {
+					t.Errorf("%d linhas arquivadas, esperado 1", lines)
+				}
+			}
+		})
+	}
+}
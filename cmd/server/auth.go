@@ -0,0 +1,814 @@
+package main
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitor-infra/internal/dashboard"
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+// sessionCookie é o nome do cookie de sessão do dashboard
+const sessionCookie = "monitor_session"
+
+// apiKeyPrefix identifica visualmente as chaves de API
+const apiKeyPrefix = "mik_"
+
+// passwordIterations é o custo do PBKDF2 usado no hash das senhas
+const passwordIterations = 210000
+
+// minPasswordLength é o tamanho mínimo aceito para senhas
+const minPasswordLength = 8
+
+// principalKey guarda no contexto quem fez o request
+const principalKey contextKey = "principal"
+
+// roleRank ordena os papéis para comparação
+var roleRank = map[string]int{
+	storage.RoleViewer: 1,
+	storage.RoleEditor: 2,
+	storage.RoleAdmin:  3,
+}
+
+// principal identifica o autor de um request autenticado
+type principal struct {
+	User       *storage.User   // login pelo dashboard
+	APIKey     *storage.APIKey // bearer com chave de API
+	KeyOwner   *storage.User   // dono da chave de API (nil em chaves criadas com o ADMIN_TOKEN)
+	AdminToken bool            // bearer com o ADMIN_TOKEN
+}
+
+// role retorna o papel efetivo; chaves de API são sempre somente leitura
+func (p *principal) role() string {
+	switch {
+	case p.AdminToken:
+		return storage.RoleAdmin
+	case p.User != nil:
+		return p.User.Role
+	default:
+		return storage.RoleViewer
+	}
+}
+
+// scope retorna os grupos visíveis (nil = todos); chaves herdam o escopo do dono
+func (p *principal) scope() *storage.Scope {
+	switch {
+	case p.User != nil:
+		return p.User.Scope()
+	case p.KeyOwner != nil:
+		return p.KeyOwner.Scope()
+	default:
+		return nil
+	}
+}
+
+// principalFromContext retorna o autor do request (nil em modo público)
+func principalFromContext(ctx context.Context) *principal {
+	p, _ := ctx.Value(principalKey).(*principal)
+	return p
+}
+
+// scopeFor retorna o escopo de grupos do autor do request (nil = todos os grupos)
+func scopeFor(r *http.Request) *storage.Scope {
+	if p := principalFromContext(r.Context()); p != nil {
+		return p.scope()
+	}
+	return nil
+}
+
+// hashPassword gera o hash PBKDF2-SHA256 de uma senha no formato pbkdf2-sha256$iterações$salt$hash
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("erro ao gerar salt: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, 32)
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		hex.EncodeToString(salt), hex.EncodeToString(key)), nil
+}
+
+// verifyPassword compara uma senha com o hash guardado
+func verifyPassword(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// dummyPasswordHash é usado no login de usuários inexistentes para manter o mesmo tempo de resposta
+var dummyPasswordHash, _ = hashPassword("monitor-infra")
+
+// seedAdmin cria o primeiro usuário a partir de ADMIN_USER/ADMIN_PASSWORD se ainda não houver nenhum
+func (s *Server) seedAdmin(username, password string) error {
+	count, err := s.storage.CountUsers()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if username == "" || password == "" {
+		if !s.config.PublicRead {
+			log.Println("Aviso: nenhum usuário cadastrado; defina ADMIN_USER e ADMIN_PASSWORD para acessar o dashboard")
+		}
+		return nil
+	}
+
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("ADMIN_PASSWORD deve ter pelo menos %d caracteres", minPasswordLength)
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if _, err := s.storage.CreateUser(username, hash, storage.RoleAdmin, nil); err != nil {
+		return err
+	}
+
+	log.Printf("Usuário administrador criado: %s", username)
+	return nil
+}
+
+// authenticate identifica o autor do request: ADMIN_TOKEN ou chave de API no header
+// Authorization, ou cookie de sessão do dashboard. Retorna nil se não autenticado.
+// O AUTH_TOKEN não é aceito aqui: ele vale apenas para o envio de métricas.
+func (s *Server) authenticate(r *http.Request) (*principal, error) {
+	if token := bearerToken(r); token != "" {
+		if s.config.AdminToken != "" && tokenEquals(token, s.config.AdminToken) {
+			return &principal{AdminToken: true}, nil
+		}
+
+		key, err := s.storage.FindAPIKeyByHash(hashToken(token))
+		if err != nil || key == nil {
+			return nil, err
+		}
+		if err := s.storage.TouchAPIKey(key.ID); err != nil {
+			log.Printf("Aviso: %v", err)
+		}
+
+		p := &principal{APIKey: key}
+		if key.UserID != nil {
+			if p.KeyOwner, err = s.storage.GetUser(*key.UserID); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+
+	user, err := s.storage.GetSessionUser(hashToken(cookie.Value))
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &principal{User: user}, nil
+}
+
+// readMiddleware exige autenticação nas rotas de leitura (API e dashboard),
+// exceto com --public-read
+func (s *Server) readMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.authenticate(r)
+		if err != nil {
+			log.Printf("Erro ao verificar autenticação: %v", err)
+			jsonError(w, r, "auth_check_failed", http.StatusInternalServerError)
+			return
+		}
+		if p == nil {
+			// Token apresentado e recusado é registrado; visitas sem login apenas redirecionam
+			if bearerToken(r) != "" {
+				s.auditFailure(r, "auth.failure", "token inválido ou revogado")
+			}
+			if s.config.PublicRead {
+				next(w, r)
+				return
+			}
+			unauthorized(w, r)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), principalKey, p)))
+	}
+}
+
+// adminMiddleware restringe rotas ao ADMIN_TOKEN e a usuários administradores
+func (s *Server) adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.roleMiddleware(storage.RoleAdmin, next)
+}
+
+// editorMiddleware restringe rotas que alteram máquinas a editores e administradores
+func (s *Server) editorMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.roleMiddleware(storage.RoleEditor, next)
+}
+
+// roleMiddleware exige um papel mínimo; chaves de API dão acesso apenas à leitura
+func (s *Server) roleMiddleware(minRole string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// --public-read abre apenas a leitura: alterações sempre exigem credencial com o papel
+		p, err := s.authenticate(r)
+		if err != nil {
+			log.Printf("Erro ao verificar autenticação: %v", err)
+			jsonError(w, r, "auth_check_failed", http.StatusInternalServerError)
+			return
+		}
+		if p == nil {
+			s.auditFailure(r, "auth.failure", "credencial ausente ou inválida")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), principalKey, p))
+		if roleRank[p.role()] < roleRank[minRole] {
+			s.auditFailure(r, "auth.forbidden", "requer papel "+minRole)
+			jsonError(w, r, "forbidden_role", http.StatusForbidden)
+			return
+		}
+
+		// Requests com cookie precisam vir do próprio dashboard
+		if p.User != nil && r.Method != http.MethodGet && !sameOrigin(r) {
+			s.auditFailure(r, "auth.forbidden", "origem não permitida: "+r.Header.Get("Origin"))
+			jsonError(w, r, "origin_not_allowed", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// unauthorized responde 401 na API e redireciona para o login no dashboard
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		jsonError(w, r, "auth_required", http.StatusUnauthorized)
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// sameOrigin verifica se o header Origin (quando presente) aponta para este servidor
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host
+}
+
+// handleLogin exibe o formulário de login (GET) e cria a sessão (POST)
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.dashboard.Render(w, r, "login.html", dashboard.Page{
+			Lang:  requestLanguage(r),
+			Error: r.URL.Query().Has("error"),
+		})
+
+	case http.MethodPost:
+		username := strings.TrimSpace(r.FormValue("username"))
+		password := r.FormValue("password")
+
+		user, passwordHash, err := s.storage.GetUserCredentials(username)
+		if err != nil {
+			log.Printf("Erro no login: %v", err)
+			http.Error(w, "Erro interno", http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			verifyPassword(password, dummyPasswordHash)
+			s.audit(r, storage.AuditEntry{Actor: "user:" + username, Action: "auth.failure", Target: "login", Details: "usuário inexistente"}, nil, nil)
+			http.Redirect(w, r, "/login?error=1", http.StatusSeeOther)
+			return
+		}
+		if !verifyPassword(password, passwordHash) {
+			log.Printf("Falha de login: %s", username)
+			s.audit(r, storage.AuditEntry{Actor: "user:" + username, Action: "auth.failure", Target: "login", Details: "senha incorreta"}, nil, nil)
+			http.Redirect(w, r, "/login?error=1", http.StatusSeeOther)
+			return
+		}
+
+		token, err := generateToken("")
+		if err != nil {
+			log.Printf("Erro no login: %v", err)
+			http.Error(w, "Erro interno", http.StatusInternalServerError)
+			return
+		}
+		expiresAt := time.Now().Add(s.config.SessionTTL)
+		if err := s.storage.CreateSession(hashToken(token), user.ID, expiresAt); err != nil {
+			log.Printf("Erro no login: %v", err)
+			http.Error(w, "Erro interno", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    token,
+			Path:     "/",
+			Expires:  expiresAt,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		s.audit(r, storage.AuditEntry{Actor: "user:" + user.Username, Action: "auth.login", Target: "login"}, nil, nil)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLogout encerra a sessão do dashboard
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if cookie, err := r.Cookie(sessionCookie); err == nil && cookie.Value != "" {
+		if user, _ := s.storage.GetSessionUser(hashToken(cookie.Value)); user != nil {
+			s.audit(r, storage.AuditEntry{Actor: "user:" + user.Username, Action: "auth.logout", Target: "login"}, nil, nil)
+		}
+		if err := s.storage.DeleteSession(hashToken(cookie.Value)); err != nil {
+			log.Printf("Aviso: erro ao encerrar sessão: %v", err)
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// handleMe retorna quem está autenticado (usado pelo dashboard)
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		s.roleMiddleware(storage.RoleViewer, s.handleMeUpdate)(w, r)
+		return
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.meInfo(r))
+}
+
+// meInfo descreve quem está autenticado; é a resposta de GET /api/me e parte do estado
+// inicial do dashboard
+func (s *Server) meInfo(r *http.Request) map[string]interface{} {
+	// can_edit segue a mesma regra de editorMiddleware (papel editor ou admin)
+	response := map[string]interface{}{
+		"public_read": s.config.PublicRead,
+		"can_edit":    false,
+		"language":    requestLanguage(r),
+		"languages":   i18n.Supported(),
+	}
+	if p := principalFromContext(r.Context()); p != nil {
+		response["role"] = p.role()
+		if roleRank[p.role()] >= roleRank[storage.RoleEditor] {
+			response["can_edit"] = true
+		}
+		if p.User != nil {
+			response["username"] = p.User.Username
+			response["groups"] = p.User.Groups
+		}
+	}
+	return response
+}
+
+// handleMeUpdate altera as preferências do usuário logado.
+// Corpo: {"language": "en"}; vazio volta a seguir o Accept-Language do navegador
+func (s *Server) handleMeUpdate(w http.ResponseWriter, r *http.Request) {
+	p := principalFromContext(r.Context())
+	if p == nil || p.User == nil {
+		jsonError(w, r, "language_requires_user", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Language string `json:"language"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, r, "invalid_json", http.StatusBadRequest)
+		return
+	}
+
+	language := ""
+	if body.Language != "" {
+		if language = i18n.Match(body.Language); language == "" {
+			jsonError(w, r, "invalid_language", http.StatusBadRequest, body.Language)
+			return
+		}
+	}
+
+	if err := s.storage.SetUserLanguage(p.User.ID, language); err != nil {
+		log.Printf("Erro ao alterar idioma: %v", err)
+		jsonError(w, r, "update_language_failed", http.StatusInternalServerError)
+		return
+	}
+	p.User.Language = language
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "ok",
+		"language": requestLanguage(r),
+	})
+}
+
+// handleUsers lista (GET) e cria (POST) usuários.
+// Corpo do POST: {"username": "...", "password": "..."}
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		users, err := s.storage.ListUsers()
+		if err != nil {
+			log.Printf("Erro ao listar usuários: %v", err)
+			jsonError(w, r, "list_users_failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"users": users,
+		})
+
+	case http.MethodPost:
+		var body struct {
+			Username string   `json:"username"`
+			Password string   `json:"password"`
+			Role     string   `json:"role"`
+			Groups   []string `json:"groups"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			jsonError(w, r, "invalid_json", http.StatusBadRequest)
+			return
+		}
+
+		body.Username = strings.TrimSpace(body.Username)
+		if body.Username == "" {
+			jsonError(w, r, "username_required", http.StatusBadRequest)
+			return
+		}
+		if len(body.Password) < minPasswordLength {
+			jsonError(w, r, "password_too_short", http.StatusBadRequest, minPasswordLength)
+			return
+		}
+		if body.Role == "" {
+			body.Role = storage.RoleViewer
+		}
+		if !storage.ValidRole(body.Role) {
+			jsonError(w, r, "invalid_role", http.StatusBadRequest)
+			return
+		}
+
+		existing, _, err := s.storage.GetUserCredentials(body.Username)
+		if err != nil {
+			log.Printf("Erro ao criar usuário: %v", err)
+			jsonError(w, r, "create_user_failed", http.StatusInternalServerError)
+			return
+		}
+		if existing != nil {
+			jsonError(w, r, "user_exists", http.StatusConflict)
+			return
+		}
+
+		hash, err := hashPassword(body.Password)
+		if err != nil {
+			log.Printf("Erro ao criar usuário: %v", err)
+			jsonError(w, r, "create_user_failed", http.StatusInternalServerError)
+			return
+		}
+		user, err := s.storage.CreateUser(body.Username, hash, body.Role, cleanGroups(body.Groups))
+		if err != nil {
+			log.Printf("Erro ao criar usuário: %v", err)
+			jsonError(w, r, "create_user_failed", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Usuário criado: %s (%s)", user.Username, user.Role)
+		s.audit(r, storage.AuditEntry{Action: "user.create", Target: fmt.Sprintf("user:%d", user.ID)}, nil, user)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleUserDetail trata /api/users/{id} (PATCH altera papel/grupos, DELETE remove)
+// e /api/users/{id}/password (POST troca a senha)
+func (s *Server) handleUserDetail(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/users/")
+	parts := strings.Split(path, "/")
+
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		jsonError(w, r, "invalid_id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		var body struct {
+			Role   string   `json:"role"`
+			Groups []string `json:"groups"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			jsonError(w, r, "invalid_json", http.StatusBadRequest)
+			return
+		}
+
+		user, err := s.storage.GetUser(userID)
+		if err != nil {
+			s.userError(w, r, "update_user_failed", err)
+			return
+		}
+		if user == nil {
+			jsonError(w, r, "user_not_found", http.StatusNotFound)
+			return
+		}
+
+		// Campos ausentes mantêm o valor atual
+		if body.Role == "" {
+			body.Role = user.Role
+		}
+		if body.Groups == nil {
+			body.Groups = user.Groups
+		}
+		if !storage.ValidRole(body.Role) {
+			jsonError(w, r, "invalid_role", http.StatusBadRequest)
+			return
+		}
+		if user.Role == storage.RoleAdmin && body.Role != storage.RoleAdmin && s.isLastAdmin(w, r) {
+			return
+		}
+
+		if err := s.storage.UpdateUserAccess(userID, body.Role, cleanGroups(body.Groups)); err != nil {
+			s.userError(w, r, "update_user_failed", err)
+			return
+		}
+		before := user
+		user, err = s.storage.GetUser(userID)
+		if err != nil {
+			s.userError(w, r, "update_user_failed", err)
+			return
+		}
+
+		log.Printf("Usuário alterado: %s (%s, grupos: %v)", user.Username, user.Role, user.Groups)
+		s.audit(r, storage.AuditEntry{Action: "user.update", Target: fmt.Sprintf("user:%d", userID)}, before, user)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		user, err := s.storage.GetUser(userID)
+		if err != nil {
+			s.userError(w, r, "delete_user_failed", err)
+			return
+		}
+		if user != nil && user.Role == storage.RoleAdmin && s.isLastAdmin(w, r) {
+			return
+		}
+
+		if err := s.storage.DeleteUser(userID); err != nil {
+			s.userError(w, r, "delete_user_failed", err)
+			return
+		}
+		log.Printf("Usuário removido: %d", userID)
+		s.audit(r, storage.AuditEntry{Action: "user.delete", Target: fmt.Sprintf("user:%d", userID)}, user, nil)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "ok",
+			"user_id": userID,
+		})
+
+	case len(parts) == 2 && parts[1] == "password" && r.Method == http.MethodPost:
+		var body struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			jsonError(w, r, "invalid_json", http.StatusBadRequest)
+			return
+		}
+		if len(body.Password) < minPasswordLength {
+			jsonError(w, r, "password_too_short", http.StatusBadRequest, minPasswordLength)
+			return
+		}
+
+		hash, err := hashPassword(body.Password)
+		if err != nil {
+			s.userError(w, r, "change_password_failed", err)
+			return
+		}
+		// As demais sessões do usuário são encerradas; quem troca a própria senha continua logado
+		keepSession := ""
+		if p := principalFromContext(r.Context()); p != nil && p.User != nil && p.User.ID == userID {
+			if cookie, err := r.Cookie(sessionCookie); err == nil {
+				keepSession = hashToken(cookie.Value)
+			}
+		}
+		if err := s.storage.SetUserPassword(userID, hash, keepSession); err != nil {
+			s.userError(w, r, "change_password_failed", err)
+			return
+		}
+		log.Printf("Senha alterada: usuário %d", userID)
+		s.audit(r, storage.AuditEntry{Action: "user.password", Target: fmt.Sprintf("user:%d", userID)}, nil, nil)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "ok",
+			"user_id": userID,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIKeys lista (GET) e cria (POST) chaves de API.
+// Corpo do POST: {"name": "..."}; a chave é retornada apenas uma vez.
+func (s *Server) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := s.storage.ListAPIKeys()
+		if err != nil {
+			log.Printf("Erro ao listar chaves de API: %v", err)
+			jsonError(w, r, "list_api_keys_failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": keys,
+		})
+
+	case http.MethodPost:
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			jsonError(w, r, "invalid_json", http.StatusBadRequest)
+			return
+		}
+		body.Name = strings.TrimSpace(body.Name)
+		if body.Name == "" {
+			jsonError(w, r, "name_required", http.StatusBadRequest)
+			return
+		}
+
+		// Chaves criadas por um usuário logado ficam vinculadas a ele
+		var userID *int64
+		if p := principalFromContext(r.Context()); p != nil && p.User != nil {
+			userID = &p.User.ID
+		}
+
+		token, err := generateToken(apiKeyPrefix)
+		if err != nil {
+			log.Printf("Erro ao criar chave de API: %v", err)
+			jsonError(w, r, "create_api_key_failed", http.StatusInternalServerError)
+			return
+		}
+		key, err := s.storage.CreateAPIKey(userID, body.Name, hashToken(token), displayPrefix(token))
+		if err != nil {
+			log.Printf("Erro ao criar chave de API: %v", err)
+			jsonError(w, r, "create_api_key_failed", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Chave de API criada: %s (%s)", key.Name, key.KeyPrefix)
+		s.audit(r, storage.AuditEntry{Action: "apikey.create", Target: fmt.Sprintf("apikey:%d", key.ID)}, nil, key)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "ok",
+			"key":     token,
+			"api_key": key,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPIKeyDetail revoga uma chave de API (DELETE /api/keys/{id})
+func (s *Server) handleAPIKeyDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keyID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/keys/"), 10, 64)
+	if err != nil {
+		jsonError(w, r, "invalid_id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.storage.RevokeAPIKey(keyID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonError(w, r, "api_key_not_found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Erro ao revogar chave de API: %v", err)
+		jsonError(w, r, "revoke_api_key_failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Chave de API revogada: %d", keyID)
+	s.audit(r, storage.AuditEntry{Action: "apikey.revoke", Target: fmt.Sprintf("apikey:%d", keyID)}, nil, nil)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"key_id": keyID,
+	})
+}
+
+// isLastAdmin responde 409 e retorna true se só resta um administrador
+func (s *Server) isLastAdmin(w http.ResponseWriter, r *http.Request) bool {
+	admins, err := s.storage.CountAdmins()
+	if err != nil {
+		log.Printf("Erro ao contar administradores: %v", err)
+		jsonError(w, r, "update_user_failed", http.StatusInternalServerError)
+		return true
+	}
+	if admins <= 1 {
+		jsonError(w, r, "last_admin", http.StatusConflict)
+		return true
+	}
+	return false
+}
+
+// cleanGroups remove espaços, vazios e duplicados da lista de grupos
+func cleanGroups(groups []string) []string {
+	seen := make(map[string]bool)
+	var cleaned []string
+	for _, g := range groups {
+		g = strings.TrimSpace(g)
+		if g == "" || seen[g] {
+			continue
+		}
+		seen[g] = true
+		cleaned = append(cleaned, g)
+	}
+	return cleaned
+}
+
+// userError responde erros das operações sobre usuários
+func (s *Server) userError(w http.ResponseWriter, r *http.Request, code string, err error) {
+	if errors.Is(err, storage.ErrNotFound) {
+		jsonError(w, r, "user_not_found", http.StatusNotFound)
+		return
+	}
+	log.Printf("%s: %v", i18n.T(i18n.Default, code), err)
+	jsonError(w, r, code, http.StatusInternalServerError)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+// login cria um usuário com uma sessão e retorna o header com o cookie dela
+func login(t *testing.T, s *Server, username, role string, groups []string) (*storage.User, map[string]string) {
+	t.Helper()
+	user, err := s.storage.CreateUser(username, "pbkdf2-sha256$1$00$00", role, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := generateToken("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.storage.CreateSession(hashToken(token), user.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	return user, map[string]string{"Cookie": sessionCookie + "=" + token}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := hashPassword("senha-correta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+		ok       bool
+	}{
+		{"senha correta", "senha-correta", hash, true},
+		{"senha incorreta", "senha-errada", hash, false},
+		{"formato desconhecido", "senha-correta", "md5$abc", false},
+		{"iterações inválidas", "senha-correta", "pbkdf2-sha256$0$00$00", false},
+		{"salt inválido", "senha-correta", "pbkdf2-sha256$1$zz$00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPassword(tt.password, tt.encoded); got != tt.ok {
+				t.Errorf("verifyPassword = %v, esperado %v", got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRoleMiddlewarePublicRead(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.PublicRead = true })
+	_, viewer := login(t, s, "leitor", storage.RoleViewer, nil)
+	_, editor := login(t, s, "editor", storage.RoleEditor, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+	}{
+		{"leitura anônima", http.MethodGet, "/api/machines", nil, http.StatusOK},
+		{"edição anônima", http.MethodPatch, "/api/machines/1", nil, http.StatusUnauthorized},
+		{"remoção anônima", http.MethodDelete, "/api/machines/1", nil, http.StatusUnauthorized},
+		{"administração anônima", http.MethodGet, "/api/users", nil, http.StatusUnauthorized},
+		{"criação de usuário anônima", http.MethodPost, "/api/users", nil, http.StatusUnauthorized},
+		{"edição por leitor", http.MethodPatch, "/api/machines/1", viewer, http.StatusForbidden},
+		{"edição por editor", http.MethodPatch, "/api/machines/1", editor, http.StatusNotFound},
+		{"administração por editor", http.MethodGet, "/api/users", editor, http.StatusForbidden},
+		{"token compartilhado só envia métricas", http.MethodGet, "/api/users", bearer("token-de-teste"), http.StatusUnauthorized},
+		{"token de administração", http.MethodGet, "/api/users", bearer("admin-de-teste"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := ""
+			if tt.method == http.MethodPatch {
+				body = `{"display_name": "novo"}`
+			}
+			w := serve(s, tt.method, tt.path, body, tt.header)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestMeCanEdit(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.PublicRead = true })
+	_, viewer := login(t, s, "leitor", storage.RoleViewer, nil)
+	_, editor := login(t, s, "editor", storage.RoleEditor, nil)
+	_, admin := login(t, s, "admin", storage.RoleAdmin, nil)
+
+	tests := []struct {
+		name    string
+		header  map[string]string
+		canEdit bool
+	}{
+		{"anônimo com --public-read", nil, false},
+		{"leitor", viewer, false},
+		{"editor", editor, true},
+		{"administrador", admin, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodGet, "/api/me", "", tt.header)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var me struct {
+				CanEdit bool `json:"can_edit"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&me); err != nil {
+				t.Fatal(err)
+			}
+			if me.CanEdit != tt.canEdit {
+				t.Errorf("can_edit = %v, esperado %v", me.CanEdit, tt.canEdit)
+			}
+		})
+	}
+}
+
+func TestPasswordChangeSessions(t *testing.T) {
+	tests := []struct {
+		name        string
+		self        bool // o próprio usuário troca a senha
+		keepCurrent bool
+	}{
+		{"troca da própria senha", true, true},
+		{"troca por outro administrador", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil)
+			user, current := login(t, s, "admin", storage.RoleAdmin, nil)
+			other := map[string]string{}
+			for i := 0; i < 2; i++ {
+				token, _ := generateToken("")
+				s.storage.CreateSession(hashToken(token), user.ID, time.Now().Add(time.Hour))
+				other = map[string]string{"Cookie": sessionCookie + "=" + token}
+			}
+
+			header := bearer("admin-de-teste")
+			if tt.self {
+				header = current
+			}
+			path := "/api/users/" + strconv.FormatInt(user.ID, 10) + "/password"
+			if w := serve(s, http.MethodPost, path, `{"password": "senha-nova-123"}`, header); w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+
+			if w := serve(s, http.MethodGet, "/api/me", "", other); w.Code != http.StatusUnauthorized {
+				t.Errorf("outra sessão continua válida (status %d)", w.Code)
+			}
+			w := serve(s, http.MethodGet, "/api/me", "", current)
+			if kept := w.Code == http.StatusOK; kept != tt.keepCurrent {
+				t.Errorf("sessão atual mantida = %v, esperado %v", kept, tt.keepCurrent)
+			}
+		})
+	}
+}
+
+func TestMachinesScopedByGroups(t *testing.T) {
+	s := newTestServer(t, nil)
+	for i, group := range []string{"web", "db"} {
+		body := fmt.Sprintf(`{"machine_uuid": "8a3c9f1e-0000-4000-8000-00000000000%d", "hostname": "host-%d", "ip": "10.0.0.1", "group": %q}`, i+1, i+1, group)
+		if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		groups []string
+		count  int
+	}{
+		{"administrador", storage.RoleAdmin, nil, 2},
+		{"leitor do grupo web", storage.RoleViewer, []string{"web"}, 1},
+		{"leitor sem grupos", storage.RoleViewer, nil, 0},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, header := login(t, s, fmt.Sprintf("usuario-%d", i), tt.role, tt.groups)
+			w := serve(s, http.MethodGet, "/api/machines", "", header)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var body struct {
+				Machines []storage.Machine `json:"machines"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Machines) != tt.count {
+				t.Errorf("%d máquinas, esperado %d", len(body.Machines), tt.count)
+			}
+		})
+	}
+}
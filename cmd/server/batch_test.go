@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleMetricsBatch(t *testing.T) {
+	item := func(n int) string {
+		return fmt.Sprintf(`{"machine_uuid": "8a3c9f1e-0000-4000-8000-%012d", "hostname": "web-%02d", "ip": "10.0.0.1", "cpu_percent": 10}`, n, n)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		accepted int
+		rejected int
+		statuses []string
+	}{
+		{"dois válidos", "[" + item(1) + "," + item(2) + "]", http.StatusOK, 2, 0, []string{"ok", "ok"}},
+		{"item inválido não derruba o lote", "[" + item(3) + `,{"hostname": "", "cpu_percent": 150}]`, http.StatusOK, 1, 1, []string{"ok", "error"}},
+		{"reenvio conta como duplicado", "[" + item(1) + "]", http.StatusOK, 0, 0, []string{"ok"}},
+		{"lote vazio", "[]", http.StatusBadRequest, 0, 0, nil},
+		{"não é array", "{}", http.StatusBadRequest, 0, 0, nil},
+	}
+
+	s := newTestServer(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, "/api/metrics/batch", tt.body, bearer("token-de-teste"))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Accepted int               `json:"accepted"`
+				Rejected int               `json:"rejected"`
+				Results  []batchItemResult `json:"results"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Accepted != tt.accepted || resp.Rejected != tt.rejected {
+				t.Errorf("accepted/rejected = %d/%d, esperado %d/%d", resp.Accepted, resp.Rejected, tt.accepted, tt.rejected)
+			}
+			for i, status := range tt.statuses {
+				if resp.Results[i].Index != i || resp.Results[i].Status != status {
+					t.Errorf("item %d = %+v, esperado %s", i, resp.Results[i], status)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleMetricsBatchGzip(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(`[{"hostname": "web-01", "ip": "10.0.0.1"}, {"hostname": "web-02", "ip": "10.0.0.2"}]`))
+	gz.Close()
+
+	tests := []struct {
+		name   string
+		body   []byte
+		status int
+		want   string
+	}{
+		{"comprimido", buf.Bytes(), http.StatusOK, `"accepted":2`},
+		{"gzip inválido", []byte("[]"), http.StatusBadRequest, "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/metrics/batch", bytes.NewReader(tt.body))
+			r.Header.Set("Authorization", "Bearer token-de-teste")
+			r.Header.Set("Content-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			s.mux.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta sem %q: %s", tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestHandleMetricsBatchGzipLimit(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.MaxBodyBytes = 512 })
+
+	// Comprimido cabe no limite, mas o conteúdo descomprimido não
+	items := make([]string, 20)
+	for i := range items {
+		items[i] = `{"hostname": "web", "ip": "10.0.0.1", "cpu_percent": 10}`
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	gz.Close()
+	if buf.Len() > 512 {
+		t.Fatalf("lote comprimido com %d bytes não cabe no limite do teste", buf.Len())
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/metrics/batch", &buf)
+	r.Header.Set("Authorization", "Bearer token-de-teste")
+	r.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, esperado 413: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitor-infra/internal/dashboard"
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+const (
+	// digestTop é quantas máquinas aparecem em cada lista de maiores consumidores
+	digestTop = 5
+
+	// digestMaxAlerts limita as linhas de alertas do resumo; as demais são apenas contadas
+	digestMaxAlerts = 20
+)
+
+// digestWeekdays são os dias aceitos em --digest-schedule
+var digestWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// digestSchedule é quando o resumo é enviado: toda semana no dia informado ou todo dia, no
+// horário local do servidor. O resumo cobre o período desde o envio anterior.
+type digestSchedule struct {
+	daily   bool
+	weekday time.Weekday
+	hour    int
+	minute  int
+}
+
+// parseDigestSchedule lê "<dia> HH:MM", com dia sun, mon, tue, wed, thu, fri, sat ou daily
+func parseDigestSchedule(value string) (digestSchedule, error) {
+	var d digestSchedule
+	day, clock, ok := strings.Cut(strings.ToLower(strings.TrimSpace(value)), " ")
+	at, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if !ok || err != nil {
+		return d, fmt.Errorf("agenda inválida %q (esperado \"<dia> HH:MM\", ex.: \"mon 08:00\")", value)
+	}
+	d.hour, d.minute = at.Hour(), at.Minute()
+
+	if day == "daily" {
+		d.daily = true
+		return d, nil
+	}
+	if d.weekday, ok = digestWeekdays[day]; !ok {
+		return d, fmt.Errorf("dia inválido %q na agenda (sun a sat ou daily)", day)
+	}
+	return d, nil
+}
+
+// days é o período coberto pelo resumo, em dias
+func (d digestSchedule) days() int {
+	if d.daily {
+		return 1
+	}
+	return 7
+}
+
+// next retorna o próximo horário de envio depois de after
+func (d digestSchedule) next(after time.Time) time.Time {
+	t := time.Date(after.Year(), after.Month(), after.Day(), d.hour, d.minute, 0, 0, time.Local)
+	for !t.After(after) || !d.daily && t.Weekday() != d.weekday {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// digestPage são os dados do resumo (templates/digest.html), já formatados no idioma do resumo
+type digestPage struct {
+	From         string
+	To           string
+	Machines     int
+	Online       int
+	Warning      int
+	Critical     int
+	Offline      int
+	Uptime       string // "" sem dados
+	OfflineRows  []digestRow
+	Top          []digestTopList
+	ForecastDays int
+	Forecasts    []digestRow
+	Alerts       []digestRow
+	AlertsMore   int
+}
+
+// digestTopList é a lista de maiores consumidores de uma métrica
+type digestTopList struct {
+	Metric string
+	Rows   []digestRow
+}
+
+// digestRow é uma linha de uma tabela do resumo: a máquina, o grupo e os valores das demais colunas
+type digestRow struct {
+	Name   string
+	Group  string
+	Values []string
+}
+
+// machineName é o nome de exibição da máquina ou, sem ele, o hostname
+func machineName(m *storage.Machine) string {
+	if m.DisplayName != "" {
+		return m.DisplayName
+	}
+	return m.Hostname
+}
+
+// buildDigest monta o resumo do período que termina em to e retorna o assunto e o HTML
+func (s *Server) buildDigest(to time.Time) (string, []byte, error) {
+	lang := s.config.DigestLang
+	from := to.AddDate(0, 0, -s.config.DigestSchedule.days())
+
+	machines, err := s.storage.GetMachinesWithMetrics(nil, storage.MachineFilter{})
+	if err != nil {
+		return "", nil, err
+	}
+	uptime, err := s.storage.Uptime(machines, []time.Time{from, to})
+	if err != nil {
+		return "", nil, err
+	}
+	averages, err := s.storage.AverageMetrics(from, to)
+	if err != nil {
+		return "", nil, err
+	}
+	// Mesma janela de /api/forecast e dos alertas disk_full, independente do período do resumo
+	forecasts, err := s.storage.ForecastDisks(s.forecastWindow(to))
+	if err != nil {
+		return "", nil, err
+	}
+	alerts, err := s.storage.SummarizeAlerts(from, to)
+	if err != nil {
+		return "", nil, err
+	}
+
+	page := digestPage{
+		From:         from.Format("2006-01-02 15:04"),
+		To:           to.Format("2006-01-02 15:04"),
+		Machines:     len(machines),
+		ForecastDays: s.config.ForecastDays,
+	}
+	horizon := to.AddDate(0, 0, s.config.ForecastDays)
+
+	var total storage.UptimeWindow
+	var offline, filling []*storage.Machine
+	for i := range machines {
+		m := &machines[i]
+		switch m.Status {
+		case storage.StatusOffline:
+			page.Offline++
+			offline = append(offline, m)
+		case storage.StatusCritical:
+			page.Critical++
+		case storage.StatusWarning:
+			page.Warning++
+		default:
+			page.Online++
+		}
+		if windows := uptime[m.ID]; len(windows) > 0 {
+			total.Add(windows[0])
+		}
+		if f, ok := forecasts[m.ID]; ok && f.FullWithin(horizon) {
+			filling = append(filling, m)
+		}
+	}
+	if percent, ok := total.Percent(); ok {
+		page.Uptime = fmt.Sprintf("%.2f", percent)
+	}
+
+	// Offline há mais tempo primeiro
+	sort.Slice(offline, func(i, j int) bool { return offline[i].LastSeen.Before(offline[j].LastSeen) })
+	for _, m := range offline {
+		page.OfflineRows = append(page.OfflineRows, digestRow{
+			Name:   machineName(m),
+			Group:  m.GroupName,
+			Values: []string{m.LastSeen.Local().Format("2006-01-02 15:04")},
+		})
+	}
+
+	// Maiores médias do período de cada métrica
+	for _, metric := range storage.ThresholdMetrics {
+		var ranked []*storage.Machine
+		for i := range machines {
+			if _, ok := averages[machines[i].ID]; ok {
+				ranked = append(ranked, &machines[i])
+			}
+		}
+		value := func(m *storage.Machine) float64 {
+			avg := averages[m.ID]
+			switch metric {
+			case storage.MetricCPU:
+				return avg.CPU
+			case storage.MetricMemory:
+				return avg.Memory
+			}
+			return avg.Disk
+		}
+		sort.SliceStable(ranked, func(i, j int) bool { return value(ranked[i]) > value(ranked[j]) })
+
+		top := digestTopList{Metric: metric}
+		for _, m := range ranked[:min(len(ranked), digestTop)] {
+			top.Rows = append(top.Rows, digestRow{
+				Name:   machineName(m),
+				Group:  m.GroupName,
+				Values: []string{fmt.Sprintf("%.1f%%", value(m))},
+			})
+		}
+		page.Top = append(page.Top, top)
+	}
+
+	// Discos que enchem antes primeiro
+	sort.Slice(filling, func(i, j int) bool {
+		return forecasts[filling[i].ID].FullAt.Before(*forecasts[filling[j].ID].FullAt)
+	})
+	for _, m := range filling {
+		f := forecasts[m.ID]
+		page.Forecasts = append(page.Forecasts, digestRow{
+			Name:  machineName(m),
+			Group: m.GroupName,
+			Values: []string{
+				fmt.Sprintf("%.1f%%", f.Current),
+				i18n.T(lang, "digest.forecast.growth", f.GrowthPerDay),
+				f.FullAt.Local().Format("2006-01-02"),
+			},
+		})
+	}
+
+	for i, a := range alerts {
+		if i == digestMaxAlerts {
+			page.AlertsMore = len(alerts) - i
+			break
+		}
+		message := i18n.T(lang, "alert."+a.Kind, a.Args...)
+		if status, ok := strings.CutPrefix(a.Kind, "status."); ok {
+			message = i18n.T(lang, "digest.alerts.became", i18n.T(lang, "ui.status_name."+status))
+		}
+		name := a.Hostname
+		if a.DisplayName != "" {
+			name = a.DisplayName
+		}
+		page.Alerts = append(page.Alerts, digestRow{
+			Name:   name,
+			Group:  a.GroupName,
+			Values: []string{message, strconv.Itoa(a.Count), a.Last.Local().Format("2006-01-02 15:04")},
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := s.dashboard.Execute(&buf, "digest.html", dashboard.Page{Lang: lang, State: page}); err != nil {
+		return "", nil, fmt.Errorf("erro ao renderizar resumo: %w", err)
+	}
+	subject := i18n.T(lang, "digest.subject", page.Machines, page.Offline, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	return subject, buf.Bytes(), nil
+}
+
+// sendDigest monta e envia o resumo do período que termina em to para --digest-to
+func (s *Server) sendDigest(to time.Time) error {
+	subject, body, err := s.buildDigest(to)
+	if err != nil {
+		return err
+	}
+	return s.sendMail(s.config.DigestTo, subject, string(body))
+}
+
+// scheduleDigest envia o resumo nos horários de --digest-schedule
+func (s *Server) scheduleDigest() {
+	for {
+		next := s.config.DigestSchedule.next(time.Now())
+		time.Sleep(time.Until(next))
+
+		if err := s.sendDigest(next); err != nil {
+			log.Printf("Erro ao enviar resumo por e-mail: %v", err)
+		} else {
+			log.Printf("Resumo enviado para %s", strings.Join(s.config.DigestTo, ", "))
+		}
+	}
+}
+
+// handleDigest mostra (GET) o resumo do período que termina agora, como seria enviado, e
+// envia (POST) o resumo imediatamente para --digest-to
+func (s *Server) handleDigest(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		_, body, err := s.buildDigest(time.Now())
+		if err != nil {
+			log.Printf("Erro ao montar resumo: %v", err)
+			jsonError(w, r, "digest_failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(body)
+
+	case http.MethodPost:
+		if !s.config.smtpConfigured() || len(s.config.DigestTo) == 0 {
+			jsonError(w, r, "digest_disabled", http.StatusForbidden)
+			return
+		}
+		if err := s.sendDigest(time.Now()); err != nil {
+			log.Printf("Erro ao enviar resumo por e-mail: %v", err)
+			jsonError(w, r, "digest_send_failed", http.StatusBadGateway)
+			return
+		}
+
+		log.Printf("Resumo enviado para %s", strings.Join(s.config.DigestTo, ", "))
+		s.audit(r, storage.AuditEntry{Action: "digest.send", Target: strings.Join(s.config.DigestTo, ",")}, nil, nil)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+			"to":     s.config.DigestTo,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestParseDigestSchedule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    digestSchedule
+		wantErr bool
+	}{
+		{"mon 08:00", digestSchedule{weekday: time.Monday, hour: 8}, false},
+		{" SAT 23:45 ", digestSchedule{weekday: time.Saturday, hour: 23, minute: 45}, false},
+		{"daily 06:30", digestSchedule{daily: true, hour: 6, minute: 30}, false},
+		{"mon", digestSchedule{}, true},
+		{"mon 8h", digestSchedule{}, true},
+		{"mon 24:00", digestSchedule{}, true},
+		{"segunda 08:00", digestSchedule{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDigestSchedule(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erro = %v, esperado erro: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("agenda = %+v, esperado %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestScheduleNext(t *testing.T) {
+	// 2026-03-04 é uma quarta-feira
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 3, day, hour, minute, 0, 0, time.Local) }
+
+	tests := []struct {
+		name     string
+		schedule string
+		after    time.Time
+		want     time.Time
+		days     int
+	}{
+		{"diário, ainda hoje", "daily 08:00", at(4, 7, 59), at(4, 8, 0), 1},
+		{"diário, no horário", "daily 08:00", at(4, 8, 0), at(5, 8, 0), 1},
+		{"semanal, mesmo dia depois do horário", "wed 08:00", at(4, 9, 0), at(11, 8, 0), 7},
+		{"semanal, mesmo dia antes do horário", "wed 08:00", at(4, 7, 0), at(4, 8, 0), 7},
+		{"semanal, outro dia", "mon 08:00", at(4, 9, 0), at(9, 8, 0), 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseDigestSchedule(tt.schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("next(%v) = %v, esperado %v", tt.after, got, tt.want)
+			}
+			if got := schedule.days(); got != tt.days {
+				t.Errorf("days() = %d, esperado %d", got, tt.days)
+			}
+		})
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	from := &mail.Address{Name: "Monitor", Address: "monitor@example.com"}
+	to := []*mail.Address{{Name: "João", Address: "joao@example.com"}, {Address: "ops@example.com"}}
+	html := `<p style="color: red">Resumo semanal — ` + strings.Repeat("x", 100) + `</p>`
+
+	msg, err := mail.ReadMessage(bytes.NewReader(buildMessage(from, to, "Resumo: 3 máquinas", html)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Resumo: 3 máquinas" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	recipients, err := msg.Header.AddressList("To")
+	if err != nil || len(recipients) != 2 || recipients[0].Name != "João" {
+		t.Errorf("To = %v (%v)", recipients, err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+	if got := msg.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", got)
+	}
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != html {
+		t.Errorf("corpo = %q, esperado %q", body, html)
+	}
+}
+
+func TestHandleDigest(t *testing.T) {
+	s := newTestServer(t, func(c *Config) {
+		c.DigestSchedule = digestSchedule{weekday: time.Monday, hour: 8}
+		c.DigestLang = "pt-BR"
+		c.ForecastDays = 14
+	})
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1", "group": "web"}`
+	if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	_, editor := login(t, s, "editor", storage.RoleEditor, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		status int
+		want   string
+	}{
+		{"prévia", http.MethodGet, bearer("admin-de-teste"), http.StatusOK, `<div class="stat-value">1</div>`},
+		{"envio sem SMTP", http.MethodPost, bearer("admin-de-teste"), http.StatusForbidden, "digest_disabled"},
+		{"editor", http.MethodGet, editor, http.StatusForbidden, ""},
+		{"método", http.MethodDelete, bearer("admin-de-teste"), http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, tt.method, "/api/digest", "", tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta sem %q: %s", tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+// Eventos enviados pelo stream /api/events
+const (
+	eventMachine        = "machine"         // máquina criada ou atualizada (dados completos)
+	eventMachineRemoved = "machine.removed" // máquina removida ou que deixou de atender ao filtro
+	eventStatus         = "status"          // mudança de estado: online, warning ou offline
+	eventAlert          = "alert"           // aviso sobre a máquina: conflito, relógio, payload recusado, disco enchendo, anomalia
+)
+
+const (
+	// eventHeartbeat é o intervalo dos comentários que mantêm o stream aberto em proxies
+	eventHeartbeat = 25 * time.Second
+	// eventBuffer é quantos eventos um cliente lento pode acumular antes de ser desconectado
+	eventBuffer = 64
+	// statusWatchInterval é o intervalo da verificação de máquinas que ficaram offline
+	statusWatchInterval = time.Minute
+)
+
+// serverEvent é um evento do stream; Machine define quais clientes podem recebê-lo (escopo e filtro)
+type serverEvent struct {
+	Name    string
+	Machine *storage.Machine
+	Data    interface{}
+}
+
+// localizer é implementado pelos dados de eventos com mensagens traduzidas para cada cliente
+type localizer interface {
+	localize(lang string) interface{}
+}
+
+// alertData são os dados de eventAlert; a mensagem é a chave "alert.<kind>" do catálogo
+type alertData struct {
+	MachineID int64
+	Hostname  string
+	Kind      string
+	Args      []interface{}
+}
+
+func (a alertData) localize(lang string) interface{} {
+	return map[string]interface{}{
+		"machine_id": a.MachineID,
+		"hostname":   a.Hostname,
+		"kind":       a.Kind,
+		"message":    i18n.T(lang, "alert."+a.Kind, localizeArgs(lang, a.Args)...),
+	}
+}
+
+// eventClient é um dashboard (ou cliente da API) inscrito no stream
+type eventClient struct {
+	scope  *storage.Scope
+	filter storage.MachineFilter
+	lang   string
+	events chan serverEvent
+}
+
+// eventHub distribui os eventos de máquinas aos clientes inscritos e acompanha o estado
+// de cada máquina para publicar as transições
+type eventHub struct {
+	mu       sync.Mutex
+	clients  map[*eventClient]struct{}
+	statuses map[int64]string
+	closed   bool
+}
+
+// newEventHub cria o distribuidor de eventos
+func newEventHub() *eventHub {
+	return &eventHub{
+		clients:  make(map[*eventClient]struct{}),
+		statuses: make(map[int64]string),
+	}
+}
+
+// subscribe inscreve um cliente; retorna nil se o servidor está encerrando
+func (h *eventHub) subscribe(scope *storage.Scope, filter storage.MachineFilter, lang string) *eventClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	c := &eventClient{scope: scope, filter: filter, lang: lang, events: make(chan serverEvent, eventBuffer)}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// unsubscribe remove o cliente (se ainda inscrito) e fecha seu canal
+func (h *eventHub) unsubscribe(c *eventClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(c)
+}
+
+// drop remove o cliente; chamado com h.mu travado
+func (h *eventHub) drop(c *eventClient) {
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.events)
+	}
+}
+
+// close encerra todos os streams (usado no shutdown do servidor)
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for c := range h.clients {
+		h.drop(c)
+	}
+}
+
+// publish entrega o evento aos clientes com acesso ao grupo da máquina e cujo filtro ela atende;
+// para os demais, eventMachine vira eventMachineRemoved. Clientes que não
+// acompanham o ritmo são desconectados e ressincronizam ao reconectar.
+func (h *eventHub) publish(ev serverEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.clients {
+		out := ev
+		if ev.Machine != nil {
+			if !c.scope.Allows(ev.Machine.GroupName) {
+				continue
+			}
+			if ev.Name != eventMachineRemoved && !c.filter.Matches(ev.Machine) {
+				if ev.Name != eventMachine {
+					continue
+				}
+				out = serverEvent{Name: eventMachineRemoved, Machine: ev.Machine, Data: map[string]interface{}{"id": ev.Machine.ID}}
+			}
+		}
+
+		select {
+		case c.events <- out:
+		default:
+			log.Printf("Aviso: cliente de eventos lento desconectado")
+			h.drop(c)
+		}
+	}
+}
+
+// observe registra o estado atual da máquina e retorna o anterior, se ele mudou.
+// Máquinas arquivadas deixam de ser acompanhadas.
+func (h *eventHub) observe(m *storage.Machine) (from string, changed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if m.ArchivedAt != nil {
+		delete(h.statuses, m.ID)
+		return "", false
+	}
+
+	status := m.Status
+	from, known := h.statuses[m.ID]
+	h.statuses[m.ID] = status
+	return from, known && from != status
+}
+
+// seed registra estados conhecidos de antes do início (ex.: os últimos gravados no banco)
+// para as máquinas ainda não acompanhadas
+func (h *eventHub) seed(statuses map[int64]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, status := range statuses {
+		if _, ok := h.statuses[id]; !ok {
+			h.statuses[id] = status
+		}
+	}
+}
+
+// status retorna o último estado registrado da máquina
+func (h *eventHub) status(machineID int64) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status, ok := h.statuses[machineID]
+	return status, ok
+}
+
+// forget deixa de acompanhar o estado de uma máquina removida
+func (h *eventHub) forget(machineID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.statuses, machineID)
+}
+
+// handleEvents mantém um stream Server-Sent Events com as atualizações das máquinas do
+// escopo do usuário. Aceita os mesmos filtros de /api/machines (include_archived e selector).
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	selector, selErr := selectorFromRequest(r)
+	if selErr != nil {
+		jsonError(w, r, selErr.Code, http.StatusBadRequest, selErr.Args...)
+		return
+	}
+
+	// O stream não tem prazo de escrita: o WriteTimeout do servidor encerraria a conexão
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		jsonError(w, r, "streaming_unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := s.events.subscribe(scopeFor(r), storage.MachineFilter{
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+		Selector:        selector,
+	}, requestLanguage(r))
+	if client == nil {
+		jsonError(w, r, "server_shutting_down", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.events.unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	rc.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, ok := <-client.events:
+			if !ok {
+				return
+			}
+			payload := ev.Data
+			if l, ok := payload.(localizer); ok {
+				payload = l.localize(client.lang)
+			}
+			data, err := json.Marshal(payload)
+			if err != nil {
+				log.Printf("Erro ao codificar evento %s: %v", ev.Name, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Name, data)
+		case <-heartbeat.C:
+			// Sessões encerradas ou revogadas deixam de receber eventos
+			if p, err := s.authenticate(r); err != nil || (p == nil && !s.config.PublicRead) {
+				return
+			}
+			fmt.Fprint(w, ": ping\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// publishMachine publica os dados atuais da máquina e, se o estado mudou, a transição
+func (s *Server) publishMachine(machineID int64) {
+	m, err := s.storage.GetMachineByID(machineID, nil)
+	if err != nil {
+		log.Printf("Aviso: %v", err)
+		return
+	}
+	if m == nil {
+		s.events.forget(machineID)
+		s.anomalies.forget(machineID)
+		return
+	}
+	s.annotateMachine(m)
+	s.broadcastMachine(m)
+}
+
+// broadcastMachine publica a máquina e, antes dela, a transição de estado (se houver),
+// que também é registrada para os relatórios de disponibilidade
+func (s *Server) broadcastMachine(m *storage.Machine) {
+	if from, changed := s.events.observe(m); changed {
+		log.Printf("Máquina %s: %s -> %s", m.Hostname, from, m.Status)
+		if err := s.storage.RecordStatusEvent(m.ID, from, m.Status); err != nil {
+			log.Printf("Aviso: %v", err)
+		}
+		s.events.publish(serverEvent{Name: eventStatus, Machine: m, Data: map[string]interface{}{
+			"machine_id": m.ID,
+			"hostname":   m.Hostname,
+			"from":       from,
+			"to":         m.Status,
+		}})
+	}
+	s.events.publish(serverEvent{Name: eventMachine, Machine: m, Data: m})
+}
+
+// publishUpdated publica a máquina alterada pela API. Ao mudar de grupo, quem só tinha acesso
+// ao grupo anterior recebe a remoção.
+func (s *Server) publishUpdated(before, after *storage.Machine) {
+	if before.GroupName != after.GroupName {
+		s.events.publish(serverEvent{Name: eventMachineRemoved, Machine: before, Data: map[string]interface{}{"id": before.ID}})
+	}
+	s.broadcastMachine(after)
+}
+
+// publishRemoved avisa que a máquina foi removida (m são os dados de antes da remoção)
+func (s *Server) publishRemoved(m *storage.Machine) {
+	if m == nil {
+		return
+	}
+	s.events.forget(m.ID)
+	s.anomalies.forget(m.ID)
+	s.events.publish(serverEvent{Name: eventMachineRemoved, Machine: m, Data: map[string]interface{}{"id": m.ID}})
+}
+
+// publishAlert publica e registra um aviso sobre a máquina (kind: hostname_conflict, clock_drift,
+// rejected, disk_full, anomaly.<métrica>); args completam a mensagem, traduzida no idioma de cada cliente
+func (s *Server) publishAlert(machineID int64, kind string, args ...interface{}) {
+	m, err := s.storage.GetMachineByID(machineID, nil)
+	if err != nil || m == nil {
+		return
+	}
+
+	// Erros são gravados como texto no idioma padrão, como o motivo em last_rejected_reason
+	stored := make([]interface{}, len(args))
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			arg = err.Error()
+		}
+		stored[i] = arg
+	}
+	if err := s.storage.RecordAlert(m.ID, kind, stored); err != nil {
+		log.Printf("Aviso: %v", err)
+	}
+
+	s.events.publish(serverEvent{Name: eventAlert, Machine: m, Data: alertData{
+		MachineID: m.ID,
+		Hostname:  m.Hostname,
+		Kind:      kind,
+		Args:      args,
+	}})
+}
+
+// watchStatus verifica periodicamente as máquinas para publicar as que ficaram offline,
+// já que essa transição acontece sem nenhum envio do agent
+func (s *Server) watchStatus() {
+	s.resumeStatuses()
+
+	ticker := time.NewTicker(statusWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkStatuses(false)
+		<-ticker.C
+	}
+}
+
+// resumeStatuses retoma o último estado gravado de cada máquina, para que as transições
+// ocorridas com o servidor parado (ex.: máquinas que ficaram offline) sejam registradas na
+// primeira verificação
+func (s *Server) resumeStatuses() {
+	statuses, err := s.storage.LastStatuses()
+	if err != nil {
+		log.Printf("Aviso: %v", err)
+		return
+	}
+	s.events.seed(statuses)
+}
+
+// checkStatuses publica as máquinas cujo estado mudou; com all, publica todas (após alterar
+// limites, que mudam os dados das máquinas mesmo quando o estado continua o mesmo)
+func (s *Server) checkStatuses(all bool) {
+	machines, err := s.storage.GetMachinesWithMetrics(nil, storage.MachineFilter{})
+	if err != nil {
+		log.Printf("Erro ao verificar estado das máquinas: %v", err)
+	}
+	s.annotateMachines(machines)
+	for i := range machines {
+		m := &machines[i]
+		last, known := s.events.status(m.ID)
+		switch {
+		case all || known && last != m.Status:
+			s.broadcastMachine(m)
+		case !known:
+			s.events.observe(m)
+		}
+	}
+}
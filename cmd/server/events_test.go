@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+// received retorna o nome dos eventos pendentes no canal do cliente
+func received(c *eventClient) []string {
+	var names []string
+	for {
+		select {
+		case ev, ok := <-c.events:
+			if !ok {
+				return names
+			}
+			names = append(names, ev.Name)
+		default:
+			return names
+		}
+	}
+}
+
+func TestEventHubPublish(t *testing.T) {
+	prod, _ := storage.ParseLabelSelector("env=prod")
+	web := &storage.Machine{ID: 1, GroupName: "web", Labels: map[string]string{"env": "prod"}}
+	staging := &storage.Machine{ID: 2, GroupName: "web", Labels: map[string]string{"env": "staging"}}
+	db := &storage.Machine{ID: 3, GroupName: "db"}
+
+	tests := []struct {
+		name   string
+		scope  *storage.Scope
+		filter storage.MachineFilter
+		event  serverEvent
+		want   string // evento recebido (vazio: nenhum)
+	}{
+		{"sem escopo recebe tudo", nil, storage.MachineFilter{}, serverEvent{Name: eventMachine, Machine: db}, eventMachine},
+		{"grupo fora do escopo", &storage.Scope{Groups: []string{"web"}}, storage.MachineFilter{}, serverEvent{Name: eventMachine, Machine: db}, ""},
+		{"grupo no escopo", &storage.Scope{Groups: []string{"web"}}, storage.MachineFilter{}, serverEvent{Name: eventStatus, Machine: web}, eventStatus},
+		{"fora do filtro vira remoção", nil, storage.MachineFilter{Selector: prod}, serverEvent{Name: eventMachine, Machine: staging}, eventMachineRemoved},
+		{"alerta fora do filtro é descartado", nil, storage.MachineFilter{Selector: prod}, serverEvent{Name: eventAlert, Machine: staging}, ""},
+		{"remoção sempre entregue no escopo", nil, storage.MachineFilter{Selector: prod}, serverEvent{Name: eventMachineRemoved, Machine: staging}, eventMachineRemoved},
+		{"evento sem máquina", &storage.Scope{}, storage.MachineFilter{}, serverEvent{Name: eventAlert}, eventAlert},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newEventHub()
+			c := h.subscribe(tt.scope, tt.filter, "pt-BR")
+			h.publish(tt.event)
+
+			got := received(c)
+			switch {
+			case tt.want == "" && len(got) != 0:
+				t.Errorf("eventos = %v, esperado nenhum", got)
+			case tt.want != "" && (len(got) != 1 || got[0] != tt.want):
+				t.Errorf("eventos = %v, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventHubSlowClient(t *testing.T) {
+	h := newEventHub()
+	slow := h.subscribe(nil, storage.MachineFilter{}, "pt-BR")
+	m := &storage.Machine{ID: 1, GroupName: "web"}
+
+	for i := 0; i <= eventBuffer; i++ {
+		h.publish(serverEvent{Name: eventMachine, Machine: m})
+	}
+
+	if got := len(received(slow)); got != eventBuffer {
+		t.Errorf("%d eventos recebidos, esperado %d", got, eventBuffer)
+	}
+	if _, ok := <-slow.events; ok {
+		t.Error("canal do cliente lento continua aberto")
+	}
+	if len(h.clients) != 0 {
+		t.Error("cliente lento continua inscrito")
+	}
+
+	h.close()
+	if h.subscribe(nil, storage.MachineFilter{}, "pt-BR") != nil {
+		t.Error("inscrição aceita depois do encerramento")
+	}
+}
+
+func TestEventHubObserve(t *testing.T) {
+	h := newEventHub()
+	archivedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		machine storage.Machine
+		from    string
+		changed bool
+		tracked bool
+	}{
+		{"primeiro estado", storage.Machine{ID: 1, Status: "online"}, "", false, true},
+		{"mesmo estado", storage.Machine{ID: 1, Status: "online"}, "online", false, true},
+		{"transição", storage.Machine{ID: 1, Status: "offline"}, "online", true, true},
+		{"arquivada deixa de ser acompanhada", storage.Machine{ID: 1, Status: "offline", ArchivedAt: &archivedAt}, "", false, false},
+		{"volta sem transição", storage.Machine{ID: 1, Status: "online"}, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, changed := h.observe(&tt.machine)
+			if from != tt.from || changed != tt.changed {
+				t.Errorf("observe = %q, %v; esperado %q, %v", from, changed, tt.from, tt.changed)
+			}
+			if _, ok := h.status(tt.machine.ID); ok != tt.tracked {
+				t.Errorf("acompanhada = %v, esperado %v", ok, tt.tracked)
+			}
+		})
+	}
+}
+
+func TestHandleEventsStream(t *testing.T) {
+	s := newTestServer(t, nil)
+	ts := httptest.NewServer(s.mux)
+	defer ts.Close()
+	defer s.events.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/events", nil)
+	req.Header.Set("Authorization", "Bearer admin-de-teste")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1"}`
+	if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() == "event: "+eventMachine {
+			scanner.Scan()
+			if !strings.Contains(scanner.Text(), `"hostname":"web-01"`) {
+				t.Errorf("dados do evento: %s", scanner.Text())
+			}
+			return
+		}
+	}
+	t.Fatalf("evento %s não recebido: %v", eventMachine, scanner.Err())
+}
+
+func TestResumeStatuses(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1"}`
+	if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	// Simula o reinício: o último estado gravado é offline e o hub ainda não conhece a máquina
+	machines, err := s.storage.GetMachinesWithMetrics(nil, storage.MachineFilter{})
+	if err != nil || len(machines) != 1 {
+		t.Fatalf("máquinas = %v, %v", machines, err)
+	}
+	id := machines[0].ID
+	s.events.forget(id)
+	if err := s.storage.RecordStatusEvent(id, storage.StatusOnline, storage.StatusOffline); err != nil {
+		t.Fatal(err)
+	}
+
+	s.resumeStatuses()
+	c := s.events.subscribe(nil, storage.MachineFilter{}, "pt-BR")
+	s.checkStatuses(false)
+
+	if got := received(c); len(got) == 0 || got[0] != eventStatus {
+		t.Errorf("eventos = %v, esperado a transição %s", got, eventStatus)
+	}
+	if status, _ := s.events.status(id); status != storage.StatusOnline {
+		t.Errorf("estado = %q, esperado %q", status, storage.StatusOnline)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+const (
+	// forecastCheckInterval é o intervalo da verificação dos discos que devem encher (--disk-full-alert-days)
+	forecastCheckInterval = time.Hour
+
+	// forecastMaxDays limita ?within= e ?history= em /api/forecast
+	forecastMaxDays = 3650
+)
+
+// machineForecast é a projeção do disco de uma máquina em /api/forecast
+type machineForecast struct {
+	ID          int64  `json:"id"`
+	Hostname    string `json:"hostname"`
+	DisplayName string `json:"display_name,omitempty"`
+	Group       string `json:"group"`
+	storage.DiskForecast
+}
+
+// forecastWindow é o período de histórico usado nas projeções do disco: os últimos
+// --forecast-history dias até now
+func (s *Server) forecastWindow(now time.Time) (time.Time, time.Time) {
+	return now.AddDate(0, 0, -s.config.ForecastHistory), now
+}
+
+// daysParam lê um parâmetro em dias (inteiro entre 0 e forecastMaxDays); ok é false se inválido
+func daysParam(r *http.Request, name string, fallback int) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return fallback, true
+	}
+	days, err := strconv.Atoi(v)
+	return days, err == nil && days >= 0 && days <= forecastMaxDays
+}
+
+// handleForecast projeta o uso de disco das máquinas do escopo por regressão linear sobre o
+// histórico. Parâmetros: within (dias; padrão --forecast-days; 0 lista todas as máquinas com
+// projeção), history (dias de histórico; padrão --forecast-history), group, selector e
+// include_archived. Os discos que enchem antes vêm primeiro.
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	within, ok := daysParam(r, "within", s.config.ForecastDays)
+	if !ok {
+		jsonError(w, r, "invalid_days_param", http.StatusBadRequest, "within", 0, forecastMaxDays)
+		return
+	}
+	history, ok := daysParam(r, "history", s.config.ForecastHistory)
+	if !ok || history == 0 {
+		jsonError(w, r, "invalid_days_param", http.StatusBadRequest, "history", 1, forecastMaxDays)
+		return
+	}
+
+	selector, selErr := selectorFromRequest(r)
+	if selErr != nil {
+		jsonError(w, r, selErr.Code, http.StatusBadRequest, selErr.Args...)
+		return
+	}
+	scope := scopeFor(r)
+	if group := r.URL.Query().Get("group"); group != "" {
+		if !scope.Allows(group) {
+			jsonError(w, r, "group_out_of_scope", http.StatusForbidden)
+			return
+		}
+		scope = &storage.Scope{Groups: []string{group}}
+	}
+
+	machines, err := s.storage.GetMachinesWithMetrics(scope, storage.MachineFilter{
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+		Selector:        selector,
+	})
+	if err != nil {
+		log.Printf("Erro ao buscar máquinas: %v", err)
+		jsonError(w, r, "list_machines_failed", http.StatusInternalServerError)
+		return
+	}
+
+	to := time.Now().Truncate(time.Second)
+	from := to.AddDate(0, 0, -history)
+	forecasts, err := s.storage.ForecastDisks(from, to)
+	if err != nil {
+		log.Printf("Erro ao projetar uso de disco: %v", err)
+		jsonError(w, r, "forecast_failed", http.StatusInternalServerError)
+		return
+	}
+
+	horizon := to.AddDate(0, 0, within)
+	result := []machineForecast{}
+	for _, m := range machines {
+		f, ok := forecasts[m.ID]
+		if !ok || within > 0 && !f.FullWithin(horizon) {
+			continue
+		}
+		result = append(result, machineForecast{
+			ID:           m.ID,
+			Hostname:     m.Hostname,
+			DisplayName:  m.DisplayName,
+			Group:        m.GroupName,
+			DiskForecast: f,
+		})
+	}
+
+	// Discos que enchem antes primeiro; sem previsão, os que crescem mais rápido
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].FullAt, result[j].FullAt
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a == nil {
+			return result[i].GrowthPerDay > result[j].GrowthPerDay
+		}
+		return a.Before(*b)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":     from,
+		"to":       to,
+		"within":   within,
+		"machines": result,
+	})
+}
+
+// watchForecasts publica um alerta disk_full quando a projeção indica que o disco de uma
+// máquina enche em até --disk-full-alert-days dias. O alerta é publicado uma vez e volta a
+// ser publicado se a máquina deixar de estar em risco e voltar a estar.
+func (s *Server) watchForecasts() {
+	ticker := time.NewTicker(forecastCheckInterval)
+	defer ticker.Stop()
+
+	alerted := make(map[int64]bool)
+	for {
+		alerted = s.checkForecasts(alerted)
+		<-ticker.C
+	}
+}
+
+// checkForecasts publica os alertas das máquinas não arquivadas que passaram a estar em risco
+// (alerted são as que já estavam) e retorna as que estão em risco agora
+func (s *Server) checkForecasts(alerted map[int64]bool) map[int64]bool {
+	machines, err := s.storage.GetMachinesWithMetrics(nil, storage.MachineFilter{})
+	if err != nil {
+		log.Printf("Erro ao verificar projeções de disco: %v", err)
+		return alerted
+	}
+	now := time.Now()
+	forecasts, err := s.storage.ForecastDisks(s.forecastWindow(now))
+	if err != nil {
+		log.Printf("Erro ao verificar projeções de disco: %v", err)
+		return alerted
+	}
+
+	horizon := now.AddDate(0, 0, s.config.DiskFullAlertDays)
+	atRisk := make(map[int64]bool)
+	for _, m := range machines {
+		f, ok := forecasts[m.ID]
+		if !ok || !f.FullWithin(horizon) {
+			continue
+		}
+		atRisk[m.ID] = true
+		if alerted[m.ID] {
+			continue
+		}
+		log.Printf("Máquina %s: disco deve encher em %.1f dias", m.Hostname, *f.DaysToFull)
+		s.publishAlert(m.ID, "disk_full", *f.DaysToFull, f.FullAt.Local().Format("2006-01-02"))
+	}
+	return atRisk
+}
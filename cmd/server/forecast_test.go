@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+// newForecastServer cria um servidor com três dias de relatórios de web-01, cujo disco cresce
+// 10 pontos por dia (enche em 2 dias), e de db-01, com o disco estável
+func newForecastServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestServer(t, func(c *Config) {
+		c.ForecastDays = 14
+		c.DiskFullAlertDays = 7
+	})
+
+	start := time.Now().Truncate(time.Second).Add(-73 * time.Hour)
+	for i, m := range []struct {
+		hostname, group string
+		disk            func(days float64) float64
+	}{
+		{"web-01", "web", func(d float64) float64 { return 50 + 10*d }},
+		{"db-01", "db", func(d float64) float64 { return 40 }},
+	} {
+		for offset := time.Duration(0); offset <= 72*time.Hour; offset += 3 * time.Hour {
+			_, err := s.storage.SaveMetrics(&storage.MetricPayload{
+				MachineUUID: fmt.Sprintf("8a3c9f1e-0000-4000-8000-%012d", i+1),
+				Hostname:    m.hostname,
+				IP:          "10.0.0.1",
+				GroupName:   m.group,
+				DiskPercent: m.disk(offset.Hours() / 24),
+				CollectedAt: start.Add(offset),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return s
+}
+
+func TestHandleForecast(t *testing.T) {
+	s := newForecastServer(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		status  int
+		want    []string // hostnames na ordem esperada, ou trecho da resposta de erro
+		without string
+	}{
+		{"padrão", "", http.StatusOK, []string{`"hostname":"web-01"`, `"days_to_full":2`}, "db-01"},
+		{"todas as projeções", "?within=0", http.StatusOK, []string{`"hostname":"web-01"`, `"hostname":"db-01"`}, ""},
+		{"um dia de histórico", "?history=1&within=0", http.StatusOK, []string{`"hostname":"web-01"`, `"hostname":"db-01"`}, ""},
+		{"grupo", "?group=db&within=0", http.StatusOK, []string{`"hostname":"db-01"`}, "web-01"},
+		{"prazo negativo", "?within=-1", http.StatusBadRequest, []string{"invalid_days_param"}, ""},
+		{"prazo acima do máximo", "?within=3651", http.StatusBadRequest, []string{"invalid_days_param"}, ""},
+		{"sem histórico", "?history=0", http.StatusBadRequest, []string{"invalid_days_param"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodGet, "/api/forecast"+tt.query, "", bearer("admin-de-teste"))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			body := w.Body.String()
+			last := -1
+			for _, want := range tt.want {
+				i := strings.Index(body, want)
+				if i < 0 || i < last {
+					t.Errorf("resposta sem %q na ordem esperada: %s", want, body)
+				}
+				last = i
+			}
+			if tt.without != "" && strings.Contains(body, tt.without) {
+				t.Errorf("resposta inclui %q: %s", tt.without, body)
+			}
+		})
+	}
+}
+
+func TestCheckForecasts(t *testing.T) {
+	s := newForecastServer(t)
+	const webID = 1
+
+	// O alerta é publicado ao entrar em risco e não se repete enquanto a máquina continua em risco
+	var alerted map[int64]bool
+	for round, want := range []int{1, 1} {
+		alerted = s.checkForecasts(alerted)
+		if len(alerted) != 1 || !alerted[webID] {
+			t.Fatalf("rodada %d: em risco = %v, esperado apenas web-01", round, alerted)
+		}
+		alerts, err := s.storage.ListAlerts(nil, storage.AlertFilter{Kind: "disk_full", Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(alerts) != want || alerts[0].Hostname != "web-01" {
+			t.Errorf("rodada %d: alertas disk_full = %+v, esperado %d de web-01", round, alerts, want)
+		}
+	}
+}
+
+func TestDigestForecastWindow(t *testing.T) {
+	s := newForecastServer(t)
+	s.config.DigestSchedule = digestSchedule{daily: true}
+
+	// O período do resumo (um dia, sem relatórios) não limita a projeção: ela usa os mesmos
+	// --forecast-history dias de /api/forecast
+	_, html, err := s.buildDigest(time.Now().Add(48 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "web-01") {
+		t.Errorf("resumo sem a projeção de web-01: %s", html)
+	}
+}
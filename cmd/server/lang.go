@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"monitor-infra/internal/i18n"
+)
+
+// langCookie guarda o idioma escolhido no dashboard por quem não tem usuário (ADMIN_TOKEN ou modo aberto)
+const langCookie = "lang"
+
+// apiError é um erro com código estável; a mensagem é a tradução do código (ver internal/i18n)
+// no idioma de quem fez o request
+type apiError struct {
+	Code string
+	Args []interface{}
+}
+
+// newAPIError cria um erro com o código e os argumentos da mensagem
+func newAPIError(code string, args ...interface{}) *apiError {
+	return &apiError{Code: code, Args: args}
+}
+
+func (e *apiError) Error() string {
+	return i18n.T(i18n.Default, e.Code, e.Args...)
+}
+
+// requestLanguage escolhe o idioma das respostas: a preferência do usuário (ou do dono da
+// chave de API), o cookie do dashboard e, por fim, o header Accept-Language
+func requestLanguage(r *http.Request) string {
+	if p := principalFromContext(r.Context()); p != nil {
+		switch {
+		case p.User != nil && p.User.Language != "":
+			return p.User.Language
+		case p.KeyOwner != nil && p.KeyOwner.Language != "":
+			return p.KeyOwner.Language
+		}
+	}
+	if cookie, err := r.Cookie(langCookie); err == nil {
+		if lang := i18n.Match(cookie.Value); lang != "" {
+			return lang
+		}
+	}
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// errorMessage traduz um erro: apiError e validationErrors no idioma informado; os demais como estão
+func errorMessage(lang string, err error) string {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return i18n.T(lang, apiErr.Code, apiErr.Args...)
+	}
+	var errs validationErrors
+	if errors.As(err, &errs) {
+		return errs.translate(lang).Error()
+	}
+	return err.Error()
+}
+
+// localizeArgs traduz os argumentos de uma mensagem que são erros
+func localizeArgs(lang string, args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			arg = errorMessage(lang, err)
+		}
+		out[i] = arg
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+func TestRequestLanguage(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.PublicRead = true })
+	user, session := login(t, s, "ana", storage.RoleViewer, nil)
+	_, plain := login(t, s, "bia", storage.RoleViewer, nil)
+	if err := s.storage.SetUserLanguage(user.ID, i18n.En); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{"sem preferência", nil, i18n.Default},
+		{"Accept-Language", map[string]string{"Accept-Language": "en-US,en;q=0.9"}, i18n.En},
+		{"cookie antes do Accept-Language", map[string]string{"Cookie": langCookie + "=pt-BR", "Accept-Language": "en"}, i18n.PtBR},
+		{"preferência do usuário antes do cookie", map[string]string{"Cookie": session["Cookie"] + "; " + langCookie + "=pt-BR"}, i18n.En},
+		{"usuário sem preferência segue o navegador", map[string]string{"Cookie": plain["Cookie"], "Accept-Language": "en"}, i18n.En},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodGet, "/api/me", "", tt.header)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), `"language":"`+tt.want+`"`) {
+				t.Errorf("resposta %s, esperado idioma %s", w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONErrorLanguage(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"", i18n.T(i18n.Default, "auth_required")},
+		{"en", "Authentication required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			w := serve(s, http.MethodGet, "/api/machines", "", map[string]string{"Accept-Language": tt.lang})
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), `"code":"auth_required"`) || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta %s, esperado %q", w.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpTimeout é o prazo para conectar e enviar um e-mail
+const smtpTimeout = 30 * time.Second
+
+// smtpConfigured indica se há um servidor SMTP para enviar e-mails
+func (c *Config) smtpConfigured() bool {
+	return c.SMTPHost != ""
+}
+
+// sendMail envia um e-mail em HTML pelo servidor SMTP configurado. Com --smtp-tls a conexão
+// já começa em TLS (porta 465); senão, usa STARTTLS quando o servidor oferece. A autenticação
+// (PLAIN, com --smtp-user) só é feita em conexões cifradas ou com localhost.
+func (s *Server) sendMail(to []string, subject, html string) error {
+	cfg := s.config
+	from, err := mail.ParseAddress(cfg.SMTPFrom)
+	if err != nil {
+		return fmt.Errorf("remetente inválido %q: %w", cfg.SMTPFrom, err)
+	}
+	recipients := make([]*mail.Address, len(to))
+	for i, rcpt := range to {
+		if recipients[i], err = mail.ParseAddress(rcpt); err != nil {
+			return fmt.Errorf("destinatário inválido %q: %w", rcpt, err)
+		}
+	}
+
+	addr := net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort))
+	tlsConfig := &tls.Config{ServerName: cfg.SMTPHost}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	if cfg.SMTPTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao SMTP %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("erro no SMTP %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !cfg.SMTPTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("erro no STARTTLS: %w", err)
+		}
+	}
+	if cfg.SMTPUser != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.SMTPUser, cfg.SMTPPassword, cfg.SMTPHost)); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("remetente recusado: %w", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("destinatário %s recusado: %w", rcpt.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erro ao enviar e-mail: %w", err)
+	}
+	if _, err := w.Write(buildMessage(from, recipients, subject, html)); err != nil {
+		return fmt.Errorf("erro ao enviar e-mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("erro ao enviar e-mail: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage monta a mensagem MIME: cabeçalhos, assunto codificado e corpo HTML em quoted-printable
+func buildMessage(from *mail.Address, to []*mail.Address, subject, html string) []byte {
+	id := make([]byte, 12)
+	rand.Read(id)
+	_, domain, _ := strings.Cut(from.Address, "@")
+
+	// String() codifica nomes com acentos
+	names := make([]string, len(to))
+	for i, rcpt := range to {
+		names[i] = rcpt.String()
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(names, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(id) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/html; charset=UTF-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, h := range headers {
+		msg.WriteString(h[0] + ": " + h[1] + "\r\n")
+	}
+	msg.WriteString("\r\n")
+
+	// O writer converte as quebras de linha para CRLF
+	body := quotedprintable.NewWriter(&msg)
+	body.Write([]byte(html))
+	body.Close()
+	return msg.Bytes()
+}
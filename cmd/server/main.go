@@ -1,12 +1,17 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/mail"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -16,6 +21,7 @@ import (
 	"time"
 
 	"monitor-infra/internal/dashboard"
+	"monitor-infra/internal/i18n"
 	"monitor-infra/internal/storage"
 )
 
@@ -26,10 +32,47 @@ var (
 
 // Config representa a configuração do servidor
 type Config struct {
-	Port          int
-	DBPath        string
-	Token         string
-	RetentionDays int
+	Port              int
+	DBPath            string
+	Token             string
+	AdminToken        string
+	EnrollToken       string
+	SharedTokenIngest bool
+	PublicRead        bool
+	TLSCert           string
+	TLSKey            string
+	ClientCA          string
+	RequireClientCert bool
+	SigningKey        string
+	SignatureWindow   time.Duration
+	TrustProxy        bool
+	MaxBodyBytes      int64
+	RateLimit         int
+	RateBurst         int
+	IPRateLimit       int
+	StrictPayload     bool
+	SessionTTL        time.Duration
+	RetentionDays     int
+	AuditRetention    int
+	AuditArchiveDir   string
+	MaxClockSkew      time.Duration
+	DashboardDir      string
+	StatusGroups      []string
+	StatusTitle       string
+	SMTPHost          string
+	SMTPPort          int
+	SMTPUser          string
+	SMTPPassword      string
+	SMTPFrom          string
+	SMTPTLS           bool
+	DigestTo          []string
+	DigestSchedule    digestSchedule
+	DigestLang        string
+	ForecastDays      int
+	ForecastHistory   int
+	DiskFullAlertDays int
+	AnomalySigma      float64
+	AnomalyHistory    int
 }
 
 // Server representa o servidor HTTP
@@ -37,14 +80,61 @@ type Server struct {
 	config  *Config
 	storage *storage.Storage
 	mux     *http.ServeMux
+	nonces  *nonceCache
+	limiter *rateLimiter
+	events  *eventHub
+
+	ipLimiter *rateLimiter // limite por IP antes da autenticação
+	failures  *failureThrottle
+	anomalies *anomalyTracker
+
+	dashboard *dashboard.Assets
+	status    statusCache
 }
 
 func main() {
 	// Flags de linha de comando
 	port := flag.Int("port", getEnvInt("PORT", 8080), "Porta do servidor")
 	dbPath := flag.String("db", getEnv("DB_PATH", "./data/monitor.db"), "Caminho do banco de dados SQLite")
-	token := flag.String("token", getEnv("AUTH_TOKEN", ""), "Token de autenticação")
+	token := flag.String("token", getEnv("AUTH_TOKEN", ""), "Token compartilhado dos agents (apenas envio de métricas)")
+	adminToken := flag.String("admin-token", getEnv("ADMIN_TOKEN", ""), "Token de administração da API (acesso de administrador)")
+	enrollToken := flag.String("enroll-token", getEnv("ENROLL_TOKEN", ""), "Token de inscrição de agents (emite credenciais próprias)")
+	sharedTokenIngest := flag.Bool("shared-token-ingest", getEnvBool("SHARED_TOKEN_INGEST", true), "Aceitar o token compartilhado no envio de métricas")
+	publicRead := flag.Bool("public-read", getEnvBool("PUBLIC_READ", false), "Dashboard e API de leitura sem autenticação")
+	sessionHours := flag.Int("session-hours", getEnvInt("SESSION_HOURS", 168), "Duração das sessões do dashboard em horas")
+	tlsCert := flag.String("tls-cert", getEnv("TLS_CERT", ""), "Certificado TLS do servidor (PEM)")
+	tlsKey := flag.String("tls-key", getEnv("TLS_KEY", ""), "Chave privada TLS do servidor (PEM)")
+	clientCA := flag.String("client-ca", getEnv("TLS_CLIENT_CA", ""), "CA para verificar certificados de cliente dos agents (PEM)")
+	requireClientCert := flag.Bool("require-client-cert", getEnvBool("REQUIRE_CLIENT_CERT", false), "Exigir certificado de cliente no envio de métricas")
+	signingKey := flag.String("signing-key", getEnv("SIGNING_KEY", ""), "Chave HMAC para exigir envios de métricas assinados")
+	signatureWindow := flag.Int("signature-window", getEnvInt("SIGNATURE_WINDOW_SECONDS", 300), "Validade das assinaturas em segundos")
+	trustProxy := flag.Bool("trust-proxy", getEnvBool("TRUST_PROXY", false), "Usar X-Forwarded-For como IP de origem (servidor atrás de proxy)")
+	maxBodyKB := flag.Int("max-body-kb", getEnvInt("MAX_BODY_KB", 1024), "Tamanho máximo do corpo dos envios de métricas, em KB")
+	rateLimit := flag.Int("rate-limit", getEnvInt("RATE_LIMIT", 60), "Envios de métricas por minuto por agent ou IP (0 desativa)")
+	rateBurst := flag.Int("rate-burst", getEnvInt("RATE_BURST", 30), "Rajada de envios permitida acima do limite por minuto")
+	ipRateLimit := flag.Int("ip-rate-limit", getEnvInt("IP_RATE_LIMIT", 600), "Envios de métricas por minuto por IP, contados antes da autenticação (0 desativa)")
+	strictPayload := flag.Bool("strict-payload", getEnvBool("STRICT_PAYLOAD", false), "Recusar envios de métricas com campos desconhecidos")
 	retentionDays := flag.Int("retention", getEnvInt("RETENTION_DAYS", 90), "Dias de retenção de métricas")
+	auditRetention := flag.Int("audit-retention", getEnvInt("AUDIT_RETENTION_DAYS", 0), "Dias de retenção do log de auditoria (0 mantém para sempre)")
+	auditArchiveDir := flag.String("audit-archive-dir", getEnv("AUDIT_ARCHIVE_DIR", ""), "Diretório onde a auditoria removida pela retenção é arquivada em JSON Lines")
+	maxSkew := flag.Int("max-skew", getEnvInt("MAX_CLOCK_SKEW_SECONDS", 300), "Diferença máxima de relógio tolerada dos agents, em segundos")
+	dashboardDir := flag.String("dashboard-dir", getEnv("DASHBOARD_DIR", ""), "Diretório com os arquivos do dashboard, relidos a cada request (desenvolvimento)")
+	statusGroups := flag.String("status-groups", getEnv("STATUS_GROUPS", ""), "Grupos exibidos na página de status pública e nos badges, separados por vírgula")
+	statusTitle := flag.String("status-title", getEnv("STATUS_TITLE", "Monitor Infra"), "Título da página de status pública")
+	smtpHost := flag.String("smtp-host", getEnv("SMTP_HOST", ""), "Servidor SMTP para o envio de e-mails")
+	smtpPort := flag.Int("smtp-port", getEnvInt("SMTP_PORT", 587), "Porta do servidor SMTP")
+	smtpUser := flag.String("smtp-user", getEnv("SMTP_USER", ""), "Usuário SMTP (vazio: sem autenticação)")
+	smtpPassword := flag.String("smtp-password", getEnv("SMTP_PASSWORD", ""), "Senha SMTP")
+	smtpFrom := flag.String("smtp-from", getEnv("SMTP_FROM", ""), "Remetente dos e-mails (padrão: o usuário SMTP)")
+	smtpTLS := flag.Bool("smtp-tls", getEnvBool("SMTP_TLS", false), "Conectar ao SMTP direto em TLS (porta 465); sem ele, usa STARTTLS quando disponível")
+	digestTo := flag.String("digest-to", getEnv("DIGEST_TO", ""), "Destinatários do resumo por e-mail, separados por vírgula")
+	digestScheduleFlag := flag.String("digest-schedule", getEnv("DIGEST_SCHEDULE", "mon 08:00"), "Envio do resumo: \"<dia> HH:MM\" (sun a sat ou daily)")
+	digestLang := flag.String("digest-lang", getEnv("DIGEST_LANG", i18n.Default), "Idioma do resumo por e-mail (pt-BR ou en)")
+	forecastDays := flag.Int("forecast-days", getEnvInt("FORECAST_DAYS", 14), "Destacar discos que devem encher em até N dias")
+	forecastHistory := flag.Int("forecast-history", getEnvInt("FORECAST_HISTORY_DAYS", 7), "Dias de histórico usados na projeção do uso de disco")
+	diskFullAlertDays := flag.Int("disk-full-alert-days", getEnvInt("DISK_FULL_ALERT_DAYS", 0), "Alertar quando o disco deve encher em até N dias (0 desativa)")
+	anomalySigma := flag.Float64("anomaly-sigma", getEnvFloat("ANOMALY_SIGMA", 3), "Desvios padrão da baseline a partir dos quais uma métrica é anomalia (0 desativa)")
+	anomalyHistory := flag.Int("anomaly-history", getEnvInt("ANOMALY_HISTORY_DAYS", 28), "Dias de histórico usados nas baselines da detecção de anomalias")
 	version := flag.Bool("version", false, "Mostrar versão")
 
 	flag.Parse()
@@ -54,11 +144,79 @@ func main() {
 		os.Exit(0)
 	}
 
+	schedule, err := parseDigestSchedule(*digestScheduleFlag)
+	if err != nil {
+		log.Fatalf("Erro em --digest-schedule: %v", err)
+	}
+	lang := i18n.Match(*digestLang)
+	if lang == "" {
+		log.Fatalf("Erro em --digest-lang: idioma não suportado %q", *digestLang)
+	}
+	if *smtpFrom == "" {
+		*smtpFrom = *smtpUser
+	}
+	if *smtpHost != "" {
+		if _, err := mail.ParseAddress(*smtpFrom); err != nil {
+			log.Fatalf("Erro em --smtp-from: remetente inválido %q (informe um e-mail)", *smtpFrom)
+		}
+	}
+	if *adminToken != "" && *adminToken == *token {
+		log.Fatal("Erro em --admin-token: use um valor diferente do --token, que fica nos agents")
+	}
+	if *forecastHistory < 1 {
+		log.Fatal("Erro em --forecast-history: informe ao menos 1 dia")
+	}
+	if *anomalySigma > 0 && *anomalyHistory < 14 {
+		log.Fatal("Erro em --anomaly-history: informe ao menos 14 dias (duas semanas de cada hora)")
+	}
+	for _, rcpt := range splitList(*digestTo) {
+		if _, err := mail.ParseAddress(rcpt); err != nil {
+			log.Fatalf("Erro em --digest-to: destinatário inválido %q", rcpt)
+		}
+	}
+
 	config := &Config{
-		Port:          *port,
-		DBPath:        *dbPath,
-		Token:         *token,
-		RetentionDays: *retentionDays,
+		Port:              *port,
+		DBPath:            *dbPath,
+		Token:             *token,
+		AdminToken:        *adminToken,
+		EnrollToken:       *enrollToken,
+		SharedTokenIngest: *sharedTokenIngest,
+		PublicRead:        *publicRead,
+		TLSCert:           *tlsCert,
+		TLSKey:            *tlsKey,
+		ClientCA:          *clientCA,
+		RequireClientCert: *requireClientCert,
+		SigningKey:        *signingKey,
+		SignatureWindow:   time.Duration(*signatureWindow) * time.Second,
+		TrustProxy:        *trustProxy,
+		MaxBodyBytes:      int64(*maxBodyKB) * 1024,
+		RateLimit:         *rateLimit,
+		RateBurst:         *rateBurst,
+		IPRateLimit:       *ipRateLimit,
+		StrictPayload:     *strictPayload,
+		SessionTTL:        time.Duration(*sessionHours) * time.Hour,
+		RetentionDays:     *retentionDays,
+		AuditRetention:    *auditRetention,
+		AuditArchiveDir:   *auditArchiveDir,
+		MaxClockSkew:      time.Duration(*maxSkew) * time.Second,
+		DashboardDir:      *dashboardDir,
+		StatusGroups:      splitList(*statusGroups),
+		StatusTitle:       *statusTitle,
+		SMTPHost:          *smtpHost,
+		SMTPPort:          *smtpPort,
+		SMTPUser:          *smtpUser,
+		SMTPPassword:      *smtpPassword,
+		SMTPFrom:          *smtpFrom,
+		SMTPTLS:           *smtpTLS,
+		DigestTo:          splitList(*digestTo),
+		DigestSchedule:    schedule,
+		DigestLang:        lang,
+		ForecastDays:      *forecastDays,
+		ForecastHistory:   *forecastHistory,
+		DiskFullAlertDays: *diskFullAlertDays,
+		AnomalySigma:      *anomalySigma,
+		AnomalyHistory:    *anomalyHistory,
 	}
 
 	// Criar diretório do banco se não existir
@@ -67,6 +225,12 @@ func main() {
 		log.Fatalf("Erro ao criar diretório do banco: %v", err)
 	}
 
+	// Carregar o dashboard (embutido ou do diretório de override)
+	assets, err := dashboard.New(config.DashboardDir)
+	if err != nil {
+		log.Fatalf("Erro ao carregar dashboard: %v", err)
+	}
+
 	// Inicializar storage
 	store, err := storage.New(config.DBPath, config.RetentionDays)
 	if err != nil {
@@ -82,6 +246,9 @@ func main() {
 		} else if deleted > 0 {
 			log.Printf("Limpeza: %d métricas antigas removidas", deleted)
 		}
+		if _, err := store.CleanupOldEvents(); err != nil {
+			log.Printf("Aviso: %v", err)
+		}
 	}()
 
 	// Criar servidor
@@ -89,6 +256,19 @@ func main() {
 		config:  config,
 		storage: store,
 		mux:     http.NewServeMux(),
+		nonces:  newNonceCache(config.SignatureWindow),
+		limiter: newRateLimiter(config.RateLimit, config.RateBurst),
+		events:  newEventHub(),
+
+		ipLimiter: newRateLimiter(config.IPRateLimit, config.IPRateLimit/2), // rajada de meio minuto
+		failures:  newFailureThrottle(),
+		anomalies: newAnomalyTracker(),
+		dashboard: assets,
+	}
+
+	// Criar administrador inicial (ADMIN_USER/ADMIN_PASSWORD) se não houver usuários
+	if err := server.seedAdmin(getEnv("ADMIN_USER", ""), getEnv("ADMIN_PASSWORD", "")); err != nil {
+		log.Fatalf("Erro ao criar administrador: %v", err)
 	}
 
 	// Registrar rotas
@@ -102,6 +282,26 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Streams de eventos abertos não impedem o encerramento
+	httpServer.RegisterOnShutdown(server.events.close)
+
+	// TLS embutido (opcional), com verificação de certificados de cliente
+	useTLS := config.TLSCert != "" || config.TLSKey != ""
+	if useTLS {
+		if config.TLSCert == "" || config.TLSKey == "" {
+			log.Fatal("Erro: --tls-cert e --tls-key devem ser informados juntos")
+		}
+		tlsConfig, err := newTLSConfig(config.ClientCA)
+		if err != nil {
+			log.Fatalf("Erro ao configurar TLS: %v", err)
+		}
+		httpServer.TLSConfig = tlsConfig
+	} else if config.ClientCA != "" || config.RequireClientCert {
+		log.Fatal("Erro: --client-ca e --require-client-cert exigem --tls-cert e --tls-key")
+	}
+	if config.RequireClientCert && config.ClientCA == "" {
+		log.Fatal("Erro: --require-client-cert exige --client-ca")
+	}
 
 	// Iniciar servidor em goroutine
 	go func() {
@@ -111,15 +311,77 @@ func main() {
 		} else {
 			log.Println("Autenticação via token: DESATIVADA")
 		}
-		log.Printf("Dashboard: http://localhost:%d", config.Port)
+		if config.AdminToken != "" {
+			log.Println("Token de administração: ATIVADO")
+		}
+		if config.EnrollToken != "" {
+			log.Println("Inscrição de agents: ATIVADA")
+		}
+		if config.PublicRead {
+			log.Println("Aviso: dashboard e API de leitura públicos (--public-read)")
+		}
+		if config.DashboardDir != "" {
+			log.Printf("Dashboard lido de %s (recarregado a cada request)", config.DashboardDir)
+		}
+		if len(config.StatusGroups) > 0 {
+			log.Printf("Página de status pública: /status (grupos: %s)", strings.Join(config.StatusGroups, ", "))
+		}
+		if len(config.DigestTo) > 0 {
+			if config.smtpConfigured() {
+				log.Printf("Resumo por e-mail: %s, para %s", *digestScheduleFlag, strings.Join(config.DigestTo, ", "))
+			} else {
+				log.Println("Aviso: --digest-to sem --smtp-host; resumo por e-mail desativado")
+			}
+		}
+		if config.DiskFullAlertDays > 0 {
+			log.Printf("Alerta de disco: máquinas que devem encher em até %d dias", config.DiskFullAlertDays)
+		}
+		if config.AnomalySigma > 0 {
+			log.Printf("Detecção de anomalias: %.1f desvios padrão (baselines de %d dias)", config.AnomalySigma, config.AnomalyHistory)
+		}
+		if config.SigningKey != "" {
+			log.Println("Assinatura HMAC dos envios: OBRIGATÓRIA")
+		}
+		if config.RateLimit > 0 {
+			log.Printf("Limite de envios: %d/min por agent (rajada: %d)", config.RateLimit, config.RateBurst)
+		}
+		if config.ClientCA != "" {
+			if config.RequireClientCert {
+				log.Println("Certificado de cliente: OBRIGATÓRIO no envio de métricas")
+			} else {
+				log.Println("Certificado de cliente: verificado quando apresentado")
+			}
+		}
 
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if useTLS {
+			log.Printf("Dashboard: https://localhost:%d", config.Port)
+			err = httpServer.ListenAndServeTLS(config.TLSCert, config.TLSKey)
+		} else {
+			log.Printf("Dashboard: http://localhost:%d", config.Port)
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Erro no servidor: %v", err)
 		}
 	}()
 
 	// Agendar limpeza diária
 	go server.scheduleDailyCleanup()
+	go server.archiveAudit(time.Now())
+	go server.flushAuditFailures()
+	if config.smtpConfigured() && len(config.DigestTo) > 0 {
+		go server.scheduleDigest()
+	}
+
+	// Publicar máquinas que ficam offline
+	go server.watchStatus()
+	if config.DiskFullAlertDays > 0 {
+		go server.watchForecasts()
+	}
+	if config.AnomalySigma > 0 {
+		go server.watchAnomalies()
+	}
 
 	// Aguardar sinal de término
 	sigChan := make(chan os.Signal, 1)
@@ -134,46 +396,65 @@ func main() {
 		log.Printf("Erro ao encerrar servidor: %v", err)
 	}
 
+	// Gravar as falhas de autenticação agregadas da janela atual
+	for _, w := range server.failures.expired(time.Now().Add(auditFailureWindow)) {
+		server.auditSuppressed(w)
+	}
+
 	log.Println("Servidor encerrado com sucesso")
 }
 
 // registerRoutes registra todas as rotas do servidor
 func (s *Server) registerRoutes() {
 	// API endpoints
-	s.mux.HandleFunc("/api/metrics", s.authMiddleware(s.handleMetrics))
-	s.mux.HandleFunc("/api/machines", s.handleMachines)
-	s.mux.HandleFunc("/api/machines/", s.handleMachineDetail)
-	s.mux.HandleFunc("/api/stats", s.handleStats)
+	s.mux.HandleFunc("/api/metrics", s.ingestMiddleware(s.handleMetrics))
+	s.mux.HandleFunc("/api/metrics/batch", s.ingestMiddleware(s.handleMetricsBatch))
+	s.mux.HandleFunc("/api/machines", s.readMiddleware(s.handleMachines))
+	s.mux.HandleFunc("/api/machines/", s.readMiddleware(s.handleMachineDetail))
+	s.mux.HandleFunc("/api/stats", s.readMiddleware(s.handleStats))
+	s.mux.HandleFunc("/api/events", s.readMiddleware(s.handleEvents))
+	s.mux.HandleFunc("/api/views", s.readMiddleware(s.handleViews))
+	s.mux.HandleFunc("/api/views/", s.readMiddleware(s.handleViewDetail))
+	s.mux.HandleFunc("/api/thresholds", s.readMiddleware(s.handleThresholds))
+	s.mux.HandleFunc("/api/thresholds/", s.readMiddleware(s.handleThresholdDetail))
+	s.mux.HandleFunc("/api/reports/uptime", s.readMiddleware(s.handleUptimeReport))
+	s.mux.HandleFunc("/api/forecast", s.readMiddleware(s.handleForecast))
+	s.mux.HandleFunc("/api/alerts", s.readMiddleware(s.handleAlerts))
 	s.mux.HandleFunc("/api/health", s.handleHealth)
 
+	// Login, usuários e chaves de API
+	s.mux.HandleFunc("/login", s.handleLogin)
+	s.mux.HandleFunc("/logout", s.handleLogout)
+	s.mux.HandleFunc("/api/me", s.readMiddleware(s.handleMe))
+	s.mux.HandleFunc("/api/users", s.adminMiddleware(s.handleUsers))
+	s.mux.HandleFunc("/api/users/", s.adminMiddleware(s.handleUserDetail))
+	s.mux.HandleFunc("/api/keys", s.adminMiddleware(s.handleAPIKeys))
+	s.mux.HandleFunc("/api/keys/", s.adminMiddleware(s.handleAPIKeyDetail))
+	s.mux.HandleFunc("/api/audit", s.adminMiddleware(s.handleAudit))
+	s.mux.HandleFunc("/api/digest", s.adminMiddleware(s.handleDigest))
+
+	// Credenciais de agents
+	s.mux.HandleFunc("/api/agents/enroll", s.bodyLimitMiddleware(s.rateLimitMiddleware(s.handleAgentEnroll)))
+	s.mux.HandleFunc("/api/agents", s.adminMiddleware(s.handleAgents))
+	s.mux.HandleFunc("/api/agents/", s.adminMiddleware(s.handleAgentDetail))
+
 	// Downloads e instalação
 	s.mux.HandleFunc("/install.sh", s.handleInstallScript)
+	s.mux.HandleFunc(dashboard.StaticPrefix, s.handleStatic)
+
+	// Página de status e badges públicos (apenas os grupos de --status-groups)
+	s.mux.HandleFunc("/status", s.handleStatusPage)
+	s.mux.HandleFunc("/badge/", s.handleBadge)
 	s.mux.HandleFunc("/download/", s.handleDownload)
 
 	// Dashboard
-	s.mux.HandleFunc("/", s.handleDashboard)
+	s.mux.HandleFunc("/", s.readMiddleware(s.handleDashboard))
 }
 
-// authMiddleware verifica o token de autenticação
-func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Se não há token configurado, permitir acesso
-		if s.config.Token == "" {
-			next(w, r)
-			return
-		}
-
-		// Verificar header Authorization
-		auth := r.Header.Get("Authorization")
-		expectedAuth := "Bearer " + s.config.Token
-
-		if auth != expectedAuth {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		next(w, r)
-	}
+// ingestMiddleware encadeia as verificações do envio de métricas: tamanho do corpo, limite
+// por IP, autenticação, limite de envios por remetente e assinatura
+func (s *Server) ingestMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.bodyLimitMiddleware(s.ipRateLimitMiddleware(s.authMiddleware(s.rateLimitMiddleware(s.signatureMiddleware(next)))))
 }
 
 // handleMetrics recebe métricas do agent
@@ -184,50 +465,286 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var payload storage.MetricPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		jsonError(w, "Erro ao decodificar payload", http.StatusBadRequest)
+	if err := s.newPayloadDecoder(r.Body).Decode(&payload); err != nil {
+		payloadError(w, r, err, "invalid_json")
 		return
 	}
 
-	// Validar campos obrigatórios
-	if payload.Hostname == "" {
-		jsonError(w, "hostname é obrigatório", http.StatusBadRequest)
+	receivedAt := time.Now()
+
+	if err := s.bindAgentIdentity(r, &payload); err != nil {
+		identityError(w, r, err)
+		return
+	}
+
+	if err := s.validatePayload(&payload, receivedAt); err != nil {
+		s.recordRejected(&payload, err)
+		validationError(w, r, err)
 		return
 	}
 
 	// Salvar métricas
-	machineID, err := s.storage.SaveMetrics(&payload)
+	result, err := s.storage.SaveMetrics(&payload)
 	if err != nil {
 		log.Printf("Erro ao salvar métricas: %v", err)
-		jsonError(w, "Erro ao salvar métricas", http.StatusInternalServerError)
+		jsonError(w, r, "save_metrics_failed", http.StatusInternalServerError)
 		return
 	}
+	machineID := result.MachineID
 
-	log.Printf("Métricas recebidas: %s (ID: %d)", payload.Hostname, machineID)
+	s.recordClockSkew(&payload, machineID, receivedAt)
+	s.warnHostnameConflict(&payload, result)
+	s.publishMachine(machineID)
+
+	if result.Duplicate {
+		log.Printf("Métricas duplicadas ignoradas: %s (ID: %d)", payload.Hostname, machineID)
+	} else {
+		log.Printf("Métricas recebidas: %s (ID: %d)", payload.Hostname, machineID)
+	}
 
 	// Resposta de sucesso
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":     "ok",
-		"machine_id": machineID,
+		"status":            "ok",
+		"machine_id":        machineID,
+		"duplicate":         result.Duplicate,
+		"hostname_conflict": result.HostnameConflict,
 	})
 }
 
-// handleMachines lista todas as máquinas
+// warnHostnameConflict registra quando outra máquina online já usa o hostname do payload
+func (s *Server) warnHostnameConflict(payload *storage.MetricPayload, result storage.SaveResult) {
+	if result.HostnameConflict {
+		log.Printf("Aviso: hostname %s em uso por outra máquina (ID: %d, uuid: %s)",
+			payload.Hostname, result.MachineID, payload.MachineUUID)
+		s.publishAlert(result.MachineID, "hostname_conflict")
+	}
+}
+
+// batchItemResult representa o resultado de um item do lote
+type batchItemResult struct {
+	Index     int    `json:"index"`
+	Status    string `json:"status"`
+	MachineID int64  `json:"machine_id,omitempty"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+	Conflict  bool   `json:"hostname_conflict,omitempty"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+
+	Errors validationErrors `json:"errors,omitempty"`
+}
+
+// handleMetricsBatch recebe várias amostras (de uma ou mais máquinas) em um único request.
+// O corpo é um array JSON de payloads, opcionalmente com Content-Encoding: gzip.
+func (s *Server) handleMetricsBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			jsonError(w, r, "invalid_gzip", http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		// O limite vale também para o conteúdo descomprimido
+		body = http.MaxBytesReader(w, gz, s.config.MaxBodyBytes)
+	}
+
+	var payloads []storage.MetricPayload
+	if err := s.newPayloadDecoder(body).Decode(&payloads); err != nil {
+		payloadError(w, r, err, "invalid_batch")
+		return
+	}
+
+	if len(payloads) == 0 {
+		jsonError(w, r, "empty_batch", http.StatusBadRequest)
+		return
+	}
+
+	receivedAt := time.Now()
+	lang := requestLanguage(r)
+	results := make([]batchItemResult, len(payloads))
+
+	// Validar cada item; apenas os válidos vão para a transação
+	var valid []*storage.MetricPayload
+	var validIndex []int
+	for i := range payloads {
+		results[i].Index = i
+		if err := s.bindAgentIdentity(r, &payloads[i]); err != nil {
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				identityError(w, r, err)
+				return
+			}
+			results[i].Status = "error"
+			results[i].Code = apiErr.Code
+			results[i].Message = errorMessage(lang, apiErr)
+			continue
+		}
+		if err := s.validatePayload(&payloads[i], receivedAt); err != nil {
+			s.recordRejected(&payloads[i], err)
+			results[i].Status = "error"
+			results[i].Code = "invalid_payload"
+			results[i].Message = i18n.T(lang, "invalid_payload", errorMessage(lang, err))
+			var errs validationErrors
+			if errors.As(err, &errs) {
+				results[i].Errors = errs.translate(lang)
+			}
+			continue
+		}
+		valid = append(valid, &payloads[i])
+		validIndex = append(validIndex, i)
+	}
+
+	accepted := 0
+	if len(valid) > 0 {
+		saved, err := s.storage.SaveMetricsBatch(valid)
+		if err != nil {
+			log.Printf("Erro ao salvar lote de métricas: %v", err)
+			jsonError(w, r, "save_metrics_failed", http.StatusInternalServerError)
+			return
+		}
+
+		// Clock skew e conflitos são verificados uma vez por máquina, com o último item do lote
+		lastByMachine := make(map[int64]int)
+		for j, result := range saved {
+			i := validIndex[j]
+			results[i].Status = "ok"
+			results[i].MachineID = result.MachineID
+			results[i].Duplicate = result.Duplicate
+			results[i].Conflict = result.HostnameConflict
+			lastByMachine[result.MachineID] = j
+			if !result.Duplicate {
+				accepted++
+			}
+		}
+
+		for machineID, j := range lastByMachine {
+			s.recordClockSkew(valid[j], machineID, receivedAt)
+			s.warnHostnameConflict(valid[j], saved[j])
+			s.publishMachine(machineID)
+		}
+	}
+
+	log.Printf("Lote de métricas recebido: %d itens (%d novos, %d rejeitados)",
+		len(payloads), accepted, len(payloads)-len(valid))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "ok",
+		"accepted": accepted,
+		"rejected": len(payloads) - len(valid),
+		"results":  results,
+	})
+}
+
+// isUUID verifica o formato textual canônico de um UUID (8-4-4-4-12 hexadecimal)
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// recordClockSkew mede e registra a diferença de relógio entre agent e servidor
+func (s *Server) recordClockSkew(payload *storage.MetricPayload, machineID int64, receivedAt time.Time) {
+	if payload.SentAt.IsZero() {
+		return
+	}
+
+	skew := payload.SentAt.Sub(receivedAt)
+	if err := s.storage.RecordClockSkew(machineID, skew.Seconds()); err != nil {
+		log.Printf("Aviso: %v", err)
+	}
+	if s.isClockDrift(skew.Seconds()) {
+		log.Printf("Aviso: relógio de %s está %.0fs fora do servidor", payload.Hostname, skew.Seconds())
+		s.publishAlert(machineID, "clock_drift", skew.Seconds())
+	}
+}
+
+// validateCollectedAt verifica se o horário de coleta informado está dentro da janela aceita:
+// no máximo MaxClockSkew no futuro e não mais antigo que a retenção
+func (s *Server) validateCollectedAt(collectedAt, now time.Time) *apiError {
+	if collectedAt.IsZero() {
+		return nil
+	}
+
+	if collectedAt.After(now.Add(s.config.MaxClockSkew)) {
+		return newAPIError("in_future", collectedAt.Sub(now).Seconds(), s.config.MaxClockSkew.Seconds())
+	}
+
+	oldest := now.AddDate(0, 0, -s.config.RetentionDays)
+	if collectedAt.Before(oldest) {
+		return newAPIError("before_retention", s.config.RetentionDays)
+	}
+
+	return nil
+}
+
+// isClockDrift indica se a diferença de relógio excede a tolerância configurada
+func (s *Server) isClockDrift(skewSeconds float64) bool {
+	return math.Abs(skewSeconds) > s.config.MaxClockSkew.Seconds()
+}
+
+// annotateMachine completa os dados calculados pelo servidor: relógio fora da tolerância e
+// métricas fora do padrão
+func (s *Server) annotateMachine(m *storage.Machine) {
+	if m.ClockSkew != nil {
+		m.ClockDrift = s.isClockDrift(*m.ClockSkew)
+	}
+	m.Anomalies = s.anomalies.get(m.ID)
+}
+
+// annotateMachines aplica annotateMachine a cada máquina
+func (s *Server) annotateMachines(machines []storage.Machine) {
+	for i := range machines {
+		s.annotateMachine(&machines[i])
+	}
+}
+
+// handleMachines lista as máquinas; arquivadas só com ?include_archived=true e,
+// com ?selector=, apenas as que atendem ao seletor de labels
 func (s *Server) handleMachines(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	machines, err := s.storage.GetMachinesWithMetrics()
+	selector, selErr := selectorFromRequest(r)
+	if selErr != nil {
+		jsonError(w, r, selErr.Code, http.StatusBadRequest, selErr.Args...)
+		return
+	}
+
+	machines, err := s.storage.GetMachinesWithMetrics(scopeFor(r), storage.MachineFilter{
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+		Selector:        selector,
+	})
 	if err != nil {
 		log.Printf("Erro ao buscar máquinas: %v", err)
-		jsonError(w, "Erro ao buscar máquinas", http.StatusInternalServerError)
+		jsonError(w, r, "list_machines_failed", http.StatusInternalServerError)
 		return
 	}
 
+	s.annotateMachines(machines)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"machines": machines,
@@ -236,22 +753,55 @@ func (s *Server) handleMachines(w http.ResponseWriter, r *http.Request) {
 
 // handleMachineDetail retorna detalhes de uma máquina específica
 func (s *Server) handleMachineDetail(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	// Extrair ID da URL
 	path := strings.TrimPrefix(r.URL.Path, "/api/machines/")
 	parts := strings.Split(path, "/")
 	if len(parts) == 0 || parts[0] == "" {
-		jsonError(w, "ID da máquina é obrigatório", http.StatusBadRequest)
+		jsonError(w, r, "machine_id_required", http.StatusBadRequest)
 		return
 	}
 
 	machineID, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
-		jsonError(w, "ID inválido", http.StatusBadRequest)
+		jsonError(w, r, "invalid_id", http.StatusBadRequest)
+		return
+	}
+
+	// Alterações exigem papel de editor
+	switch {
+	case len(parts) > 1 && parts[1] == "merge":
+		// Mesclar registro duplicado em outra máquina
+		s.editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			s.handleMachineMerge(w, r, machineID)
+		})(w, r)
+		return
+	case len(parts) == 1 && r.Method == http.MethodPatch:
+		s.editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			s.handleMachineUpdate(w, r, machineID)
+		})(w, r)
+		return
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		s.editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			s.handleMachineDelete(w, r, machineID)
+		})(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Buscar máquina (fora do escopo do usuário é tratada como inexistente)
+	machine, err := s.storage.GetMachineByID(machineID, scopeFor(r))
+	if err != nil {
+		log.Printf("Erro ao buscar máquina: %v", err)
+		jsonError(w, r, "get_machine_failed", http.StatusInternalServerError)
+		return
+	}
+
+	if machine == nil {
+		jsonError(w, r, "machine_not_found", http.StatusNotFound)
 		return
 	}
 
@@ -264,10 +814,10 @@ func (s *Server) handleMachineDetail(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		history, err := s.storage.GetMetricsHistory(machineID, hours)
+		history, err := s.storage.GetMetricsHistory(machineID, hours, scopeFor(r))
 		if err != nil {
 			log.Printf("Erro ao buscar histórico: %v", err)
-			jsonError(w, "Erro ao buscar histórico", http.StatusInternalServerError)
+			jsonError(w, r, "history_failed", http.StatusInternalServerError)
 			return
 		}
 
@@ -280,21 +830,166 @@ func (s *Server) handleMachineDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Buscar máquina
-	machine, err := s.storage.GetMachineByID(machineID)
+	s.annotateMachine(machine)
+	from, to := s.forecastWindow(time.Now())
+	if machine.DiskForecast, err = s.storage.ForecastDisk(machineID, from, to); err != nil {
+		log.Printf("Aviso: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(machine)
+}
+
+// handleMachineMerge une o histórico da máquina ao de outra e remove o registro duplicado.
+// Corpo: {"into": <id da máquina que permanece>}
+func (s *Server) handleMachineMerge(w http.ResponseWriter, r *http.Request, machineID int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Into int64 `json:"into"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Into == 0 {
+		jsonError(w, r, "merge_target_required", http.StatusBadRequest)
+		return
+	}
+
+	if body.Into == machineID {
+		jsonError(w, r, "merge_same_machine", http.StatusBadRequest)
+		return
+	}
+
+	source, _ := s.storage.GetMachineByID(machineID, scopeFor(r))
+
+	if err := s.storage.MergeMachines(machineID, body.Into, scopeFor(r)); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonError(w, r, "machine_not_found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Erro ao mesclar máquinas: %v", err)
+		jsonError(w, r, "merge_failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Máquina %d mesclada em %d", machineID, body.Into)
+	target, _ := s.storage.GetMachineByID(body.Into, scopeFor(r))
+	s.audit(r, storage.AuditEntry{Action: "machine.merge", Target: fmt.Sprintf("machine:%d", machineID)}, source, target)
+	s.publishRemoved(source)
+	s.publishMachine(body.Into)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "ok",
+		"machine_id": body.Into,
+	})
+}
+
+// handleMachineUpdate altera nome de exibição, grupo, notas, labels ou arquivamento de uma máquina.
+// Corpo (campos opcionais): {"display_name": "...", "group": "...", "notes": "...", "archived": true,
+// "labels": {"env": "prod", "antigo": null}}
+func (s *Server) handleMachineUpdate(w http.ResponseWriter, r *http.Request, machineID int64) {
+	var update storage.MachineUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		jsonError(w, r, "invalid_json", http.StatusBadRequest)
+		return
+	}
+
+	var errs validationErrors
+	if update.DisplayName != nil {
+		*update.DisplayName = strings.TrimSpace(*update.DisplayName)
+		errs.checkText("display_name", *update.DisplayName, maxHostnameLength)
+	}
+	if update.GroupName != nil {
+		*update.GroupName = strings.TrimSpace(*update.GroupName)
+		if *update.GroupName == "" {
+			errs.add("group", "empty")
+		} else {
+			errs.checkText("group", *update.GroupName, maxGroupLength)
+		}
+	}
+	if update.Notes != nil && len(*update.Notes) > maxNotesLength {
+		errs.add("notes", "too_long", maxNotesLength)
+	}
+	if len(update.Labels) > storage.MaxLabels {
+		errs.add("labels", "too_many_labels", storage.MaxLabels)
+	}
+	for key, value := range update.Labels {
+		errs.checkLabel("labels", key, value)
+	}
+	if len(errs) > 0 {
+		validationError(w, r, errs)
+		return
+	}
+
+	scope := scopeFor(r)
+	if update.GroupName != nil && !scope.Allows(*update.GroupName) {
+		jsonError(w, r, "group_out_of_scope", http.StatusForbidden)
+		return
+	}
+
+	before, err := s.storage.GetMachineByID(machineID, scope)
 	if err != nil {
 		log.Printf("Erro ao buscar máquina: %v", err)
-		jsonError(w, "Erro ao buscar máquina", http.StatusInternalServerError)
+		jsonError(w, r, "get_machine_failed", http.StatusInternalServerError)
+		return
+	}
+	if before == nil {
+		jsonError(w, r, "machine_not_found", http.StatusNotFound)
 		return
 	}
 
-	if machine == nil {
-		jsonError(w, "Máquina não encontrada", http.StatusNotFound)
+	if err := s.storage.UpdateMachine(machineID, update, scope); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonError(w, r, "machine_not_found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Erro ao atualizar máquina: %v", err)
+		jsonError(w, r, "update_machine_failed", http.StatusInternalServerError)
 		return
 	}
 
+	after, err := s.storage.GetMachineByID(machineID, scope)
+	if err != nil || after == nil {
+		log.Printf("Erro ao buscar máquina: %v", err)
+		jsonError(w, r, "get_machine_failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Máquina %d atualizada", machineID)
+	s.audit(r, storage.AuditEntry{Action: "machine.update", Target: fmt.Sprintf("machine:%d", machineID)}, before, after)
+
+	s.annotateMachine(after)
+	s.publishUpdated(before, after)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(machine)
+	json.NewEncoder(w).Encode(after)
+}
+
+// handleMachineDelete remove uma máquina e todo o seu histórico
+func (s *Server) handleMachineDelete(w http.ResponseWriter, r *http.Request, machineID int64) {
+	before, _ := s.storage.GetMachineByID(machineID, scopeFor(r))
+
+	if err := s.storage.DeleteMachine(machineID, scopeFor(r)); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			jsonError(w, r, "machine_not_found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Erro ao remover máquina: %v", err)
+		jsonError(w, r, "delete_machine_failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Máquina %d removida", machineID)
+	s.audit(r, storage.AuditEntry{Action: "machine.delete", Target: fmt.Sprintf("machine:%d", machineID)}, before, nil)
+	s.publishRemoved(before)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "ok",
+		"machine_id": machineID,
+	})
 }
 
 // handleStats retorna estatísticas gerais
@@ -304,10 +999,16 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := s.storage.GetStats()
+	selector, selErr := selectorFromRequest(r)
+	if selErr != nil {
+		jsonError(w, r, selErr.Code, http.StatusBadRequest, selErr.Args...)
+		return
+	}
+
+	stats, err := s.storage.GetStats(scopeFor(r), selector)
 	if err != nil {
 		log.Printf("Erro ao buscar estatísticas: %v", err)
-		jsonError(w, "Erro ao buscar estatísticas", http.StatusInternalServerError)
+		jsonError(w, r, "stats_failed", http.StatusInternalServerError)
 		return
 	}
 
@@ -324,21 +1025,51 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleDashboard serve o dashboard HTML
+// handleDashboard serve o dashboard HTML, já com o estado inicial (usuário, máquinas e visões)
+// que o JavaScript buscaria em /api/me, /api/machines e /api/views
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
 		return
 	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(dashboard.GetHTML()))
+	machines, err := s.storage.GetMachinesWithMetrics(scopeFor(r), storage.MachineFilter{})
+	if err != nil {
+		log.Printf("Erro ao buscar máquinas: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+	s.annotateMachines(machines)
+
+	views, err := s.storage.ListViews(viewOwner(r))
+	if err != nil {
+		log.Printf("Erro ao listar visões: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	s.dashboard.Render(w, r, "dashboard.html", dashboard.Page{
+		Lang: requestLanguage(r),
+		State: map[string]interface{}{
+			"me":       s.meInfo(r),
+			"machines": machines,
+			"views":    views,
+		},
+	})
+}
+
+// handleStatic serve CSS e JavaScript do dashboard (públicos, como a página de login)
+func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
+	s.dashboard.ServeStatic(w, r)
 }
 
 // handleInstallScript serve o script de instalação
 func (s *Server) handleInstallScript(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Write([]byte(dashboard.GetInstallScript()))
+	s.dashboard.ServeInstallScript(w, r)
 }
 
 // handleDownload serve os binários do agent
@@ -389,16 +1120,28 @@ func (s *Server) scheduleDailyCleanup() {
 		} else {
 			log.Printf("Limpeza programada: %d métricas removidas", deleted)
 		}
+
+		if _, err := s.storage.CleanupOldEvents(); err != nil {
+			log.Printf("Erro na limpeza de mudanças de estado: %v", err)
+		}
+
+		if _, err := s.storage.CleanupExpiredSessions(); err != nil {
+			log.Printf("Erro na limpeza de sessões: %v", err)
+		}
+
+		s.archiveAudit(time.Now())
 	}
 }
 
-// jsonError envia uma resposta de erro em JSON
-func jsonError(w http.ResponseWriter, message string, status int) {
+// jsonError envia uma resposta de erro em JSON: code é estável (chave do catálogo de
+// mensagens) e message, sua tradução no idioma do request formatada com args
+func jsonError(w http.ResponseWriter, r *http.Request, code string, status int, args ...interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]string{
 		"status":  "error",
-		"message": message,
+		"code":    code,
+		"message": i18n.T(requestLanguage(r), code, args...),
 	})
 }
 
@@ -410,6 +1153,37 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList separa uma lista por vírgulas, ignorando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvBool obtém variável de ambiente como bool com valor default
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat obtém variável de ambiente como float com valor default
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 // getEnvInt obtém variável de ambiente como int com valor default
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/dashboard"
+	"monitor-infra/internal/storage"
+)
+
+// newTestServer cria um servidor com banco temporário e a configuração padrão das flags;
+// configure ajusta a configuração antes da criação dos limitadores
+func newTestServer(t *testing.T, configure func(*Config)) *Server {
+	t.Helper()
+
+	config := &Config{
+		Token:             "token-de-teste",
+		AdminToken:        "admin-de-teste",
+		SharedTokenIngest: true,
+		SignatureWindow:   5 * time.Minute,
+		MaxBodyBytes:      1024 * 1024,
+		SessionTTL:        time.Hour,
+		RetentionDays:     90,
+		MaxClockSkew:      5 * time.Minute,
+		AnomalyHistory:    28,
+		ForecastHistory:   7,
+	}
+	if configure != nil {
+		configure(config)
+	}
+
+	store, err := storage.New(filepath.Join(t.TempDir(), "monitor.db"), config.RetentionDays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	assets, err := dashboard.New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		config:  config,
+		storage: store,
+		mux:     http.NewServeMux(),
+		nonces:  newNonceCache(config.SignatureWindow),
+		limiter: newRateLimiter(config.RateLimit, config.RateBurst),
+		events:  newEventHub(),
+
+		ipLimiter: newRateLimiter(config.IPRateLimit, config.IPRateLimit/2),
+		failures:  newFailureThrottle(),
+		anomalies: newAnomalyTracker(),
+		dashboard: assets,
+	}
+	s.registerRoutes()
+	return s
+}
+
+// serve envia um request ao servidor e retorna a resposta gravada
+func serve(s *Server, method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, r)
+	return w
+}
+
+// bearer é o header de autenticação com o token informado
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func TestValidateCollectedAt(t *testing.T) {
+	s := newTestServer(t, nil)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		collectedAt time.Time
+		code        string // vazio: aceito
+	}{
+		{"sem horário", time.Time{}, ""},
+		{"agora", now, ""},
+		{"atrasado um dia", now.AddDate(0, 0, -1), ""},
+		{"adiantado dentro da tolerância", now.Add(4 * time.Minute), ""},
+		{"no futuro", now.Add(10 * time.Minute), "in_future"},
+		{"antes da retenção", now.AddDate(0, 0, -91), "before_retention"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.validateCollectedAt(tt.collectedAt, now)
+			switch {
+			case tt.code == "" && err != nil:
+				t.Errorf("recusado com %s", err.Code)
+			case tt.code != "" && (err == nil || err.Code != tt.code):
+				t.Errorf("erro = %v, esperado %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestIsClockDrift(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	tests := []struct {
+		skew  float64
+		drift bool
+	}{
+		{0, false},
+		{299, false},
+		{-300, false},
+		{301, true},
+		{-600, true},
+	}
+
+	for _, tt := range tests {
+		if got := s.isClockDrift(tt.skew); got != tt.drift {
+			t.Errorf("isClockDrift(%v) = %v, esperado %v", tt.skew, got, tt.drift)
+		}
+	}
+}
+
+func TestHandleMetricsBackfill(t *testing.T) {
+	s := newTestServer(t, nil)
+	collected := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second).Format(time.RFC3339)
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01",
+		"ip": "10.0.0.1", "cpu_percent": 10, "collected_at": "` + collected + `"}`
+
+	tests := []struct {
+		name      string
+		duplicate string
+	}{
+		{"primeiro envio", `"duplicate":false`},
+		{"reenvio do spool", `"duplicate":true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste"))
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.duplicate) {
+				t.Errorf("resposta %s, esperado %s", w.Body, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestHandleMachineUpdate(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1", "group": "web"}`
+	if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	_, editor := login(t, s, "editor", storage.RoleEditor, []string{"web"})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		header map[string]string
+		status int
+		want   string // trecho esperado na resposta
+	}{
+		{"renomear", http.MethodPatch, "/api/machines/1", `{"display_name": " Servidor web "}`, editor, http.StatusOK, `"display_name":"Servidor web"`},
+		{"grupo vazio", http.MethodPatch, "/api/machines/1", `{"group": " "}`, editor, http.StatusBadRequest, `"field":"group"`},
+		{"notas longas", http.MethodPatch, "/api/machines/1", `{"notes": "` + strings.Repeat("x", maxNotesLength+1) + `"}`, editor, http.StatusBadRequest, `"field":"notes"`},
+		{"grupo fora do escopo", http.MethodPatch, "/api/machines/1", `{"group": "db"}`, editor, http.StatusForbidden, "group_out_of_scope"},
+		{"desativar", http.MethodPatch, "/api/machines/1", `{"archived": true}`, editor, http.StatusOK, `"archived_at"`},
+		{"máquina inexistente", http.MethodPatch, "/api/machines/99", `{"notes": "x"}`, editor, http.StatusNotFound, "machine_not_found"},
+		{"remover", http.MethodDelete, "/api/machines/1", "", editor, http.StatusOK, `"status":"ok"`},
+		{"remover de novo", http.MethodDelete, "/api/machines/1", "", editor, http.StatusNotFound, "machine_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, tt.method, tt.path, tt.body, tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta %s, esperado %s", w.Body, tt.want)
+			}
+		})
+	}
+}
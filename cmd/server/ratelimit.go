@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenBucket guarda os tokens disponíveis de uma chave e quando foram atualizados
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter aplica token bucket por chave (agent, certificado ou IP)
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	rate    float64 // tokens por segundo
+	burst   float64
+	cleanAt time.Time
+}
+
+// newRateLimiter cria o limitador com perMinute envios por minuto e rajada de burst;
+// retorna nil (sem limite) se perMinute <= 0
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+	}
+}
+
+// Allow consome um token da chave; se não houver, retorna false e quanto esperar pelo próximo
+func (l *rateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Limpeza periódica: um bucket cheio equivale a não ter bucket
+	if now.After(l.cleanAt) {
+		for k, b := range l.buckets {
+			if l.refill(b, now) >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.cleanAt = now.Add(time.Minute)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	if l.refill(b, now) < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// refill repõe os tokens acumulados desde a última atualização
+func (l *rateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+		b.updated = now
+	}
+	return b.tokens
+}
+
+// rateLimitKey identifica o remetente: credencial do agent, certificado de cliente ou,
+// com o token compartilhado (ou sem autenticação), o IP de origem
+func (s *Server) rateLimitKey(r *http.Request) string {
+	if cred := agentFromContext(r.Context()); cred != nil {
+		return fmt.Sprintf("agent:%d", cred.ID)
+	}
+	if identity := clientCertFromContext(r); identity != nil {
+		return "cert:" + identity.String()
+	}
+	return "ip:" + s.clientIP(r)
+}
+
+// rateLimitMiddleware responde 429 com Retry-After quando o remetente excede o limite de envios
+func (s *Server) rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.limitWith(s.limiter, s.rateLimitKey, next)
+}
+
+// ipRateLimitMiddleware limita os envios por IP de origem antes da autenticação, para que
+// credenciais inválidas em massa não passem pela verificação e pela auditoria sem limite
+func (s *Server) ipRateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.limitWith(s.ipLimiter, func(r *http.Request) string { return "ip:" + s.clientIP(r) }, next)
+}
+
+// limitWith aplica o limitador à chave do request (sem limitador, não limita)
+func (s *Server) limitWith(l *rateLimiter, key func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if l == nil {
+			next(w, r)
+			return
+		}
+
+		if ok, wait := l.Allow(key(r), time.Now()); !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			jsonError(w, r, "rate_limited", http.StatusTooManyRequests, seconds)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// bodyLimitMiddleware limita o tamanho do corpo do request a --max-body-kb
+func (s *Server) bodyLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > s.config.MaxBodyBytes {
+			jsonError(w, r, "payload_too_large", http.StatusRequestEntityTooLarge, s.config.MaxBodyBytes)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes)
+		next(w, r)
+	}
+}
+
+// payloadError responde um erro de leitura do corpo, distinguindo payloads acima do limite
+// e erros de decodificação que apontam um campo
+func payloadError(w http.ResponseWriter, r *http.Request, err error, code string) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		jsonError(w, r, "payload_too_large", http.StatusRequestEntityTooLarge, maxErr.Limit)
+		return
+	}
+	if fieldErr := decodeFieldError(err); fieldErr != nil {
+		validationError(w, r, fieldErr)
+		return
+	}
+	jsonError(w, r, code, http.StatusBadRequest)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(60, 2) // 1 envio por segundo, rajada de 2
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		key  string
+		at   time.Duration
+		ok   bool
+		wait time.Duration
+	}{
+		{"rajada 1", "a", 0, true, 0},
+		{"rajada 2", "a", 0, true, 0},
+		{"rajada esgotada", "a", 0, false, time.Second},
+		{"outra chave", "b", 0, true, 0},
+		{"meio token reposto", "a", 500 * time.Millisecond, false, 500 * time.Millisecond},
+		{"token reposto", "a", time.Second, true, 0},
+		{"reposição limitada à rajada", "a", time.Hour, true, 0},
+		{"segundo da rajada reposta", "a", time.Hour, true, 0},
+		{"rajada esgotada de novo", "a", time.Hour, false, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, wait := l.Allow(tt.key, start.Add(tt.at))
+			if ok != tt.ok || wait != tt.wait {
+				t.Errorf("Allow = %v, %v; esperado %v, %v", ok, wait, tt.ok, tt.wait)
+			}
+		})
+	}
+
+	if newRateLimiter(0, 10) != nil {
+		t.Error("limite 0 deveria desativar o limitador")
+	}
+}
+
+func TestIngestRateLimits(t *testing.T) {
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1"}`
+
+	tests := []struct {
+		name     string
+		ipLimit  int
+		rate     int
+		burst    int
+		token    string
+		statuses []int
+	}{
+		{"sem limites", 0, 0, 0, "token-de-teste", []int{201, 201, 201}},
+		{"limite por IP antes da autenticação", 4, 0, 0, "token-invalido", []int{401, 401, 429, 429}},
+		{"limite por IP com token válido", 4, 0, 0, "token-de-teste", []int{201, 201, 429}},
+		{"limite por remetente autenticado", 0, 60, 2, "token-de-teste", []int{201, 201, 429}},
+		{"credencial inválida não consome o limite autenticado", 0, 60, 1, "token-invalido", []int{401, 401, 401}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(c *Config) {
+				c.IPRateLimit = tt.ipLimit
+				c.RateLimit = tt.rate
+				c.RateBurst = tt.burst
+			})
+			for i, want := range tt.statuses {
+				w := serve(s, http.MethodPost, "/api/metrics", body, bearer(tt.token))
+				if w.Code != want {
+					t.Fatalf("envio %d: status = %d, esperado %d: %s", i+1, w.Code, want, w.Body)
+				}
+				if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Error("429 sem Retry-After")
+				}
+			}
+		})
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	s := newTestServer(t, func(c *Config) { c.MaxBodyBytes = 200 })
+	small := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1"}`
+	large := `{"hostname": "web-01", "notes": "` + strings.Repeat("x", 300) + `"}`
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"dentro do limite", small, http.StatusCreated},
+		{"acima do limite", large, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, "/api/metrics", tt.body, bearer("token-de-teste"))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
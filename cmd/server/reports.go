@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+const (
+	// reportDefaultDays é o período do relatório de disponibilidade sem ?from=
+	reportDefaultDays = 30
+
+	// reportMaxPeriods limita quantos intervalos (dias ou meses) um relatório pode ter
+	reportMaxPeriods = 400
+)
+
+// reportIntervals são os valores aceitos em ?interval= ("" é o período inteiro, sem divisão)
+var reportIntervals = map[string]bool{"": true, "day": true, "month": true}
+
+// uptimeSummary é a disponibilidade de uma máquina, de um grupo ou de todas em um período.
+// uptime_percent é null quando o período não tem tempo monitorado.
+type uptimeSummary struct {
+	From                   time.Time `json:"from"`
+	To                     time.Time `json:"to"`
+	UptimePercent          *float64  `json:"uptime_percent"`
+	MonitoredSeconds       int64     `json:"monitored_seconds"`
+	DowntimeSeconds        int64     `json:"downtime_seconds"`
+	LongestDowntimeSeconds int64     `json:"longest_downtime_seconds"`
+	Incidents              int       `json:"incidents"`
+}
+
+// machineUptime é a disponibilidade de uma máquina no relatório
+type machineUptime struct {
+	ID          int64  `json:"id"`
+	Hostname    string `json:"hostname"`
+	DisplayName string `json:"display_name,omitempty"`
+	Group       string `json:"group"`
+	uptimeSummary
+	Periods []uptimeSummary `json:"periods,omitempty"`
+}
+
+// groupUptime é a disponibilidade somada das máquinas de um grupo
+type groupUptime struct {
+	Group    string `json:"group"`
+	Machines int    `json:"machines"`
+	uptimeSummary
+	Periods []uptimeSummary `json:"periods,omitempty"`
+}
+
+// summarize converte um período calculado pelo storage
+func summarize(w storage.UptimeWindow) uptimeSummary {
+	summary := uptimeSummary{
+		From:                   w.From,
+		To:                     w.To,
+		MonitoredSeconds:       int64(w.Monitored.Seconds()),
+		DowntimeSeconds:        int64(w.Down().Seconds()),
+		LongestDowntimeSeconds: int64(w.LongestDown.Seconds()),
+		Incidents:              w.Incidents,
+	}
+	if percent, ok := w.Percent(); ok {
+		percent = math.Round(percent*1000) / 1000
+		summary.UptimePercent = &percent
+	}
+	return summary
+}
+
+// uptimeTotals soma os períodos de cada máquina: o total do relatório inteiro e o de cada intervalo
+type uptimeTotals struct {
+	total   storage.UptimeWindow
+	periods []storage.UptimeWindow
+}
+
+func newUptimeTotals(bounds []time.Time) *uptimeTotals {
+	t := &uptimeTotals{
+		total:   storage.UptimeWindow{From: bounds[0], To: bounds[len(bounds)-1]},
+		periods: make([]storage.UptimeWindow, len(bounds)-1),
+	}
+	for i := range t.periods {
+		t.periods[i].From, t.periods[i].To = bounds[i], bounds[i+1]
+	}
+	return t
+}
+
+func (t *uptimeTotals) add(windows []storage.UptimeWindow) {
+	for i, w := range windows {
+		t.total.Add(w)
+		t.periods[i].Add(w)
+	}
+}
+
+// summaries retorna o total e, se o relatório é dividido em intervalos, cada um deles
+func (t *uptimeTotals) summaries(interval string) (uptimeSummary, []uptimeSummary) {
+	if interval == "" {
+		return summarize(t.total), nil
+	}
+	periods := make([]uptimeSummary, len(t.periods))
+	for i, w := range t.periods {
+		periods[i] = summarize(w)
+	}
+	return summarize(t.total), periods
+}
+
+// parseReportTime aceita RFC3339 ou uma data (AAAA-MM-DD, meia-noite no fuso do servidor)
+func parseReportTime(v string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// reportBounds divide [from, to) em dias ou meses do calendário (fuso do servidor);
+// retorna nil se houver mais de reportMaxPeriods intervalos
+func reportBounds(from, to time.Time, interval string) []time.Time {
+	bounds := []time.Time{from}
+	next := from.In(time.Local)
+	for {
+		switch interval {
+		case "day":
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, time.Local)
+		case "month":
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, time.Local)
+		default:
+			next = to
+		}
+		if !next.Before(to) {
+			return append(bounds, to)
+		}
+		if len(bounds) >= reportMaxPeriods {
+			return nil
+		}
+		bounds = append(bounds, next)
+	}
+}
+
+// handleUptimeReport calcula a disponibilidade das máquinas do escopo, por máquina, por grupo e
+// no total. Parâmetros: from e to (RFC3339 ou AAAA-MM-DD; padrão: últimos 30 dias), group,
+// selector, include_archived, interval (day ou month, para dividir o período) e format (json
+// ou csv). Com format=csv ou download=true a resposta vem como anexo.
+func (s *Server) handleUptimeReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	to := time.Now().Truncate(time.Second)
+	from := to.AddDate(0, 0, -reportDefaultDays)
+	for name, dest := range map[string]*time.Time{"from": &from, "to": &to} {
+		if v := q.Get(name); v != "" {
+			t, err := parseReportTime(v)
+			if err != nil {
+				jsonError(w, r, "invalid_report_time", http.StatusBadRequest, name)
+				return
+			}
+			*dest = t
+		}
+	}
+	if !from.Before(to) {
+		jsonError(w, r, "invalid_report_range", http.StatusBadRequest)
+		return
+	}
+
+	interval := q.Get("interval")
+	if !reportIntervals[interval] {
+		jsonError(w, r, "invalid_report_interval", http.StatusBadRequest, interval)
+		return
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		jsonError(w, r, "invalid_report_format", http.StatusBadRequest, format)
+		return
+	}
+	bounds := reportBounds(from, to, interval)
+	if bounds == nil {
+		jsonError(w, r, "too_many_report_periods", http.StatusBadRequest, reportMaxPeriods)
+		return
+	}
+
+	selector, selErr := selectorFromRequest(r)
+	if selErr != nil {
+		jsonError(w, r, selErr.Code, http.StatusBadRequest, selErr.Args...)
+		return
+	}
+	scope := scopeFor(r)
+	if group := q.Get("group"); group != "" {
+		if !scope.Allows(group) {
+			jsonError(w, r, "group_out_of_scope", http.StatusForbidden)
+			return
+		}
+		scope = &storage.Scope{Groups: []string{group}}
+	}
+
+	machines, err := s.storage.GetMachinesWithMetrics(scope, storage.MachineFilter{
+		IncludeArchived: q.Get("include_archived") == "true",
+		Selector:        selector,
+	})
+	if err != nil {
+		log.Printf("Erro ao buscar máquinas: %v", err)
+		jsonError(w, r, "list_machines_failed", http.StatusInternalServerError)
+		return
+	}
+	uptime, err := s.storage.Uptime(machines, bounds)
+	if err != nil {
+		log.Printf("Erro ao calcular disponibilidade: %v", err)
+		jsonError(w, r, "uptime_report_failed", http.StatusInternalServerError)
+		return
+	}
+
+	total := newUptimeTotals(bounds)
+	groupTotals := make(map[string]*uptimeTotals)
+	groupSizes := make(map[string]int)
+	machineReports := make([]machineUptime, 0, len(machines))
+	for i := range machines {
+		m := &machines[i]
+		windows := uptime[m.ID]
+
+		totals := newUptimeTotals(bounds)
+		totals.add(windows)
+		report := machineUptime{ID: m.ID, Hostname: m.Hostname, DisplayName: m.DisplayName, Group: m.GroupName}
+		report.uptimeSummary, report.Periods = totals.summaries(interval)
+		machineReports = append(machineReports, report)
+
+		if groupTotals[m.GroupName] == nil {
+			groupTotals[m.GroupName] = newUptimeTotals(bounds)
+		}
+		groupTotals[m.GroupName].add(windows)
+		groupSizes[m.GroupName]++
+		total.add(windows)
+	}
+
+	groupReports := make([]groupUptime, 0, len(groupTotals))
+	for name, totals := range groupTotals {
+		report := groupUptime{Group: name, Machines: groupSizes[name]}
+		report.uptimeSummary, report.Periods = totals.summaries(interval)
+		groupReports = append(groupReports, report)
+	}
+	sort.Slice(groupReports, func(i, j int) bool { return groupReports[i].Group < groupReports[j].Group })
+
+	summary, periods := total.summaries(interval)
+	filename := fmt.Sprintf("uptime-%s-%s", from.Format("20060102"), to.Format("20060102"))
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		writeUptimeCSV(w, machineReports, groupReports, summary, periods)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if q.Get("download") == "true" {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+	}
+	response := map[string]interface{}{
+		"from":     from,
+		"to":       to,
+		"interval": interval,
+		"total":    summary,
+		"groups":   groupReports,
+		"machines": machineReports,
+	}
+	if periods != nil {
+		response["periods"] = periods
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeUptimeCSV escreve o relatório em CSV: uma linha por máquina, grupo e total (ou, com
+// interval, uma por intervalo de cada um)
+func writeUptimeCSV(w http.ResponseWriter, machines []machineUptime, groups []groupUptime, total uptimeSummary, periods []uptimeSummary) {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"type", "group", "machine_id", "hostname", "display_name", "from", "to", "uptime_percent",
+		"monitored_seconds", "downtime_seconds", "longest_downtime_seconds", "incidents",
+	})
+
+	write := func(kind, group, id, hostname, displayName string, summary uptimeSummary, periods []uptimeSummary) {
+		if periods == nil {
+			periods = []uptimeSummary{summary}
+		}
+		for _, p := range periods {
+			percent := ""
+			if p.UptimePercent != nil {
+				percent = strconv.FormatFloat(*p.UptimePercent, 'f', 3, 64)
+			}
+			out.Write([]string{
+				kind, csvSafe(group), id, csvSafe(hostname), csvSafe(displayName),
+				p.From.Format(time.RFC3339), p.To.Format(time.RFC3339), percent,
+				strconv.FormatInt(p.MonitoredSeconds, 10),
+				strconv.FormatInt(p.DowntimeSeconds, 10),
+				strconv.FormatInt(p.LongestDowntimeSeconds, 10),
+				strconv.Itoa(p.Incidents),
+			})
+		}
+	}
+
+	for _, m := range machines {
+		write("machine", m.Group, strconv.FormatInt(m.ID, 10), m.Hostname, m.DisplayName, m.uptimeSummary, m.Periods)
+	}
+	for _, g := range groups {
+		write("group", g.Group, "", "", "", g.uptimeSummary, g.Periods)
+	}
+	write("total", "", "", "", "", total, periods)
+	out.Flush()
+}
+
+// csvSafe impede que planilhas interpretem como fórmula um valor vindo dos agents ou da API
+// (hostname, nome, grupo): valores que começam com =, +, -, @, tab ou CR ganham um ' na frente
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestCSVSafe(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"web-01", "web-01"},
+		{"", ""},
+		{"=SUM(A1)", "'=SUM(A1)"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@cmd", "'@cmd"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+	}
+
+	for _, tt := range tests {
+		if got := csvSafe(tt.value); got != tt.want {
+			t.Errorf("csvSafe(%q) = %q, esperado %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReportBounds(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 0, 0, 0, 0, time.Local) }
+	from, to := day(1, 30).Add(6*time.Hour), day(3, 2).Add(12*time.Hour)
+
+	tests := []struct {
+		interval string
+		periods  int
+		second   time.Time // início do segundo intervalo
+	}{
+		{"", 1, to},
+		{"day", 32, day(1, 31)},
+		{"month", 3, day(2, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			bounds := reportBounds(from, to, tt.interval)
+			if len(bounds) != tt.periods+1 {
+				t.Fatalf("%d intervalos, esperado %d", len(bounds)-1, tt.periods)
+			}
+			if !bounds[0].Equal(from) || !bounds[len(bounds)-1].Equal(to) || !bounds[1].Equal(tt.second) {
+				t.Errorf("limites inesperados: %v", bounds)
+			}
+		})
+	}
+
+	if bounds := reportBounds(day(1, 1), day(1, 1).AddDate(2, 0, 0), "day"); bounds != nil {
+		t.Errorf("esperado nil acima de %d intervalos, recebido %d", reportMaxPeriods, len(bounds)-1)
+	}
+}
+
+func TestHandleUptimeReport(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1", "group": "web"}`
+	if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	_, editor := login(t, s, "editor", storage.RoleEditor, []string{"web"})
+	if w := serve(s, http.MethodPatch, "/api/machines/1", `{"display_name": "=HYPERLINK(\"x\")"}`, editor); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   string
+	}{
+		{"json", "", http.StatusOK, `"hostname":"web-01"`},
+		{"dividido por dia", "?from=2026-01-01&to=2026-01-03&interval=day", http.StatusOK, `"periods"`},
+		{"csv", "?format=csv", http.StatusOK, "display_name"},
+		{"grupo fora do escopo", "?group=db", http.StatusForbidden, "group_out_of_scope"},
+		{"intervalo desconhecido", "?interval=week", http.StatusBadRequest, "invalid_report_interval"},
+		{"formato desconhecido", "?format=xml", http.StatusBadRequest, "invalid_report_format"},
+		{"data inválida", "?from=ontem", http.StatusBadRequest, "invalid_report_time"},
+		{"período invertido", "?from=2026-02-01&to=2026-01-01", http.StatusBadRequest, "invalid_report_range"},
+		{"intervalos demais", "?from=2020-01-01&to=2026-01-01&interval=day", http.StatusBadRequest, "too_many_report_periods"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodGet, "/api/reports/uptime"+tt.query, "", editor)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta sem %q: %s", tt.want, w.Body)
+			}
+		})
+	}
+
+	w := serve(s, http.MethodGet, "/api/reports/uptime?format=csv", "", editor)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cabeçalho, máquina, grupo e total
+	if len(records) != 4 || records[1][0] != "machine" {
+		t.Fatalf("linhas inesperadas: %v", records)
+	}
+	if got := records[1][4]; got != `'=HYPERLINK("x")` {
+		t.Errorf("display_name = %q, esperado com ' na frente", got)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, ".csv") {
+		t.Errorf("Content-Disposition = %q", disposition)
+	}
+}
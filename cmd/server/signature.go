@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers da assinatura HMAC dos envios de métricas
+const (
+	headerTimestamp = "X-Monitor-Timestamp"
+	headerNonce     = "X-Monitor-Nonce"
+	headerSignature = "X-Monitor-Signature"
+)
+
+// signaturePayload monta a mensagem assinada: timestamp, nonce, método, caminho e corpo
+// (exatamente como enviado, inclusive comprimido)
+func signaturePayload(timestamp, nonce, method, path string, body []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n%s\n%s\n%s\n", timestamp, nonce, method, path)
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// nonceCache guarda os nonces vistos dentro da janela de validade para detectar replays
+type nonceCache struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	window  time.Duration
+	cleanAt time.Time
+}
+
+// newNonceCache cria o cache de nonces para a janela informada
+func newNonceCache(window time.Duration) *nonceCache {
+	return &nonceCache{seen: make(map[string]time.Time), window: window}
+}
+
+// Use registra o nonce e retorna false se ele já foi usado dentro da janela
+func (c *nonceCache) Use(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Limpeza periódica dos nonces que já saíram da janela
+	if now.After(c.cleanAt) {
+		for n, expires := range c.seen {
+			if now.After(expires) {
+				delete(c.seen, n)
+			}
+		}
+		c.cleanAt = now.Add(c.window)
+	}
+
+	if expires, ok := c.seen[nonce]; ok && now.Before(expires) {
+		return false
+	}
+
+	// Um timestamp aceito pode estar até window no passado ou no futuro
+	c.seen[nonce] = now.Add(2 * c.window)
+	return true
+}
+
+// signatureMiddleware verifica a assinatura HMAC-SHA256 do corpo quando --signing-key
+// está configurado, rejeitando timestamps fora da janela e nonces repetidos
+func (s *Server) signatureMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.SigningKey == "" {
+			next(w, r)
+			return
+		}
+
+		timestamp := r.Header.Get(headerTimestamp)
+		nonce := r.Header.Get(headerNonce)
+		signature := r.Header.Get(headerSignature)
+		if timestamp == "" || nonce == "" || signature == "" {
+			s.auditFailure(r, "auth.failure", "assinatura ausente")
+			jsonError(w, r, "signature_missing", http.StatusUnauthorized)
+			return
+		}
+
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			s.auditFailure(r, "auth.failure", "timestamp da assinatura inválido")
+			jsonError(w, r, "signature_timestamp_invalid", http.StatusUnauthorized)
+			return
+		}
+		now := time.Now()
+		if diff := now.Sub(time.Unix(unix, 0)); diff > s.config.SignatureWindow || diff < -s.config.SignatureWindow {
+			s.auditFailure(r, "auth.failure", "assinatura expirada")
+			jsonError(w, r, "signature_expired", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			payloadError(w, r, err, "read_body_failed")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		expected, err := hex.DecodeString(signature)
+		mac := hmac.New(sha256.New, []byte(s.config.SigningKey))
+		mac.Write(signaturePayload(timestamp, nonce, r.Method, r.URL.Path, body))
+		if err != nil || !hmac.Equal(mac.Sum(nil), expected) {
+			log.Printf("Assinatura inválida de %s", r.RemoteAddr)
+			s.auditFailure(r, "auth.failure", "assinatura inválida")
+			jsonError(w, r, "signature_invalid", http.StatusUnauthorized)
+			return
+		}
+
+		// O nonce só é registrado depois da assinatura válida, para não ser envenenado
+		if !s.nonces.Use(nonce, now) {
+			log.Printf("Replay detectado de %s (nonce %s)", r.RemoteAddr, nonce)
+			s.auditFailure(r, "auth.failure", "nonce repetido (replay)")
+			jsonError(w, r, "replayed_request", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
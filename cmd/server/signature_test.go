@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signedHeader assina o corpo como o agent faz e retorna os headers do envio
+func signedHeader(key, nonce string, at time.Time, path, body string) map[string]string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(signaturePayload(timestamp, nonce, http.MethodPost, path, []byte(body)))
+
+	header := bearer("token-de-teste")
+	header[headerTimestamp] = timestamp
+	header[headerNonce] = nonce
+	header[headerSignature] = hex.EncodeToString(mac.Sum(nil))
+	return header
+}
+
+func TestSignatureMiddleware(t *testing.T) {
+	const key = "chave-de-assinatura"
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1"}`
+	s := newTestServer(t, func(c *Config) { c.SigningKey = key })
+	now := time.Now()
+
+	tampered := signedHeader(key, "nonce-3", now, "/api/metrics", body)
+	badHex := signedHeader(key, "nonce-4", now, "/api/metrics", body)
+	badHex[headerSignature] = "não-é-hex"
+	badTimestamp := signedHeader(key, "nonce-5", now, "/api/metrics", body)
+	badTimestamp[headerTimestamp] = "ontem"
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		body   string
+		status int
+		code   string
+	}{
+		{"sem assinatura", bearer("token-de-teste"), body, http.StatusUnauthorized, "signature_missing"},
+		{"assinatura válida", signedHeader(key, "nonce-1", now, "/api/metrics", body), body, http.StatusCreated, ""},
+		{"replay do mesmo nonce", signedHeader(key, "nonce-1", now, "/api/metrics", body), body, http.StatusUnauthorized, "replayed_request"},
+		{"outra chave", signedHeader("outra-chave", "nonce-2", now, "/api/metrics", body), body, http.StatusUnauthorized, "signature_invalid"},
+		{"corpo alterado", tampered, body + " ", http.StatusUnauthorized, "signature_invalid"},
+		{"assinatura não hexadecimal", badHex, body, http.StatusUnauthorized, "signature_invalid"},
+		{"outro caminho", signedHeader(key, "nonce-6", now, "/api/metrics/batch", body), body, http.StatusUnauthorized, "signature_invalid"},
+		{"timestamp inválido", badTimestamp, body, http.StatusUnauthorized, "signature_timestamp_invalid"},
+		{"timestamp expirado", signedHeader(key, "nonce-7", now.Add(-10*time.Minute), "/api/metrics", body), body, http.StatusUnauthorized, "signature_expired"},
+		{"timestamp no futuro", signedHeader(key, "nonce-8", now.Add(10*time.Minute), "/api/metrics", body), body, http.StatusUnauthorized, "signature_expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, "/api/metrics", tt.body, tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.code != "" && !strings.Contains(w.Body.String(), `"`+tt.code+`"`) {
+				t.Errorf("resposta %s, esperado código %s", w.Body, tt.code)
+			}
+		})
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	c := newNonceCache(time.Minute)
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		nonce string
+		at    time.Time
+		ok    bool
+	}{
+		{"primeiro uso", "a", now, true},
+		{"repetido na janela", "a", now.Add(time.Minute), false},
+		{"outro nonce", "b", now, true},
+		{"repetido após a janela", "a", now.Add(3 * time.Minute), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Use(tt.nonce, tt.at); got != tt.ok {
+				t.Errorf("Use(%q) = %v, esperado %v", tt.nonce, got, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"monitor-infra/internal/dashboard"
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+const (
+	// statusDays é o número de dias das barras de disponibilidade da página de status
+	statusDays = 90
+
+	// statusCacheTTL é por quanto tempo os dados da página de status e dos badges são reaproveitados
+	statusCacheTTL = time.Minute
+)
+
+// Estados públicos de máquinas e grupos (página de status e badges)
+const (
+	stateUp       = "up"
+	stateDegraded = "degraded"
+	stateDown     = "down"
+)
+
+// Cores dos badges e das barras, por estado
+var stateColors = map[string]string{
+	stateUp:       "#4c1",
+	stateDegraded: "#dfb317",
+	stateDown:     "#e05d44",
+	"":            "#9f9f9f",
+}
+
+// statusSnapshot são os dados públicos: máquinas dos grupos de --status-groups e a
+// disponibilidade diária de cada uma
+type statusSnapshot struct {
+	at       time.Time
+	machines []storage.Machine
+	uptime   map[int64][]storage.UptimeWindow
+}
+
+// statusCache guarda o último statusSnapshot, já que a página é pública e o cálculo lê 90 dias de relatórios
+type statusCache struct {
+	mu   sync.Mutex
+	snap *statusSnapshot
+}
+
+// statusSnapshot retorna os dados públicos, recalculados no máximo a cada statusCacheTTL
+func (s *Server) statusSnapshot() (*statusSnapshot, error) {
+	s.status.mu.Lock()
+	defer s.status.mu.Unlock()
+
+	if snap := s.status.snap; snap != nil && time.Since(snap.at) < statusCacheTTL {
+		return snap, nil
+	}
+
+	machines, err := s.storage.GetMachinesWithMetrics(&storage.Scope{Groups: s.config.StatusGroups}, storage.MachineFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Um período por dia (horário local), do mais antigo até hoje
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	bounds := make([]time.Time, statusDays+1)
+	for i := range bounds {
+		bounds[i] = today.AddDate(0, 0, i-statusDays+1)
+	}
+	uptime, err := s.storage.Uptime(machines, bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	s.status.snap = &statusSnapshot{at: now, machines: machines, uptime: uptime}
+	return s.status.snap, nil
+}
+
+// machineState converte o estado da máquina: offline é down; atenção ou crítico, degraded
+func machineState(m *storage.Machine) string {
+	switch m.Status {
+	case storage.StatusOffline:
+		return stateDown
+	case storage.StatusWarning, storage.StatusCritical:
+		return stateDegraded
+	}
+	return stateUp
+}
+
+// worstState combina estados: down só se todos estiverem down; degraded se algum não estiver up
+func worstState(states []string) string {
+	if len(states) == 0 {
+		return ""
+	}
+	down, up := 0, 0
+	for _, state := range states {
+		switch state {
+		case stateDown:
+			down++
+		case stateUp:
+			up++
+		}
+	}
+	switch {
+	case down == len(states):
+		return stateDown
+	case up == len(states):
+		return stateUp
+	}
+	return stateDegraded
+}
+
+// uptimeState classifica um percentual de disponibilidade para as cores
+func uptimeState(percent float64) string {
+	switch {
+	case percent >= 99.9:
+		return stateUp
+	case percent >= 95:
+		return stateDegraded
+	}
+	return stateDown
+}
+
+// statusPage são os dados da página de status (templates/status.html)
+type statusPage struct {
+	Title   string
+	Overall string
+	Updated string
+	Groups  []statusGroup
+}
+
+// statusGroup é um grupo da página de status
+type statusGroup struct {
+	Name     string
+	State    string
+	Machines int
+	Up       int
+	Uptime   string // percentual dos últimos statusDays dias ("" sem dados)
+	Days     []statusDay
+}
+
+// statusDay é uma barra de disponibilidade diária
+type statusDay struct {
+	Title string
+	State string // "" sem dados
+}
+
+// handleStatusPage serve a página de status pública com os grupos de --status-groups
+func (s *Server) handleStatusPage(w http.ResponseWriter, r *http.Request) {
+	if len(s.config.StatusGroups) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	snap, err := s.statusSnapshot()
+	if err != nil {
+		log.Printf("Erro ao montar página de status: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	lang := requestLanguage(r)
+	page := statusPage{
+		Title:   s.config.StatusTitle,
+		Updated: snap.at.Format("2006-01-02 15:04"),
+	}
+	var groupStates []string
+	for _, name := range s.config.StatusGroups {
+		group := statusGroup{Name: name, Days: make([]statusDay, statusDays)}
+		var states []string
+		var total storage.UptimeWindow
+		for i := range snap.machines {
+			m := &snap.machines[i]
+			if m.GroupName != name {
+				continue
+			}
+			states = append(states, machineState(m))
+			if states[len(states)-1] == stateUp {
+				group.Up++
+			}
+			for _, day := range snap.uptime[m.ID] {
+				total.Add(day)
+			}
+		}
+		// Grupos sem máquinas não aparecem
+		if len(states) == 0 {
+			continue
+		}
+		group.Machines = len(states)
+		group.State = worstState(states)
+		groupStates = append(groupStates, group.State)
+		if percent, ok := total.Percent(); ok {
+			group.Uptime = fmt.Sprintf("%.2f", percent)
+		}
+
+		for j := range group.Days {
+			var day storage.UptimeWindow
+			for i := range snap.machines {
+				if m := &snap.machines[i]; m.GroupName == name {
+					day.From = snap.uptime[m.ID][j].From
+					day.Add(snap.uptime[m.ID][j])
+				}
+			}
+			date := day.From.Format("2006-01-02")
+			group.Days[j].Title = date + ": " + i18n.T(lang, "ui.status_page.no_data")
+			if percent, ok := day.Percent(); ok {
+				group.Days[j].State = uptimeState(percent)
+				group.Days[j].Title = fmt.Sprintf("%s: %.2f%%", date, percent)
+			}
+		}
+		page.Groups = append(page.Groups, group)
+	}
+	// Na página, um grupo fora do ar já deixa o resumo como indisponível
+	page.Overall = worstState(groupStates)
+	if slices.Contains(groupStates, stateDown) {
+		page.Overall = stateDown
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	s.dashboard.Render(w, r, "status.html", dashboard.Page{Lang: lang, State: page})
+}
+
+// badgeMetrics são os valores aceitos em ?metric= nos badges ("" é o estado)
+var badgeMetrics = map[string]bool{"": true, "cpu": true, "memory": true, "disk": true, "uptime": true}
+
+// handleBadge serve /badge/{grupo-ou-máquina}.svg com o estado ou, com ?metric=cpu, memory,
+// disk ou uptime, o valor da métrica (de um grupo, o maior entre as máquinas online).
+// Apenas grupos de --status-groups e suas máquinas; ?label= troca o texto da esquerda.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/badge/"), ".svg")
+	if len(s.config.StatusGroups) == 0 || !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	metric := r.URL.Query().Get("metric")
+	if !badgeMetrics[metric] {
+		badgeError(w, r, name, "invalid_badge_metric", metric)
+		return
+	}
+
+	snap, err := s.statusSnapshot()
+	if err != nil {
+		log.Printf("Erro ao montar badge: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	// O nome é um grupo público ou, se não for, o hostname (ou nome de exibição) de uma máquina
+	var machines []*storage.Machine
+	for i := range snap.machines {
+		if snap.machines[i].GroupName == name {
+			machines = append(machines, &snap.machines[i])
+		}
+	}
+	if len(machines) == 0 {
+		for i := range snap.machines {
+			if m := &snap.machines[i]; m.Hostname == name || m.DisplayName == name {
+				machines = append(machines, m)
+				break
+			}
+		}
+	}
+	if len(machines) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	lang := requestLanguage(r)
+	label := name
+	if metric != "" {
+		label += " " + metric
+	}
+	if custom := r.URL.Query().Get("label"); custom != "" {
+		label = custom
+	}
+
+	var value, state string
+	switch metric {
+	case "":
+		states := make([]string, len(machines))
+		for i, m := range machines {
+			states[i] = machineState(m)
+		}
+		state = worstState(states)
+		value = i18n.T(lang, "ui.status_page.state."+state)
+
+	case "uptime":
+		var total storage.UptimeWindow
+		for _, m := range machines {
+			for _, day := range snap.uptime[m.ID] {
+				total.Add(day)
+			}
+		}
+		value = i18n.T(lang, "ui.status_page.no_data")
+		if percent, ok := total.Percent(); ok {
+			value, state = fmt.Sprintf("%.2f%%", percent), uptimeState(percent)
+		}
+
+	default:
+		value = i18n.T(lang, "ui.status_page.state.down")
+		var worst *storage.Machine
+		for _, m := range machines {
+			if m.IsOnline && m.Metrics != nil && (worst == nil || metricValue(m, metric) > metricValue(worst, metric)) {
+				worst = m
+			}
+		}
+		if worst != nil {
+			percent := metricValue(worst, metric)
+			limit := worst.Thresholds[metric]
+			value, state = fmt.Sprintf("%.0f%%", percent), stateUp
+			switch {
+			case percent >= limit.Critical:
+				state = stateDown
+			case percent >= limit.Warning:
+				state = stateDegraded
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Header().Set("Vary", "Accept-Language")
+	w.Write(renderBadge(label, value, stateColors[state]))
+}
+
+// badgeError responde 400 com um badge de erro, para que a imagem embutida mostre o problema
+// em vez de aparecer quebrada; o valor é a mensagem do código no idioma do request
+func badgeError(w http.ResponseWriter, r *http.Request, label, code string, args ...interface{}) {
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Vary", "Accept-Language")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(renderBadge(label, i18n.T(requestLanguage(r), code, args...), stateColors[stateDown]))
+}
+
+// metricValue retorna o percentual atual de uma métrica da máquina
+func metricValue(m *storage.Machine, metric string) float64 {
+	switch metric {
+	case storage.MetricCPU:
+		return m.Metrics.CPUPercent
+	case storage.MetricMemory:
+		return m.Metrics.MemoryPercent
+	}
+	return m.Metrics.DiskPercent
+}
+
+// renderBadge desenha um badge no estilo do shields.io: rótulo em cinza, valor na cor do estado
+func renderBadge(label, value, color string) []byte {
+	// Largura aproximada do texto em Verdana 11px
+	labelWidth := utf8.RuneCountInString(label)*7 + 10
+	valueWidth := utf8.RuneCountInString(value)*7 + 10
+	width := labelWidth + valueWidth
+	label, value = html.EscapeString(label), html.EscapeString(value)
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">
+<title>%[2]s: %[3]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[4]d" height="20" fill="#555"/><rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[2]s</text><text x="%[8]d" y="14">%[3]s</text>
+</g>
+</svg>
+`, width, label, value, labelWidth, valueWidth, color, labelWidth/2, labelWidth+valueWidth/2))
+}
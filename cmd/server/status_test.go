@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestMachineState(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{storage.StatusOffline, stateDown},
+		{storage.StatusWarning, stateDegraded},
+		{storage.StatusCritical, stateDegraded},
+		{storage.StatusOnline, stateUp},
+	}
+
+	for _, tt := range tests {
+		if got := machineState(&storage.Machine{Status: tt.status}); got != tt.want {
+			t.Errorf("machineState(%q) = %q, esperado %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestWorstState(t *testing.T) {
+	tests := []struct {
+		states []string
+		want   string
+	}{
+		{nil, ""},
+		{[]string{stateUp, stateUp}, stateUp},
+		{[]string{stateUp, stateDegraded}, stateDegraded},
+		{[]string{stateUp, stateDown}, stateDegraded},
+		{[]string{stateDown, stateDown}, stateDown},
+	}
+
+	for _, tt := range tests {
+		if got := worstState(tt.states); got != tt.want {
+			t.Errorf("worstState(%v) = %q, esperado %q", tt.states, got, tt.want)
+		}
+	}
+}
+
+func TestUptimeState(t *testing.T) {
+	tests := []struct {
+		percent float64
+		want    string
+	}{
+		{100, stateUp},
+		{99.9, stateUp},
+		{99.5, stateDegraded},
+		{95, stateDegraded},
+		{94.9, stateDown},
+	}
+
+	for _, tt := range tests {
+		if got := uptimeState(tt.percent); got != tt.want {
+			t.Errorf("uptimeState(%v) = %q, esperado %q", tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBadgeEscapes(t *testing.T) {
+	svg := string(renderBadge(`<script>"x"`, "100%", stateColors[stateUp]))
+	if strings.Contains(svg, "<script>") || !strings.Contains(svg, "&lt;script&gt;") {
+		t.Errorf("rótulo não escapado: %s", svg)
+	}
+}
+
+func TestStatusPageAndBadges(t *testing.T) {
+	s := newTestServer(t, func(c *Config) {
+		c.StatusGroups = []string{"web"}
+		c.StatusTitle = "Status"
+	})
+	machines := []struct {
+		hostname, group string
+		disk            int
+	}{{"web-01", "web", 50}, {"web-02", "web", 90}, {"db-01", "db", 30}}
+	for i, m := range machines {
+		body := fmt.Sprintf(`{"machine_uuid": "8a3c9f1e-0000-4000-8000-00000000000%d", "hostname": %q, "ip": "10.0.0.1", "group": %q, "disk_percent": %d}`,
+			i+1, m.hostname, m.group, m.disk)
+		if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		want    string
+		without string
+	}{
+		{"página de status", "/status", http.StatusOK, "web", "db-01"},
+		{"badge do grupo", "/badge/web.svg", http.StatusOK, stateColors[stateDegraded], ""},
+		{"badge da máquina", "/badge/web-01.svg", http.StatusOK, stateColors[stateUp], ""},
+		{"badge de métrica do grupo", "/badge/web.svg?metric=disk", http.StatusOK, "90%", ""},
+		{"rótulo personalizado", "/badge/web.svg?label=site", http.StatusOK, ">site<", ""},
+		{"grupo privado", "/badge/db.svg", http.StatusNotFound, "", ""},
+		{"máquina de grupo privado", "/badge/db-01.svg", http.StatusNotFound, "", ""},
+		{"métrica desconhecida", "/badge/web.svg?metric=swap", http.StatusBadRequest, "cpu, memory, disk ou uptime (recebido &#34;swap&#34;)", ""},
+		{"sem extensão", "/badge/web", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodGet, tt.path, "", nil)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta sem %q: %s", tt.want, w.Body)
+			}
+			if tt.without != "" && strings.Contains(w.Body.String(), tt.without) {
+				t.Errorf("resposta expõe %q", tt.without)
+			}
+		})
+	}
+}
+
+func TestStatusPageDisabled(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, path := range []string{"/status", "/badge/web.svg"} {
+		if w := serve(s, http.MethodGet, path, "", nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, esperado 404", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"monitor-infra/internal/storage"
+)
+
+// handleThresholds lista (GET) e salva (POST) os limites de atenção e crítico.
+// Corpo do POST: {"metric": "disk", "warning": 80, "critical": 90} com "group": "web" ou
+// "machine_id": 3 (sem nenhum dos dois, altera o limite padrão)
+func (s *Server) handleThresholds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.storage.ListThresholds()
+		if err != nil {
+			log.Printf("Erro ao listar limites: %v", err)
+			jsonError(w, r, "list_thresholds_failed", http.StatusInternalServerError)
+			return
+		}
+
+		// Limites de grupos e máquinas fora do escopo não aparecem
+		scope := scopeFor(r)
+		visible := storage.ThresholdRules{}
+		for _, rule := range rules {
+			if thresholdAllowed(scope, &rule) {
+				visible = append(visible, rule)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"defaults":   storage.DefaultThresholds(),
+			"thresholds": visible,
+		})
+
+	case http.MethodPost:
+		s.editorMiddleware(s.handleThresholdSave)(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// thresholdAllowed indica se o limite está no escopo: os padrão exigem acesso a todos os grupos
+func thresholdAllowed(scope *storage.Scope, rule *storage.ThresholdRule) bool {
+	switch rule.Scope {
+	case storage.ThresholdGroup:
+		return scope.Allows(rule.GroupName)
+	case storage.ThresholdMachine:
+		return scope.Allows(rule.MachineGroup)
+	}
+	return scope == nil
+}
+
+// handleThresholdSave cria ou substitui o limite de uma métrica no escopo informado
+func (s *Server) handleThresholdSave(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Metric    string   `json:"metric"`
+		GroupName string   `json:"group"`
+		MachineID *int64   `json:"machine_id"`
+		Warning   *float64 `json:"warning"`
+		Critical  *float64 `json:"critical"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, r, "invalid_json", http.StatusBadRequest)
+		return
+	}
+
+	var errs validationErrors
+	if !slices.Contains(storage.ThresholdMetrics, body.Metric) {
+		errs.add("metric", "invalid_metric", body.Metric)
+	}
+	body.GroupName = strings.TrimSpace(body.GroupName)
+	errs.checkText("group", body.GroupName, maxGroupLength)
+	if body.GroupName != "" && body.MachineID != nil {
+		errs.add("group", "group_and_machine")
+	}
+	if body.Warning == nil {
+		errs.add("warning", "required")
+	} else {
+		errs.checkPercent("warning", *body.Warning)
+	}
+	if body.Critical == nil {
+		errs.add("critical", "required")
+	} else {
+		errs.checkPercent("critical", *body.Critical)
+	}
+	if body.Warning != nil && body.Critical != nil && *body.Warning > *body.Critical {
+		errs.add("warning", "above_critical")
+	}
+	if len(errs) > 0 {
+		validationError(w, r, errs)
+		return
+	}
+
+	rule := storage.ThresholdRule{
+		Metric:    body.Metric,
+		GroupName: body.GroupName,
+		MachineID: body.MachineID,
+		Warning:   *body.Warning,
+		Critical:  *body.Critical,
+		Scope:     storage.ThresholdDefault,
+	}
+	scope := scopeFor(r)
+	switch {
+	case rule.MachineID != nil:
+		rule.Scope = storage.ThresholdMachine
+		m, err := s.storage.GetMachineByID(*rule.MachineID, scope)
+		if err != nil {
+			log.Printf("Erro ao buscar máquina: %v", err)
+			jsonError(w, r, "get_machine_failed", http.StatusInternalServerError)
+			return
+		}
+		if m == nil {
+			jsonError(w, r, "machine_not_found", http.StatusNotFound)
+			return
+		}
+		rule.MachineGroup = m.GroupName
+	case rule.GroupName != "":
+		rule.Scope = storage.ThresholdGroup
+	}
+	if !thresholdAllowed(scope, &rule) {
+		jsonError(w, r, "threshold_out_of_scope", http.StatusForbidden)
+		return
+	}
+
+	before := s.currentThreshold(rule)
+	saved, err := s.storage.SaveThreshold(rule)
+	if err != nil || saved == nil {
+		log.Printf("Erro ao salvar limite: %v", err)
+		jsonError(w, r, "save_threshold_failed", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Limite %s (%s) atualizado: %.0f%%/%.0f%%", saved.Metric, thresholdTarget(saved), saved.Warning, saved.Critical)
+	s.audit(r, storage.AuditEntry{Action: "threshold.update", Target: thresholdTarget(saved)}, before, saved)
+	s.checkStatuses(true)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// currentThreshold retorna o limite já configurado para a métrica no mesmo escopo (para a auditoria)
+func (s *Server) currentThreshold(rule storage.ThresholdRule) *storage.ThresholdRule {
+	rules, err := s.storage.ListThresholds()
+	if err != nil {
+		return nil
+	}
+	for _, r := range rules {
+		if thresholdTarget(&r) == thresholdTarget(&rule) {
+			return &r
+		}
+	}
+	return nil
+}
+
+// thresholdTarget identifica o limite no log de auditoria (ex.: "threshold:group:web:disk")
+func thresholdTarget(rule *storage.ThresholdRule) string {
+	switch rule.Scope {
+	case storage.ThresholdGroup:
+		return "threshold:group:" + rule.GroupName + ":" + rule.Metric
+	case storage.ThresholdMachine:
+		return fmt.Sprintf("threshold:machine:%d:%s", *rule.MachineID, rule.Metric)
+	}
+	return "threshold:default:" + rule.Metric
+}
+
+// handleThresholdDetail remove (DELETE) um limite; a métrica volta a seguir o escopo mais geral
+func (s *Server) handleThresholdDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/thresholds/"), 10, 64)
+	if err != nil {
+		jsonError(w, r, "invalid_id", http.StatusBadRequest)
+		return
+	}
+
+	s.editorMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		before, err := s.storage.GetThreshold(id)
+		if err != nil {
+			log.Printf("Erro ao buscar limite: %v", err)
+			jsonError(w, r, "delete_threshold_failed", http.StatusInternalServerError)
+			return
+		}
+		// Fora do escopo é tratado como inexistente
+		if before == nil || !thresholdAllowed(scopeFor(r), before) {
+			jsonError(w, r, "threshold_not_found", http.StatusNotFound)
+			return
+		}
+
+		if err := s.storage.DeleteThreshold(id); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				jsonError(w, r, "threshold_not_found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Erro ao remover limite: %v", err)
+			jsonError(w, r, "delete_threshold_failed", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("Limite %s (%s) removido", before.Metric, thresholdTarget(before))
+		s.audit(r, storage.AuditEntry{Action: "threshold.delete", Target: thresholdTarget(before)}, before, nil)
+		s.checkStatuses(true)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+			"id":     id,
+		})
+	})(w, r)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestHandleThresholdSave(t *testing.T) {
+	s := newTestServer(t, nil)
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "db-01", "ip": "10.0.0.1", "group": "db"}`
+	if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	_, editor := login(t, s, "editor", storage.RoleEditor, []string{"web"})
+	admin := bearer("admin-de-teste")
+
+	tests := []struct {
+		name   string
+		body   string
+		header map[string]string
+		status int
+		want   string
+	}{
+		{"limite padrão", `{"metric": "disk", "warning": 80, "critical": 90}`, admin, http.StatusCreated, `"scope":"default"`},
+		{"limite do grupo", `{"metric": "disk", "group": "db", "warning": 70, "critical": 80}`, admin, http.StatusCreated, `"scope":"group"`},
+		{"limite da máquina", `{"metric": "cpu", "machine_id": 1, "warning": 60, "critical": 70}`, admin, http.StatusCreated, `"scope":"machine"`},
+		{"métrica desconhecida", `{"metric": "swap", "warning": 80, "critical": 90}`, admin, http.StatusBadRequest, `"field":"metric"`},
+		{"atenção acima do crítico", `{"metric": "disk", "warning": 95, "critical": 90}`, admin, http.StatusBadRequest, "above_critical"},
+		{"sem crítico", `{"metric": "disk", "warning": 80}`, admin, http.StatusBadRequest, `"field":"critical"`},
+		{"grupo e máquina juntos", `{"metric": "disk", "group": "db", "machine_id": 1, "warning": 80, "critical": 90}`, admin, http.StatusBadRequest, "group_and_machine"},
+		{"máquina inexistente", `{"metric": "disk", "machine_id": 99, "warning": 80, "critical": 90}`, admin, http.StatusNotFound, "machine_not_found"},
+		{"editor altera o próprio grupo", `{"metric": "disk", "group": "web", "warning": 70, "critical": 80}`, editor, http.StatusCreated, `"group":"web"`},
+		{"editor não altera outro grupo", `{"metric": "disk", "group": "db", "warning": 70, "critical": 80}`, editor, http.StatusForbidden, "threshold_out_of_scope"},
+		{"editor não altera o padrão", `{"metric": "disk", "warning": 70, "critical": 80}`, editor, http.StatusForbidden, "threshold_out_of_scope"},
+		{"editor não vê máquina de outro grupo", `{"metric": "disk", "machine_id": 1, "warning": 70, "critical": 80}`, editor, http.StatusNotFound, "machine_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, "/api/thresholds", tt.body, tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("resposta %s, esperado %s", w.Body, tt.want)
+			}
+		})
+	}
+
+	// O leitor do grupo web vê apenas o limite do próprio grupo
+	_, viewer := login(t, s, "leitor", storage.RoleViewer, []string{"web"})
+	w := serve(s, http.MethodGet, "/api/thresholds", "", viewer)
+	if strings.Count(w.Body.String(), `"scope"`) != 1 {
+		t.Errorf("limites visíveis ao leitor: %s", w.Body)
+	}
+}
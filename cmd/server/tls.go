@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// clientCertKey guarda no contexto a identidade do certificado de cliente verificado
+const clientCertKey contextKey = "client_cert"
+
+// certIdentity é a máquina que um certificado de cliente representa. O UUID vem de um
+// URI SAN "urn:uuid:<uuid>" ou do CN; se o CN não for um UUID, ele é tratado como hostname.
+type certIdentity struct {
+	UUID        string
+	Hostname    string
+	Fingerprint string
+}
+
+// String descreve a identidade para logs
+func (c *certIdentity) String() string {
+	if c.UUID != "" {
+		return "uuid " + c.UUID
+	}
+	return "hostname " + c.Hostname
+}
+
+// newTLSConfig monta a configuração TLS do servidor. Com clientCA, certificados de cliente
+// apresentados são verificados; a exigência fica a cargo do authMiddleware, para que o
+// dashboard continue acessível por navegadores sem certificado.
+func newTLSConfig(clientCA string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if clientCA == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler CA de clientes: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("nenhum certificado válido em %s", clientCA)
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	return cfg, nil
+}
+
+// verifiedClientCert retorna a identidade do certificado de cliente verificado (nil se não houver)
+func verifiedClientCert(r *http.Request) *certIdentity {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	sum := sha256.Sum256(cert.Raw)
+	identity := &certIdentity{Fingerprint: hex.EncodeToString(sum[:])}
+
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "urn" && strings.HasPrefix(strings.ToLower(uri.Opaque), "uuid:") {
+			if id := uri.Opaque[len("uuid:"):]; isUUID(id) {
+				identity.UUID = strings.ToLower(id)
+				return identity
+			}
+		}
+	}
+
+	cn := cert.Subject.CommonName
+	if isUUID(cn) {
+		identity.UUID = strings.ToLower(cn)
+	} else {
+		identity.Hostname = cn
+	}
+	return identity
+}
+
+// clientCertFromContext retorna a identidade do certificado usado no request (nil se não houver)
+func clientCertFromContext(r *http.Request) *certIdentity {
+	identity, _ := r.Context().Value(clientCertKey).(*certIdentity)
+	return identity
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withClientCert simula um request TLS com o certificado de cliente já verificado
+func withClientCert(r *http.Request, cert *x509.Certificate) *http.Request {
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+	return r
+}
+
+func TestVerifiedClientCert(t *testing.T) {
+	const id = "8a3c9f1e-0000-4000-8000-000000000001"
+	uuidURI, _ := url.Parse("urn:uuid:" + strings.ToUpper(id))
+	otherURI, _ := url.Parse("https://example.com/agent")
+
+	tests := []struct {
+		name     string
+		cert     *x509.Certificate
+		verified bool
+		uuid     string
+		hostname string
+	}{
+		{"URI SAN com UUID", &x509.Certificate{URIs: []*url.URL{uuidURI}, Subject: pkix.Name{CommonName: "web-01"}}, true, id, ""},
+		{"CN com UUID", &x509.Certificate{Subject: pkix.Name{CommonName: id}}, true, id, ""},
+		{"CN com hostname", &x509.Certificate{URIs: []*url.URL{otherURI}, Subject: pkix.Name{CommonName: "web-01"}}, true, "", "web-01"},
+		{"certificado não verificado", &x509.Certificate{Subject: pkix.Name{CommonName: id}}, false, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/metrics", nil)
+			if tt.verified {
+				withClientCert(r, tt.cert)
+			} else {
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{tt.cert}}
+			}
+
+			identity := verifiedClientCert(r)
+			if !tt.verified {
+				if identity != nil {
+					t.Errorf("identidade %v sem cadeia verificada", identity)
+				}
+				return
+			}
+			if identity == nil {
+				t.Fatal("identidade ausente")
+			}
+			if identity.UUID != tt.uuid || identity.Hostname != tt.hostname {
+				t.Errorf("identidade = %+v, esperado uuid %q hostname %q", identity, tt.uuid, tt.hostname)
+			}
+		})
+	}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-000000000001", "hostname": "web-01", "ip": "10.0.0.1"}`
+
+	tests := []struct {
+		name    string
+		cn      string // vazio: sem certificado
+		require bool   // --require-client-cert
+		token   string
+		status  int
+	}{
+		{"certificado da máquina", "8a3c9f1e-0000-4000-8000-000000000001", true, "", http.StatusCreated},
+		{"certificado de outra máquina", "8a3c9f1e-0000-4000-8000-000000000002", true, "", http.StatusForbidden},
+		{"certificado por hostname", "web-01", true, "", http.StatusCreated},
+		{"hostname diferente", "web-02", true, "", http.StatusForbidden},
+		{"sem certificado quando exigido", "", true, "token-de-teste", http.StatusUnauthorized},
+		{"sem certificado com token", "", false, "token-de-teste", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(c *Config) { c.RequireClientCert = tt.require })
+			r := httptest.NewRequest(http.MethodPost, "/api/metrics", strings.NewReader(body))
+			if tt.cn != "" {
+				withClientCert(r, &x509.Certificate{Subject: pkix.Name{CommonName: tt.cn}})
+			}
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			s.mux.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestClientCertHostnameUUID(t *testing.T) {
+	s := newTestServer(t, nil)
+	for uuid, hostname := range map[string]string{"1": "web-01", "2": "db-01"} {
+		body := `{"machine_uuid": "8a3c9f1e-0000-4000-8000-00000000000` + uuid + `", "hostname": "` + hostname + `", "ip": "10.0.0.1"}`
+		if w := serve(s, http.MethodPost, "/api/metrics", body, bearer("token-de-teste")); w.Code != http.StatusCreated {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		cn     string
+		uuid   string
+		status int
+	}{
+		{"uuid da máquina do hostname", "web-01", "8a3c9f1e-0000-4000-8000-000000000001", http.StatusCreated},
+		{"uuid de outra máquina", "web-01", "8a3c9f1e-0000-4000-8000-000000000002", http.StatusForbidden},
+		{"uuid novo em hostname já ligado", "web-01", "8a3c9f1e-0000-4000-8000-000000000003", http.StatusForbidden},
+		{"máquina nova", "api-01", "8a3c9f1e-0000-4000-8000-000000000003", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"machine_uuid": "` + tt.uuid + `", "hostname": "` + tt.cn + `", "ip": "10.0.0.1"}`
+			r := httptest.NewRequest(http.MethodPost, "/api/metrics", strings.NewReader(body))
+			withClientCert(r, &x509.Certificate{Subject: pkix.Name{CommonName: tt.cn}})
+			w := httptest.NewRecorder()
+			s.mux.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	os.WriteFile(caPath, selfSignedPEM(t), 0600)
+	os.WriteFile(invalidPath, []byte("não é um certificado"), 0600)
+
+	tests := []struct {
+		name       string
+		clientCA   string
+		fails      bool
+		clientAuth tls.ClientAuthType
+	}{
+		{"sem CA de clientes", "", false, tls.NoClientCert},
+		{"CA válida", caPath, false, tls.VerifyClientCertIfGiven},
+		{"arquivo inexistente", filepath.Join(dir, "ausente.pem"), true, 0},
+		{"arquivo sem certificados", invalidPath, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := newTLSConfig(tt.clientCA)
+			if tt.fails {
+				if err == nil {
+					t.Error("esperado erro")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.ClientAuth != tt.clientAuth {
+				t.Errorf("ClientAuth = %v, esperado %v", cfg.ClientAuth, tt.clientAuth)
+			}
+		})
+	}
+}
+
+// selfSignedPEM gera um certificado autoassinado em PEM
+func selfSignedPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "monitor-infra CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
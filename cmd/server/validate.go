@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"monitor-infra/internal/i18n"
+	"monitor-infra/internal/storage"
+)
+
+// Limites dos campos de texto e das contagens do payload
+const (
+	maxHostnameLength = 253
+	maxGroupLength    = 64
+	maxContainers     = 10000
+	maxNotesLength    = 2000
+)
+
+// swarmRoles são os papéis de Swarm reportados pelo collector (vazio em agents antigos)
+var swarmRoles = map[string]bool{"": true, "none": true, "manager": true, "worker": true}
+
+// fieldError descreve um campo inválido do payload; Code é estável e Message, sua tradução
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	args []interface{}
+}
+
+// validationErrors reúne todos os campos inválidos de um payload
+type validationErrors []fieldError
+
+func (e validationErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// add registra um campo inválido com o código do erro e os argumentos da mensagem
+func (e *validationErrors) add(field, code string, args ...interface{}) {
+	*e = append(*e, fieldError{Field: field, Code: code, Message: i18n.T(i18n.Default, code, args...), args: args})
+}
+
+// translate retorna os erros com as mensagens no idioma informado
+func (e validationErrors) translate(lang string) validationErrors {
+	out := make(validationErrors, len(e))
+	for i, fe := range e {
+		fe.Message = i18n.T(lang, fe.Code, fe.args...)
+		out[i] = fe
+	}
+	return out
+}
+
+// checkPercent verifica se um percentual é finito e está entre 0 e 100
+func (e *validationErrors) checkPercent(field string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		e.add(field, "not_numeric")
+		return
+	}
+	if value < 0 || value > 100 {
+		e.add(field, "percent_range", value)
+	}
+}
+
+// checkCount verifica uma contagem de containers
+func (e *validationErrors) checkCount(field string, value int) {
+	if value < 0 || value > maxContainers {
+		e.add(field, "count_range", maxContainers, value)
+	}
+}
+
+// checkText verifica o tamanho de um campo de texto e a ausência de caracteres de controle
+func (e *validationErrors) checkText(field, value string, maxLength int) {
+	if len(value) > maxLength {
+		e.add(field, "too_long", maxLength)
+		return
+	}
+	if strings.ContainsFunc(value, unicode.IsControl) {
+		e.add(field, "control_chars")
+	}
+}
+
+// checkLabels verifica a quantidade, as chaves e os valores de labels
+func (e *validationErrors) checkLabels(field string, labels map[string]string) {
+	if len(labels) > storage.MaxLabels {
+		e.add(field, "too_many_labels", storage.MaxLabels)
+		return
+	}
+	for key, value := range labels {
+		e.checkLabel(field, key, &value)
+	}
+}
+
+// checkLabel verifica a chave e, se informado, o valor de um label
+func (e *validationErrors) checkLabel(field, key string, value *string) {
+	if !storage.ValidLabelKey(key) {
+		e.add(field+"."+key, "invalid_label_key", storage.MaxLabelKeyLength)
+		return
+	}
+	if value != nil {
+		e.checkText(field+"."+key, *value, storage.MaxLabelValueLength)
+	}
+}
+
+// validatePayload verifica os campos de um payload recebido do agent. Retorna
+// validationErrors com todos os campos inválidos, não apenas o primeiro.
+func (s *Server) validatePayload(payload *storage.MetricPayload, receivedAt time.Time) error {
+	var errs validationErrors
+
+	// Identificação da máquina
+	if strings.TrimSpace(payload.Hostname) == "" {
+		errs.add("hostname", "required")
+	} else {
+		errs.checkText("hostname", payload.Hostname, maxHostnameLength)
+	}
+	if payload.MachineUUID != "" && !isUUID(payload.MachineUUID) {
+		errs.add("machine_uuid", "invalid_uuid")
+	}
+	if payload.IP != "" && net.ParseIP(payload.IP) == nil {
+		errs.add("ip", "invalid_ip")
+	}
+	errs.checkText("group", payload.GroupName, maxGroupLength)
+	if !swarmRoles[payload.SwarmRole] {
+		errs.add("swarm_role", "invalid_swarm_role", payload.SwarmRole)
+	}
+
+	errs.checkLabels("labels", payload.Labels)
+
+	// Métricas
+	errs.checkPercent("cpu_percent", payload.CPUPercent)
+	errs.checkPercent("memory_percent", payload.MemoryPercent)
+	errs.checkPercent("disk_percent", payload.DiskPercent)
+	errs.checkCount("docker_running", payload.DockerRunning)
+	errs.checkCount("docker_stopped", payload.DockerStopped)
+
+	// Agregados da amostragem (ignorados pelo storage quando sample_count é 0)
+	if payload.SampleCount < 0 {
+		errs.add("sample_count", "negative")
+	}
+	if payload.SampleCount > 0 {
+		a := payload.MetricAggregates
+		for _, agg := range []struct {
+			name          string
+			min, max, p95 float64
+		}{
+			{"cpu", a.CPUMin, a.CPUMax, a.CPUP95},
+			{"memory", a.MemoryMin, a.MemoryMax, a.MemoryP95},
+			{"disk", a.DiskMin, a.DiskMax, a.DiskP95},
+		} {
+			errs.checkPercent(agg.name+"_min", agg.min)
+			errs.checkPercent(agg.name+"_max", agg.max)
+			errs.checkPercent(agg.name+"_p95", agg.p95)
+			if agg.min > agg.max {
+				errs.add(agg.name+"_min", "min_above_max", agg.name)
+			}
+		}
+	}
+
+	// Horário de coleta informado pelo agent
+	if err := s.validateCollectedAt(payload.CollectedAt, receivedAt); err != nil {
+		errs.add("collected_at", err.Code, err.Args...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// selectorFromRequest lê o seletor de labels de ?selector=
+func selectorFromRequest(r *http.Request) (storage.LabelSelector, *apiError) {
+	selector, err := storage.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		return nil, selectorError(err)
+	}
+	return selector, nil
+}
+
+// selectorError converte o erro de ParseLabelSelector no código invalid_selector
+func selectorError(err error) *apiError {
+	var selErr *storage.SelectorError
+	if errors.As(err, &selErr) {
+		return newAPIError("invalid_selector", selErr.Term)
+	}
+	return newAPIError("invalid_selector", err.Error())
+}
+
+// newPayloadDecoder cria o decoder dos envios de métricas; com --strict-payload,
+// campos desconhecidos são recusados
+func (s *Server) newPayloadDecoder(r io.Reader) *json.Decoder {
+	decoder := json.NewDecoder(r)
+	if s.config.StrictPayload {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder
+}
+
+// decodeFieldError converte erros de decodificação que apontam um campo (tipo errado
+// ou campo desconhecido) em validationErrors; retorna nil para os demais erros
+func decodeFieldError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		var errs validationErrors
+		errs.add(typeErr.Field, "invalid_type", typeErr.Value, typeErr.Type.String())
+		return errs
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		var errs validationErrors
+		errs.add(strings.Trim(field, `"`), "unknown_field")
+		return errs
+	}
+	return nil
+}
+
+// validationError responde 400 com a lista de campos inválidos em "errors"
+func validationError(w http.ResponseWriter, r *http.Request, err error) {
+	lang := requestLanguage(r)
+	body := map[string]interface{}{
+		"status":  "error",
+		"code":    "invalid_payload",
+		"message": i18n.T(lang, "invalid_payload", errorMessage(lang, err)),
+	}
+	var errs validationErrors
+	if errors.As(err, &errs) {
+		body["errors"] = errs.translate(lang)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(body)
+}
+
+// recordRejected conta o payload recusado na máquina que o enviou (se já cadastrada)
+func (s *Server) recordRejected(payload *storage.MetricPayload, reason error) {
+	log.Printf("Payload de %q recusado: %v", payload.Hostname, reason)
+	machineID, err := s.storage.RecordRejectedPayload(payload.MachineUUID, payload.Hostname, reason.Error())
+	if err != nil {
+		log.Printf("Aviso: %v", err)
+	}
+	if machineID != 0 {
+		s.publishAlert(machineID, "rejected", reason)
+		s.publishMachine(machineID)
+	}
+}
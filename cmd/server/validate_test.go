@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestValidatePayload(t *testing.T) {
+	s := newTestServer(t, nil)
+	now := time.Now()
+	valid := func() storage.MetricPayload {
+		return storage.MetricPayload{
+			MachineUUID:   "8a3c9f1e-0000-4000-8000-000000000001",
+			Hostname:      "web-01",
+			IP:            "10.0.0.1",
+			CPUPercent:    10,
+			MemoryPercent: 20,
+			DiskPercent:   30,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *storage.MetricPayload)
+		fields []string // campos inválidos esperados, na ordem
+	}{
+		{"payload válido", func(p *storage.MetricPayload) {}, nil},
+		{"sem hostname", func(p *storage.MetricPayload) { p.Hostname = " " }, []string{"hostname"}},
+		{"hostname longo", func(p *storage.MetricPayload) { p.Hostname = strings.Repeat("a", 254) }, []string{"hostname"}},
+		{"caractere de controle", func(p *storage.MetricPayload) { p.Hostname = "web\n01" }, []string{"hostname"}},
+		{"UUID inválido", func(p *storage.MetricPayload) { p.MachineUUID = "não-é-uuid" }, []string{"machine_uuid"}},
+		{"IP inválido", func(p *storage.MetricPayload) { p.IP = "10.0.0.256" }, []string{"ip"}},
+		{"papel de swarm desconhecido", func(p *storage.MetricPayload) { p.SwarmRole = "leader" }, []string{"swarm_role"}},
+		{"label inválido", func(p *storage.MetricPayload) { p.Labels = map[string]string{"env=x": "prod"} }, []string{"labels.env=x"}},
+		{"percentual acima de 100", func(p *storage.MetricPayload) { p.CPUPercent = 150 }, []string{"cpu_percent"}},
+		{"percentual NaN", func(p *storage.MetricPayload) { p.MemoryPercent = math.NaN() }, []string{"memory_percent"}},
+		{"contagem negativa", func(p *storage.MetricPayload) { p.DockerRunning = -1 }, []string{"docker_running"}},
+		{"todos os erros de uma vez", func(p *storage.MetricPayload) {
+			p.Hostname = ""
+			p.CPUPercent = -1
+			p.DockerStopped = maxContainers + 1
+		}, []string{"hostname", "cpu_percent", "docker_stopped"}},
+		{"agregados com mínimo acima do máximo", func(p *storage.MetricPayload) {
+			p.SampleCount = 3
+			p.CPUMin, p.CPUMax, p.CPUP95 = 50, 40, 45
+		}, []string{"cpu_min"}},
+		{"agregados ignorados sem amostras", func(p *storage.MetricPayload) { p.CPUMin = 500 }, nil},
+		{"amostras negativas", func(p *storage.MetricPayload) { p.SampleCount = -1 }, []string{"sample_count"}},
+		{"coleta no futuro", func(p *storage.MetricPayload) { p.CollectedAt = now.Add(time.Hour) }, []string{"collected_at"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := valid()
+			tt.change(&payload)
+
+			var fields []string
+			var errs validationErrors
+			if err := s.validatePayload(&payload, now); errors.As(err, &errs) {
+				for _, fe := range errs {
+					fields = append(fields, fe.Field)
+				}
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("campos inválidos = %v, esperado %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestIsUUID(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"8a3c9f1e-0000-4000-8000-000000000001", true},
+		{"8A3C9F1E-0000-4000-8000-00000000000F", true},
+		{"8a3c9f1e00004000800000000000000001", false},
+		{"8a3c9f1e-0000-4000-8000-00000000000g", false},
+		{"8a3c9f1e-0000-4000-8000_000000000001", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isUUID(tt.value); got != tt.ok {
+			t.Errorf("isUUID(%q) = %v, esperado %v", tt.value, got, tt.ok)
+		}
+	}
+}
+
+func TestPayloadDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool // --strict-payload
+		body   string
+		status int
+		field  string // campo apontado no erro (vazio: nenhum)
+		lang   string
+	}{
+		{"campo desconhecido aceito", false, `{"hostname": "web-01", "ip": "10.0.0.1", "extra": 1}`, http.StatusCreated, "", ""},
+		{"campo desconhecido no modo estrito", true, `{"hostname": "web-01", "ip": "10.0.0.1", "extra": 1}`, http.StatusBadRequest, "extra", ""},
+		{"tipo errado", false, `{"hostname": "web-01", "cpu_percent": "alto"}`, http.StatusBadRequest, "cpu_percent", ""},
+		{"JSON inválido", false, `{"hostname":`, http.StatusBadRequest, "", ""},
+		{"mensagem em inglês", false, `{"hostname": "", "ip": "10.0.0.1"}`, http.StatusBadRequest, "hostname", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, func(c *Config) { c.StrictPayload = tt.strict })
+			header := bearer("token-de-teste")
+			if tt.lang != "" {
+				header["Accept-Language"] = tt.lang
+			}
+			w := serve(s, http.MethodPost, "/api/metrics", tt.body, header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+
+			var body struct {
+				Errors []fieldError `json:"errors"`
+			}
+			json.NewDecoder(w.Body).Decode(&body)
+			if tt.field == "" {
+				if len(body.Errors) != 0 {
+					t.Errorf("erros inesperados: %+v", body.Errors)
+				}
+				return
+			}
+			if len(body.Errors) != 1 || body.Errors[0].Field != tt.field {
+				t.Fatalf("erros = %+v, esperado o campo %s", body.Errors, tt.field)
+			}
+			if tt.lang == "en" && body.Errors[0].Message != "is required" {
+				t.Errorf("mensagem não traduzida: %s", body.Errors[0].Message)
+			}
+		})
+	}
+}
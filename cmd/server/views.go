@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"monitor-infra/internal/storage"
+)
+
+// maxViewNameLength é o tamanho máximo do nome de uma visão salva
+const maxViewNameLength = 64
+
+// viewSorts são os campos de ordenação aceitos nas visões (com "-" para ordem decrescente)
+var viewSorts = map[string]bool{
+	"": true, "name": true, "cpu": true, "memory": true, "disk": true, "containers": true, "last_seen": true,
+}
+
+// viewStatuses são os estados aceitos no filtro de status das visões
+var viewStatuses = map[string]bool{
+	"": true, storage.StatusOnline: true, storage.StatusWarning: true, storage.StatusCritical: true, storage.StatusOffline: true,
+}
+
+// viewOwner retorna o usuário dono das visões do request: o usuário logado ou o dono da
+// chave de API (nil com o ADMIN_TOKEN ou no modo aberto)
+func viewOwner(r *http.Request) *int64 {
+	p := principalFromContext(r.Context())
+	switch {
+	case p == nil:
+		return nil
+	case p.User != nil:
+		return &p.User.ID
+	case p.KeyOwner != nil:
+		return &p.KeyOwner.ID
+	}
+	return nil
+}
+
+// handleViews lista (GET) e salva (POST) visões do dashboard.
+// Corpo do POST: {"name": "...", "shared": false, "filters": {"status": "offline", "sort": "-disk"}}
+func (s *Server) handleViews(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		views, err := s.storage.ListViews(viewOwner(r))
+		if err != nil {
+			log.Printf("Erro ao listar visões: %v", err)
+			jsonError(w, r, "list_views_failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"views": views,
+		})
+
+	case http.MethodPost:
+		s.roleMiddleware(storage.RoleViewer, s.handleViewSave)(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleViewSave cria ou substitui (mesmo nome) uma visão do usuário
+func (s *Server) handleViewSave(w http.ResponseWriter, r *http.Request) {
+	if p := principalFromContext(r.Context()); p != nil && p.APIKey != nil {
+		jsonError(w, r, "api_key_read_only", http.StatusForbidden)
+		return
+	}
+
+	var body struct {
+		Name    string              `json:"name"`
+		Shared  bool                `json:"shared"`
+		Filters storage.ViewFilters `json:"filters"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, r, "invalid_json", http.StatusBadRequest)
+		return
+	}
+
+	var errs validationErrors
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		errs.add("name", "required")
+	} else {
+		errs.checkText("name", body.Name, maxViewNameLength)
+	}
+	f := &body.Filters
+	f.Search = strings.TrimSpace(f.Search)
+	errs.checkText("filters.search", f.Search, maxHostnameLength)
+	errs.checkText("filters.group", f.Group, maxGroupLength)
+	if !viewStatuses[f.Status] {
+		errs.add("filters.status", "invalid_status", f.Status)
+	}
+	if !swarmRoles[f.SwarmRole] {
+		errs.add("filters.swarm_role", "invalid_swarm_role", f.SwarmRole)
+	}
+	if _, err := storage.ParseLabelSelector(f.Selector); err != nil {
+		selErr := selectorError(err)
+		errs.add("filters.selector", selErr.Code, selErr.Args...)
+	}
+	if !viewSorts[strings.TrimPrefix(f.Sort, "-")] {
+		errs.add("filters.sort", "invalid_sort", f.Sort)
+	}
+	if len(errs) > 0 {
+		validationError(w, r, errs)
+		return
+	}
+
+	view, err := s.storage.SaveView(viewOwner(r), body.Name, body.Shared, body.Filters)
+	if err != nil {
+		log.Printf("Erro ao salvar visão: %v", err)
+		jsonError(w, r, "save_view_failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(view)
+}
+
+// handleViewDetail remove (DELETE) uma visão; apenas o dono ou um administrador
+func (s *Server) handleViewDetail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/views/"), 10, 64)
+	if err != nil {
+		jsonError(w, r, "invalid_id", http.StatusBadRequest)
+		return
+	}
+
+	s.roleMiddleware(storage.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		p := principalFromContext(r.Context())
+		if p != nil && p.APIKey != nil {
+			jsonError(w, r, "api_key_read_only", http.StatusForbidden)
+			return
+		}
+
+		isAdmin := p != nil && p.role() == storage.RoleAdmin
+		if err := s.storage.DeleteView(id, viewOwner(r), isAdmin); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				jsonError(w, r, "view_not_found", http.StatusNotFound)
+				return
+			}
+			log.Printf("Erro ao remover visão: %v", err)
+			jsonError(w, r, "delete_view_failed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+			"id":     id,
+		})
+	})(w, r)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"monitor-infra/internal/storage"
+)
+
+func TestHandleViewSave(t *testing.T) {
+	s := newTestServer(t, nil)
+	_, viewer := login(t, s, "leitor", storage.RoleViewer, nil)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		field  string // campo inválido esperado
+	}{
+		{"visão válida", `{"name": "Offline", "filters": {"status": "offline", "sort": "-disk", "selector": "env=prod"}}`, http.StatusCreated, ""},
+		{"sem nome", `{"name": " ", "filters": {}}`, http.StatusBadRequest, "name"},
+		{"status desconhecido", `{"name": "x", "filters": {"status": "down"}}`, http.StatusBadRequest, "filters.status"},
+		{"ordenação desconhecida", `{"name": "x", "filters": {"sort": "-ip"}}`, http.StatusBadRequest, "filters.sort"},
+		{"seletor inválido", `{"name": "x", "filters": {"selector": "env prod"}}`, http.StatusBadRequest, "filters.selector"},
+		{"papel de swarm desconhecido", `{"name": "x", "filters": {"swarm_role": "leader"}}`, http.StatusBadRequest, "filters.swarm_role"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodPost, "/api/views", tt.body, viewer)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.field != "" && !strings.Contains(w.Body.String(), `"field":"`+tt.field+`"`) {
+				t.Errorf("resposta %s, esperado o campo %s", w.Body, tt.field)
+			}
+		})
+	}
+}
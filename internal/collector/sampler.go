@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Aggregate resume uma série de amostras de uma métrica
+type Aggregate struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P95 float64 `json:"p95"`
+}
+
+// Summary contém os agregados das amostras coletadas entre dois relatórios
+type Summary struct {
+	Count  int
+	CPU    Aggregate
+	Memory Aggregate
+	Disk   Aggregate
+}
+
+// Sampler coleta amostras de CPU, memória e disco em alta resolução entre relatórios.
+// O CPU de cada amostra é calculado sobre todo o período desde a amostra anterior,
+// sem a espera de 1 segundo usada por CollectCPU.
+type Sampler struct {
+	collector *Collector
+
+	mu        sync.Mutex
+	prevIdle  uint64
+	prevTotal uint64
+	hasPrev   bool
+	cpu       []float64
+	memory    []float64
+	disk      []float64
+}
+
+// NewSampler cria um sampler e registra a leitura inicial de CPU
+func NewSampler(c *Collector) *Sampler {
+	s := &Sampler{collector: c}
+	if idle, total, err := readCPUStat(); err == nil {
+		s.prevIdle, s.prevTotal, s.hasPrev = idle, total, true
+	}
+	return s
+}
+
+// Sample registra uma amostra de cada métrica
+func (s *Sampler) Sample() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idle, total, err := readCPUStat()
+	if err != nil {
+		return err
+	}
+
+	if s.hasPrev && total > s.prevTotal {
+		idleDelta := float64(idle - s.prevIdle)
+		totalDelta := float64(total - s.prevTotal)
+		s.cpu = append(s.cpu, 100*(1-idleDelta/totalDelta))
+	}
+	s.prevIdle, s.prevTotal, s.hasPrev = idle, total, true
+
+	if mem, err := s.collector.CollectMemory(); err == nil {
+		s.memory = append(s.memory, mem)
+	}
+
+	if disk, err := s.collector.CollectDisk(); err == nil {
+		s.disk = append(s.disk, disk)
+	}
+
+	return nil
+}
+
+// Flush retorna os agregados das amostras acumuladas e reinicia o acumulador.
+// Retorna nil se não houver amostras de CPU no período.
+func (s *Sampler) Flush() *Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cpu) == 0 {
+		return nil
+	}
+
+	summary := &Summary{
+		Count:  len(s.cpu),
+		CPU:    aggregate(s.cpu),
+		Memory: aggregate(s.memory),
+		Disk:   aggregate(s.disk),
+	}
+
+	s.cpu, s.memory, s.disk = nil, nil, nil
+	return summary
+}
+
+// aggregate calcula mínimo, média, máximo e percentil 95 (nearest-rank)
+func aggregate(values []float64) Aggregate {
+	if len(values) == 0 {
+		return Aggregate{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return Aggregate{
+		Min: sorted[0],
+		Avg: sum / float64(len(sorted)),
+		Max: sorted[len(sorted)-1],
+		P95: sorted[rank],
+	}
+}
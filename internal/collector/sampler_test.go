@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	// 1..100: p95 pelo nearest-rank é o 95º valor
+	hundred := make([]float64, 100)
+	for i := range hundred {
+		hundred[i] = float64(i + 1)
+	}
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   Aggregate
+	}{
+		{"sem amostras", nil, Aggregate{}},
+		{"uma amostra", []float64{42}, Aggregate{Min: 42, Avg: 42, Max: 42, P95: 42}},
+		{"fora de ordem", []float64{30, 10, 20}, Aggregate{Min: 10, Avg: 20, Max: 30, P95: 30}},
+		{"pico isolado", []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 100}, Aggregate{Min: 5, Avg: 9.75, Max: 100, P95: 5}},
+		{"cem amostras", hundred, Aggregate{Min: 1, Avg: 50.5, Max: 100, P95: 95}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregate(tt.values)
+			if math.Abs(got.Avg-tt.want.Avg) > 1e-9 || got.Min != tt.want.Min || got.Max != tt.want.Max || got.P95 != tt.want.P95 {
+				t.Errorf("aggregate = %+v, esperado %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateKeepsInput(t *testing.T) {
+	values := []float64{3, 1, 2}
+	aggregate(values)
+	if values[0] != 3 || values[1] != 1 || values[2] != 2 {
+		t.Errorf("aggregate alterou a série: %v", values)
+	}
+}
+
+func TestSamplerFlush(t *testing.T) {
+	s := &Sampler{}
+	if s.Flush() != nil {
+		t.Fatal("Flush sem amostras deveria retornar nil")
+	}
+
+	s.cpu = []float64{10, 20}
+	s.memory = []float64{50}
+	s.disk = []float64{70, 71}
+
+	summary := s.Flush()
+	if summary == nil {
+		t.Fatal("Flush retornou nil com amostras")
+	}
+	if summary.Count != 2 || summary.CPU.Max != 20 || summary.Memory.Avg != 50 || summary.Disk.Min != 70 {
+		t.Errorf("resumo inesperado: %+v", summary)
+	}
+	if s.Flush() != nil {
+		t.Error("Flush deveria reiniciar o acumulador")
+	}
+}
@@ -0,0 +1,221 @@
+// Package dashboard serve o dashboard web, a página de login e o script de instalação, e
+// renderiza o resumo enviado por e-mail.
+//
+// As páginas ficam em templates/ (html/template, renderizadas por idioma com o estado
+// inicial), CSS e JavaScript em static/ e o script em install.sh. Tudo é embutido no
+// binário; com um diretório de override (--dashboard-dir), os arquivos são relidos do
+// disco a cada request, para desenvolvimento sem recompilar.
+package dashboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"monitor-infra/internal/i18n"
+)
+
+//go:embed templates static install.sh
+var embedded embed.FS
+
+// StaticPrefix é o caminho em que os arquivos de static/ são servidos
+const StaticPrefix = "/static/"
+
+// Page são os dados de uma página: o idioma, o estado inicial do dashboard (serializado em
+// JSON para o JavaScript) e, no login, se a última tentativa falhou
+type Page struct {
+	Lang  string
+	State interface{}
+	Error bool
+}
+
+// Assets serve os arquivos do dashboard, embutidos ou de um diretório
+type Assets struct {
+	fsys   fs.FS
+	dev    bool
+	bundle *bundle
+}
+
+// bundle guarda os templates compilados por idioma e o conteúdo e a versão (hash) de cada arquivo
+type bundle struct {
+	pages    map[string]*template.Template
+	files    map[string][]byte
+	versions map[string]string
+}
+
+// New carrega os arquivos do dashboard. Com dir vazio, usa os embutidos no binário; senão, lê
+// de dir (mesma estrutura: templates/, static/ e install.sh) e recarrega a cada request.
+func New(dir string) (*Assets, error) {
+	if dir == "" {
+		b, err := load(embedded)
+		if err != nil {
+			return nil, err
+		}
+		return &Assets{fsys: embedded, bundle: b}, nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s não é um diretório", dir)
+	}
+	fsys := os.DirFS(dir)
+	if _, err := load(fsys); err != nil {
+		return nil, err
+	}
+	return &Assets{fsys: fsys, dev: true}, nil
+}
+
+// load lê os arquivos e compila os templates de cada idioma
+func load(fsys fs.FS) (*bundle, error) {
+	b := &bundle{
+		pages:    make(map[string]*template.Template),
+		files:    make(map[string][]byte),
+		versions: make(map[string]string),
+	}
+
+	names, err := fs.Glob(fsys, "static/*")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range append(names, "install.sh") {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		b.files[name] = data
+		b.versions[name] = version(data)
+	}
+
+	for _, lang := range i18n.Supported() {
+		page, err := template.New("").Funcs(b.funcs(lang)).ParseFS(fsys, "templates/*.html")
+		if err != nil {
+			return nil, fmt.Errorf("erro nos templates: %w", err)
+		}
+		b.pages[lang] = page
+	}
+	return b, nil
+}
+
+// funcs são as funções disponíveis nos templates:
+// t traduz uma chave, messages retorna o catálogo ui.* (usado pela função t() do JavaScript)
+// e static o caminho de um arquivo de static/ com a versão, para cache longo no navegador
+func (b *bundle) funcs(lang string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...interface{}) string {
+			return i18n.T(lang, key, args...)
+		},
+		"messages": func() map[string]string {
+			return i18n.Messages(lang, "ui.")
+		},
+		"static": func(name string) string {
+			return StaticPrefix + name + "?v=" + b.versions["static/"+name]
+		},
+	}
+}
+
+// version é o hash do conteúdo de um arquivo, usado no ETag e em ?v=
+func version(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// current retorna os arquivos carregados; com override, relê o diretório
+func (a *Assets) current() (*bundle, error) {
+	if !a.dev {
+		return a.bundle, nil
+	}
+	return load(a.fsys)
+}
+
+// Render renderiza uma página de templates/ (ex.: "dashboard.html") no idioma de page.Lang.
+// Sem um Cache-Control definido por quem chama, a resposta não vai para caches compartilhados
+// (depende do usuário) e é revalidada pelo ETag.
+func (a *Assets) Render(w http.ResponseWriter, r *http.Request, name string, page Page) {
+	var buf bytes.Buffer
+	if err := a.Execute(&buf, name, page); err != nil {
+		log.Printf("Erro ao renderizar %s: %v", name, err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache, private")
+	}
+	w.Header().Set("Vary", "Cookie, Accept-Language")
+	serve(w, r, name, buf.Bytes(), version(buf.Bytes()))
+}
+
+// Execute renderiza uma página de templates/ em w, no idioma de page.Lang (ou no padrão, se
+// não suportado); usado também fora de requests, como no resumo por e-mail
+func (a *Assets) Execute(w io.Writer, name string, page Page) error {
+	b, err := a.current()
+	if err != nil {
+		return err
+	}
+	tmpl, ok := b.pages[page.Lang]
+	if !ok {
+		page.Lang = i18n.Default
+		tmpl = b.pages[page.Lang]
+	}
+	return tmpl.ExecuteTemplate(w, name, page)
+}
+
+// ServeStatic serve os arquivos de static/. Com ?v= igual à versão atual o arquivo é imutável
+// e fica em cache por um ano; sem ele (ou com override), é revalidado pelo ETag.
+func (a *Assets) ServeStatic(w http.ResponseWriter, r *http.Request) {
+	b, err := a.current()
+	if err != nil {
+		log.Printf("Erro ao carregar dashboard: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	name := "static/" + strings.TrimPrefix(r.URL.Path, StaticPrefix)
+	data, ok := b.files[name]
+	if !ok || path.Dir(name) != "static" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !a.dev && r.URL.Query().Get("v") == b.versions[name] {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	serve(w, r, name, data, b.versions[name])
+}
+
+// ServeInstallScript serve o script de instalação do agent
+func (a *Assets) ServeInstallScript(w http.ResponseWriter, r *http.Request) {
+	b, err := a.current()
+	if err != nil {
+		log.Printf("Erro ao carregar dashboard: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	serve(w, r, "install.sh", b.files["install.sh"], b.versions["install.sh"])
+}
+
+// serve envia o conteúdo com ETag; http.ServeContent responde 304 a If-None-Match e trata HEAD e Range
+func serve(w http.ResponseWriter, r *http.Request, name string, data []byte, etag string) {
+	w.Header().Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"bytes"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"monitor-infra/internal/i18n"
+)
+
+func TestExecuteLanguages(t *testing.T) {
+	a, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{i18n.PtBR, i18n.T(i18n.PtBR, "ui.login.title")},
+		{i18n.En, i18n.T(i18n.En, "ui.login.title")},
+		{"fr", i18n.T(i18n.Default, "ui.login.title")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := a.Execute(&buf, "login.html", Page{Lang: tt.lang}); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), "<title>"+tt.want) {
+				t.Errorf("título em %s não encontrado", tt.lang)
+			}
+		})
+	}
+}
+
+// TestUIKeys garante que toda chave literal usada nos templates e no JavaScript existe no
+// catálogo (chaves montadas em tempo de execução, como 'ui.status.' + status, são ignoradas)
+func TestUIKeys(t *testing.T) {
+	usage := regexp.MustCompile(`\bt[ (]['"](ui\.[a-z0-9_.]*[a-z0-9_])['"]`)
+	found := 0
+	err := fs.WalkDir(embedded, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(embedded, name)
+		if err != nil {
+			return err
+		}
+		for _, m := range usage.FindAllStringSubmatch(string(data), -1) {
+			found++
+			for _, lang := range i18n.Supported() {
+				if i18n.T(lang, m[1]) == m[1] {
+					t.Errorf("%s: chave %q sem mensagem em %s", name, m[1], lang)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == 0 {
+		t.Error("nenhuma chave encontrada nos arquivos do dashboard")
+	}
+}
+
+func TestServeStatic(t *testing.T) {
+	a, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := a.bundle.versions["static/dashboard.css"]
+
+	tests := []struct {
+		name         string
+		path         string
+		header       map[string]string
+		status       int
+		cacheControl string
+	}{
+		{"versão atual", "/static/dashboard.css?v=" + current, nil, http.StatusOK, "public, max-age=31536000, immutable"},
+		{"sem versão", "/static/dashboard.css", nil, http.StatusOK, "no-cache"},
+		{"versão antiga", "/static/dashboard.css?v=0000", nil, http.StatusOK, "no-cache"},
+		{"revalidação pelo ETag", "/static/dashboard.css", map[string]string{"If-None-Match": `"` + current + `"`}, http.StatusNotModified, "no-cache"},
+		{"arquivo inexistente", "/static/ausente.css", nil, http.StatusNotFound, ""},
+		{"fora de static", "/static/../install.sh", nil, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			a.ServeStatic(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, esperado %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Cache-Control"); tt.cacheControl != "" && got != tt.cacheControl {
+				t.Errorf("Cache-Control = %q, esperado %q", got, tt.cacheControl)
+			}
+		})
+	}
+}
+
+func TestOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("templates/page.html", `{{define "page.html"}}<p>{{t "ui.logout"}}</p>{{end}}`)
+	write("static/app.css", "body{}")
+	write("install.sh", "#!/bin/sh\n")
+
+	a, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"conteúdo inicial", "body{}"},
+		{"alteração relida sem reiniciar", "body{color:red}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write("static/app.css", tt.content)
+			w := httptest.NewRecorder()
+			a.ServeStatic(w, httptest.NewRequest(http.MethodGet, "/static/app.css", nil))
+			if w.Body.String() != tt.content {
+				t.Errorf("conteúdo = %q, esperado %q", w.Body, tt.content)
+			}
+		})
+	}
+
+	if _, err := New(filepath.Join(dir, "install.sh")); err == nil {
+		t.Error("arquivo aceito como diretório do dashboard")
+	}
+	write("templates/broken.html", `{{define "broken.html"}}{{end`)
+	if _, err := New(dir); err == nil {
+		t.Error("template inválido aceito")
+	}
+}
@@ -0,0 +1,859 @@
+const REFRESH_INTERVAL = 60000; // 60 segundos
+const STATUS_ORDER = { offline: 0, critical: 1, warning: 2, online: 3 };
+
+// Estado e limites vêm calculados pelo servidor (limites padrão, do grupo ou da máquina)
+function getBarClass(machine, type, metric, value) {
+    const limit = (machine.thresholds || {})[metric];
+    if (!limit) return type;
+    if (value >= limit.critical) return type + ' critical';
+    if (value >= limit.warning) return type + ' warning';
+    return type;
+}
+
+function getStatusClass(machine) {
+    return machine.status || 'online';
+}
+
+// t traduz uma mensagem do catálogo (internal/i18n); %s e %d recebem os argumentos, em ordem
+function t(key) {
+    const args = Array.prototype.slice.call(arguments, 1);
+    return (MESSAGES[key] || key).replace(/%[sd]/g, function() { return args.length ? args.shift() : ''; });
+}
+
+function formatTime(dateStr) {
+    const date = new Date(dateStr);
+    const now = new Date();
+    const diffMinutes = Math.floor((now - date) / 1000 / 60);
+
+    if (diffMinutes < 1) return t('ui.time.now');
+    if (diffMinutes < 60) return t('ui.time.minutes', diffMinutes);
+    if (diffMinutes < 1440) return t('ui.time.hours', Math.floor(diffMinutes / 60));
+    return t('ui.time.days', Math.floor(diffMinutes / 1440));
+}
+
+function escapeHtml(text) {
+    return String(text).replace(/&/g, '&amp;').replace(/"/g, '&quot;').replace(/</g, '&lt;').replace(/>/g, '&gt;');
+}
+
+function renderMachine(machine) {
+    const status = getStatusClass(machine);
+    const m = machine.metrics || {};
+
+    let swarmBadge = '';
+    if (machine.swarm_role === 'manager') {
+        swarmBadge = '<span class="badge badge-manager">' + t('ui.role.manager') + '</span>';
+    } else if (machine.swarm_role === 'worker') {
+        swarmBadge = '<span class="badge badge-worker">' + t('ui.role.worker') + '</span>';
+    }
+
+    let driftBadge = '';
+    if (machine.clock_drift) {
+        const skew = Math.round(machine.clock_skew_seconds || 0);
+        driftBadge = '<span class="badge badge-drift" title="' + escapeHtml(t('ui.badge.clock_title', (skew > 0 ? '+' : '') + skew)) + '">' + t('ui.badge.clock') + '</span>';
+    }
+
+    if (machine.hostname_conflict) {
+        driftBadge += '<span class="badge badge-conflict" title="' + escapeHtml(t('ui.badge.conflict_title')) + '">' + t('ui.badge.conflict') + '</span>';
+    }
+
+    // Payloads recusados pela validação nas últimas 24h
+    if (machine.last_rejected_at && Date.now() - new Date(machine.last_rejected_at) < 24 * 3600 * 1000) {
+        driftBadge += '<span class="badge badge-drift" title="' + escapeHtml(t('ui.badge.rejected_title', machine.rejected_payloads, machine.last_rejected_reason || '')) + '">' + t('ui.badge.rejected') + '</span>';
+    }
+
+    // Métricas fora do padrão da máquina para a hora da semana
+    if (machine.anomalies && machine.anomalies.length) {
+        const details = machine.anomalies.map(function(a) {
+            return metricName(a.metric) + ' ' + a.value.toFixed(1) + '% (' + a.expected.toFixed(1) + '% ± ' + a.stddev.toFixed(1) + ')';
+        }).join(', ');
+        driftBadge += '<span class="badge badge-drift" title="' + escapeHtml(t('ui.badge.anomaly_title', details)) + '">' + t('ui.badge.anomaly') + '</span>';
+    }
+
+    let statusBadge = '';
+    if (machine.archived_at) {
+        statusBadge = '<span class="badge badge-offline">' + t('ui.badge.archived') + '</span>';
+    } else {
+        statusBadge = '<span class="badge badge-' + status + '">' + t('ui.status.' + status) + '</span>';
+    }
+
+    const name = machine.display_name || machine.hostname;
+    return '<div class="machine-card ' + (machine.archived_at ? 'archived' : status) + '" data-machine-id="' + machine.id + '" onclick="openHistory(' + machine.id + ')">' +
+        '<div class="card-header">' +
+            '<span class="hostname" title="' + escapeHtml(machine.hostname) + '">' + escapeHtml(name) + '</span>' +
+            '<div class="badges">' + swarmBadge + driftBadge + statusBadge + '</div>' +
+        '</div>' +
+        '<div class="metrics">' +
+            '<div class="metric">' +
+                '<span class="metric-label">CPU</span>' +
+                '<div class="bar-container">' +
+                    '<div class="bar-fill ' + getBarClass(machine, 'cpu', 'cpu', m.cpu_percent || 0) + '" style="width: ' + (m.cpu_percent || 0) + '%"></div>' +
+                '</div>' +
+                '<span class="metric-value">' + (m.cpu_percent || 0).toFixed(1) + '%</span>' +
+            '</div>' +
+            '<div class="metric">' +
+                '<span class="metric-label">' + t('ui.metric.memory') + '</span>' +
+                '<div class="bar-container">' +
+                    '<div class="bar-fill ' + getBarClass(machine, 'mem', 'memory', m.memory_percent || 0) + '" style="width: ' + (m.memory_percent || 0) + '%"></div>' +
+                '</div>' +
+                '<span class="metric-value">' + (m.memory_percent || 0).toFixed(1) + '%</span>' +
+            '</div>' +
+            '<div class="metric">' +
+                '<span class="metric-label">' + t('ui.metric.disk') + '</span>' +
+                '<div class="bar-container">' +
+                    '<div class="bar-fill ' + getBarClass(machine, 'disk', 'disk', m.disk_percent || 0) + '" style="width: ' + (m.disk_percent || 0) + '%"></div>' +
+                '</div>' +
+                '<span class="metric-value">' + (m.disk_percent || 0).toFixed(1) + '%</span>' +
+            '</div>' +
+        '</div>' +
+        '<div class="docker-info">' +
+            '<span class="docker-stat"><span class="docker-up">' + (m.docker_running || 0) + '</span> ' + t('ui.docker.running') + '</span>' +
+            '<span class="docker-stat"><span class="docker-down">' + (m.docker_stopped || 0) + '</span> ' + t('ui.docker.stopped') + '</span>' +
+        '</div>' +
+        renderLabels(machine.labels) +
+        '<div class="card-footer">' +
+            '<span>' + (machine.ip || t('ui.unknown_ip')) + '</span>' +
+            '<span>' + formatTime(machine.last_seen) + '</span>' +
+        '</div>' +
+    '</div>';
+}
+
+function metricName(metric) {
+    return metric === 'cpu' ? 'CPU' : t('ui.chart.' + metric);
+}
+
+function renderLabels(labels) {
+    const keys = Object.keys(labels || {}).sort();
+    if (keys.length === 0) return '';
+    return '<div class="labels">' + keys.map(function(key) {
+        return '<span class="label-chip">' + escapeHtml(key + '=' + labels[key]) + '</span>';
+    }).join('') + '</div>';
+}
+
+function renderEmptyState() {
+    const serverUrl = window.location.origin;
+    return '<div class="empty-state">' +
+        '<h2>' + t('ui.empty.title') + '</h2>' +
+        '<p>' + t('ui.empty.hint') + '</p>' +
+        '<code>curl -sSL ' + serverUrl + '/install.sh | bash -s -- --server ' + serverUrl + ' --token ' + t('ui.empty.token') + ' --name "' + t('ui.empty.name') + '"</code>' +
+    '</div>';
+}
+
+function groupMachines(machines) {
+    const groups = {};
+    machines.forEach(function(m) {
+        const group = m.group || 'default';
+        if (!groups[group]) groups[group] = [];
+        groups[group].push(m);
+    });
+
+    // Ordenar cada grupo: offline primeiro, depois por nome
+    Object.keys(groups).forEach(function(key) {
+        groups[key].sort(function(a, b) {
+            const statusA = getStatusClass(a);
+            const statusB = getStatusClass(b);
+
+            // Offline primeiro, depois crítico e atenção
+            if (STATUS_ORDER[statusA] !== STATUS_ORDER[statusB]) return STATUS_ORDER[statusA] - STATUS_ORDER[statusB];
+
+            // Por nome
+            return a.hostname.localeCompare(b.hostname);
+        });
+    });
+
+    return groups;
+}
+
+// Últimos dados de cada máquina, por ID (lista completa + eventos do stream)
+let machinesById = {};
+
+function render(data) {
+    machinesById = {};
+    (data.machines || []).forEach(function(m) { machinesById[m.id] = m; });
+    renderAll();
+}
+
+function renderStats(machines) {
+    let online = 0, warning = 0, critical = 0, offline = 0, containers = 0;
+    machines.forEach(function(m) {
+        if (m.archived_at) return;
+        const status = getStatusClass(m);
+        if (status === 'online') online++;
+        else if (status === 'warning') warning++;
+        else if (status === 'critical') critical++;
+        else offline++;
+
+        if (m.metrics) containers += m.metrics.docker_running || 0;
+    });
+
+    document.getElementById('stat-online').textContent = online;
+    document.getElementById('stat-warning').textContent = warning;
+    document.getElementById('stat-critical').textContent = critical;
+    document.getElementById('stat-offline').textContent = offline;
+    document.getElementById('stat-containers').textContent = containers;
+}
+
+// Busca, filtros e ordenação aplicados no navegador (labels e arquivadas são filtrados pela API)
+let filters = { search: '', status: '', group: '', swarm_role: '', sort: '' };
+
+const SORT_KEYS = {
+    name: function(m) { return (m.display_name || m.hostname).toLowerCase(); },
+    cpu: function(m) { return (m.metrics || {}).cpu_percent || 0; },
+    memory: function(m) { return (m.metrics || {}).memory_percent || 0; },
+    disk: function(m) { return (m.metrics || {}).disk_percent || 0; },
+    containers: function(m) { return (m.metrics || {}).docker_running || 0; },
+    last_seen: function(m) { return new Date(m.last_seen).getTime(); }
+};
+
+function matchesFilters(m) {
+    if (filters.status && getStatusClass(m) !== filters.status) return false;
+    if (filters.group && (m.group || 'default') !== filters.group) return false;
+    if (filters.swarm_role && (m.swarm_role || 'none') !== filters.swarm_role) return false;
+    if (filters.search) {
+        const text = [m.hostname, m.display_name || '', m.ip || ''].join(' ').toLowerCase();
+        if (text.indexOf(filters.search.toLowerCase()) < 0) return false;
+    }
+    return true;
+}
+
+function sortMachines(machines, sort) {
+    const desc = sort.charAt(0) === '-';
+    const key = SORT_KEYS[desc ? sort.slice(1) : sort];
+    return machines.sort(function(a, b) {
+        const x = key(a), y = key(b);
+        const order = x < y ? -1 : (x > y ? 1 : 0);
+        return desc ? -order : order;
+    });
+}
+
+function applyFilters() {
+    filters = {
+        search: document.getElementById('filter-search').value.trim(),
+        status: document.getElementById('filter-status').value,
+        group: document.getElementById('filter-group').value,
+        swarm_role: document.getElementById('filter-role').value,
+        sort: document.getElementById('filter-sort').value
+    };
+    renderAll();
+}
+
+function updateGroupOptions(machines) {
+    const select = document.getElementById('filter-group');
+    const groups = {};
+    machines.forEach(function(m) { groups[m.group || 'default'] = true; });
+    if (filters.group) groups[filters.group] = true;
+    select.innerHTML = '<option value="">' + t('ui.filter.all_groups') + '</option>' + Object.keys(groups).sort().map(function(g) {
+        return '<option value="' + escapeHtml(g) + '">' + escapeHtml(g) + '</option>';
+    }).join('');
+    select.value = filters.group;
+}
+
+function renderAll() {
+    const main = document.getElementById('main');
+    const all = Object.values(machinesById);
+    updateGroupOptions(all);
+    const machines = all.filter(matchesFilters);
+    renderStats(machines);
+
+    // Renderizar máquinas
+    if (all.length === 0) {
+        main.innerHTML = renderEmptyState();
+        return;
+    }
+    if (machines.length === 0) {
+        main.innerHTML = '<div class="empty-state"><h2>' + t('ui.filter.no_match') + '</h2></div>';
+        return;
+    }
+
+    // Com ordenação, uma lista única; sem ela, agrupado por grupo
+    if (filters.sort) {
+        main.innerHTML = '<div class="machines-grid">' + sortMachines(machines, filters.sort).map(renderMachine).join('') + '</div>';
+        return;
+    }
+
+    const groups = groupMachines(machines);
+    let html = '';
+
+    const sortedGroups = Object.keys(groups).sort();
+    sortedGroups.forEach(function(groupName) {
+        html += '<div class="group">' +
+            '<div class="group-header">' +
+                '<span>📁</span> ' + escapeHtml(groupName) + ' (' + groups[groupName].length + ')' +
+            '</div>' +
+            '<div class="machines-grid">';
+
+        groups[groupName].forEach(function(machine) {
+            html += renderMachine(machine);
+        });
+
+        html += '</div></div>';
+    });
+
+    main.innerHTML = html;
+}
+
+function updateTime() {
+    const now = new Date();
+    const time = now.toLocaleTimeString(LANG, { hour: '2-digit', minute: '2-digit' });
+    document.getElementById('update-time').textContent = t('ui.updated', time) + (live ? ' · ' + t('ui.live') : '');
+}
+
+// Atualizações em tempo real (/api/events); sem o stream, o dashboard volta a consultar a cada REFRESH_INTERVAL
+let eventSource = null;
+let live = false;
+let streamDropped = false;
+
+function connectEvents() {
+    if (!window.EventSource) return;
+    if (eventSource) eventSource.close();
+
+    eventSource = new EventSource('/api/events?' + filterParams().toString());
+    eventSource.onopen = function() {
+        // Ao reconectar, buscar a lista completa para recuperar eventos perdidos
+        if (streamDropped) fetchData();
+        streamDropped = false;
+        live = true;
+        updateTime();
+    };
+    eventSource.onerror = function() {
+        live = false;
+        streamDropped = true;
+        updateTime();
+        // O navegador reconecta sozinho, exceto se o servidor recusou o stream
+        if (eventSource.readyState === EventSource.CLOSED) {
+            setTimeout(connectEvents, REFRESH_INTERVAL);
+        }
+    };
+    eventSource.addEventListener('machine', function(e) {
+        patchMachine(JSON.parse(e.data));
+    });
+    eventSource.addEventListener('machine.removed', function(e) {
+        removeMachine(JSON.parse(e.data).id);
+    });
+    eventSource.addEventListener('status', function(e) {
+        const d = JSON.parse(e.data);
+        showToast(t('ui.toast.status', d.hostname, t('ui.status_name.' + d.to)), d.to);
+    });
+    eventSource.addEventListener('alert', function(e) {
+        const d = JSON.parse(e.data);
+        showToast(d.hostname + ': ' + d.message, 'alert');
+    });
+}
+
+// patchMachine atualiza só o card da máquina; mudanças de grupo ou máquinas novas redesenham a lista
+function patchMachine(machine) {
+    const old = machinesById[machine.id];
+    machinesById[machine.id] = machine;
+
+    const card = document.querySelector('[data-machine-id="' + machine.id + '"]');
+    const inPlace = card && old && !filters.sort && matchesFilters(old) && matchesFilters(machine) &&
+        (old.group || 'default') === (machine.group || 'default');
+    if (inPlace) {
+        card.outerHTML = renderMachine(machine);
+        renderStats(Object.values(machinesById).filter(matchesFilters));
+    } else {
+        renderAll();
+    }
+    updateTime();
+}
+
+function removeMachine(id) {
+    if (!machinesById[id]) return;
+    delete machinesById[id];
+    renderAll();
+    updateTime();
+}
+
+function showToast(text, kind) {
+    const toast = document.createElement('div');
+    toast.className = 'toast ' + kind;
+    toast.textContent = text;
+    document.getElementById('toasts').appendChild(toast);
+    setTimeout(function() { toast.remove(); }, 8000);
+}
+
+async function fetchData() {
+    try {
+        const response = await fetch('/api/machines?' + filterParams().toString());
+        if (response.status === 401) {
+            window.location.href = '/login';
+            return;
+        }
+        const data = await response.json();
+        const input = document.getElementById('selector-input');
+        input.classList.toggle('invalid', response.status === 400);
+        input.title = response.status === 400 ? data.message : '';
+        if (response.status === 400) return;
+        render(data);
+        updateTime();
+    } catch (error) {
+        console.error('Erro ao carregar dados:', error);
+        document.getElementById('main').innerHTML =
+            '<div class="empty-state"><h2>' + t('ui.error.load') + '</h2><p>' + escapeHtml(error.message) + '</p></div>';
+    }
+}
+
+let historyMachine = null;
+let historyArchived = false;
+let historyGroup = '';
+let historyHours = 24;
+let canEdit = false;
+let showArchived = false;
+let selector = '';
+let historyLabels = {};
+
+function filterParams() {
+    const params = new URLSearchParams();
+    if (showArchived) params.set('include_archived', 'true');
+    if (selector) params.set('selector', selector);
+    return params;
+}
+
+function applySelector() {
+    selector = document.getElementById('selector-input').value.trim();
+    fetchData();
+    connectEvents();
+}
+
+function formatLabels(labels) {
+    return Object.keys(labels || {}).sort().map(function(key) { return key + '=' + labels[key]; }).join(', ');
+}
+
+function parseLabels(text) {
+    const labels = {};
+    text.split(',').forEach(function(item) {
+        const i = item.indexOf('=');
+        if (item.trim() === '') return;
+        const key = (i < 0 ? item : item.slice(0, i)).trim();
+        labels[key] = i < 0 ? '' : item.slice(i + 1).trim();
+    });
+    return labels;
+}
+
+function toggleArchived() {
+    showArchived = !showArchived;
+    document.getElementById('archived-toggle').classList.toggle('active', showArchived);
+    fetchData();
+    connectEvents();
+}
+
+function openHistory(id) {
+    historyMachine = id;
+    document.getElementById('history-modal').classList.add('open');
+    setHistoryHours(24);
+}
+
+function closeHistory() {
+    historyMachine = null;
+    document.getElementById('history-modal').classList.remove('open');
+}
+
+function setHistoryHours(hours) {
+    historyHours = hours;
+    document.querySelectorAll('.modal-actions [data-hours]').forEach(function(btn) {
+        btn.classList.toggle('active', Number(btn.dataset.hours) === hours);
+    });
+    loadHistory();
+}
+
+function parseTime(s) {
+    return new Date(s.indexOf('T') > 0 ? s : s.replace(' ', 'T') + 'Z').getTime();
+}
+
+// renderChart desenha a média, o p95 e a faixa mínimo–máximo de uma métrica
+function renderChart(label, points, key, color) {
+    const W = 600, H = 140;
+    const t0 = parseTime(points[0].collected_at);
+    const t1 = parseTime(points[points.length - 1].collected_at);
+    const span = Math.max(t1 - t0, 1);
+    const x = function(t) { return ((t - t0) / span * W).toFixed(1); };
+    const y = function(v) { return (H - Math.min(Math.max(v, 0), 100) / 100 * H).toFixed(1); };
+
+    const upper = [], lower = [], avg = [], p95 = [];
+    points.forEach(function(p) {
+        const t = parseTime(p.collected_at);
+        const v = p[key + '_percent'] || 0;
+        const hi = p[key + '_max'] !== undefined ? p[key + '_max'] : v;
+        const lo = p[key + '_min'] !== undefined ? p[key + '_min'] : v;
+        upper.push(x(t) + ',' + y(hi));
+        lower.unshift(x(t) + ',' + y(lo));
+        avg.push(x(t) + ',' + y(v));
+        if (p[key + '_p95'] !== undefined) p95.push(x(t) + ',' + y(p[key + '_p95']));
+    });
+
+    const last = points[points.length - 1];
+    return '<div class="chart">' +
+        '<div class="chart-header"><span>' + label + '</span><span>' + (last[key + '_percent'] || 0).toFixed(1) + '%</span></div>' +
+        '<svg viewBox="0 0 ' + W + ' ' + H + '" preserveAspectRatio="none">' +
+            '<polygon points="' + upper.concat(lower).join(' ') + '" fill="' + color + '" fill-opacity="0.2" stroke="none"/>' +
+            (p95.length > 1 ? '<polyline points="' + p95.join(' ') + '" fill="none" stroke="' + color + '" stroke-width="1" stroke-dasharray="4 3" vector-effect="non-scaling-stroke"/>' : '') +
+            '<polyline points="' + avg.join(' ') + '" fill="none" stroke="' + color + '" stroke-width="2" vector-effect="non-scaling-stroke"/>' +
+        '</svg>' +
+    '</div>';
+}
+
+// formatForecast descreve a projeção do disco retornada por /api/machines/{id}
+function formatForecast(forecast) {
+    if (!forecast) return '';
+    const growth = (forecast.growth_percent_per_day >= 0 ? '+' : '') + forecast.growth_percent_per_day.toFixed(2) + '%';
+    if (!forecast.full_at) return t('ui.forecast.stable', growth);
+    return t('ui.forecast.full_in', growth, forecast.days_to_full.toFixed(1), new Date(forecast.full_at).toLocaleDateString());
+}
+
+// renderMachineAlerts lista os alertas mais recentes da máquina (anomalias, disco enchendo etc.)
+function renderMachineAlerts(alerts) {
+    const el = document.getElementById('machine-alerts');
+    if (alerts.length === 0) {
+        el.innerHTML = '';
+        return;
+    }
+    el.innerHTML = '<h3>' + t('ui.alerts.title') + '</h3><ul>' + alerts.map(function(a) {
+        return '<li><span>' + formatTime(a.created_at) + '</span> ' + escapeHtml(a.message) + '</li>';
+    }).join('') + '</ul>';
+}
+
+async function loadHistory() {
+    const id = historyMachine;
+    const body = document.getElementById('history-body');
+    body.innerHTML = '<div class="loading"><div class="spinner"></div></div>';
+
+    try {
+        const responses = await Promise.all([
+            fetch('/api/machines/' + id),
+            fetch('/api/machines/' + id + '/metrics?hours=' + historyHours),
+            fetch('/api/alerts?limit=10&machine_id=' + id)
+        ]);
+        const machine = await responses[0].json();
+        const data = await responses[1].json();
+        const alerts = await responses[2].json();
+        if (id !== historyMachine) return;
+
+        document.getElementById('history-title').textContent = machine.display_name || machine.hostname || t('ui.history.title');
+        document.getElementById('machine-notes').textContent = machine.notes || '';
+        document.getElementById('machine-forecast').textContent = formatForecast(machine.disk_forecast);
+        renderMachineActions(machine);
+        renderMachineAlerts(alerts.alerts || []);
+
+        // A API retorna do mais recente para o mais antigo
+        const points = (data.metrics || []).slice().reverse();
+        if (points.length === 0) {
+            body.innerHTML = '<div class="empty-state"><p>' + t('ui.history.empty') + '</p></div>';
+            return;
+        }
+
+        body.innerHTML =
+            renderChart('CPU', points, 'cpu', '#06b6d4') +
+            renderChart(t('ui.chart.memory'), points, 'memory', '#8b5cf6') +
+            renderChart(t('ui.chart.disk'), points, 'disk', '#f97316');
+    } catch (error) {
+        console.error('Erro ao carregar histórico:', error);
+        body.innerHTML = '<div class="empty-state"><p>' + t('ui.history.error') + '</p></div>';
+    }
+}
+
+let reportMonths = 6;
+
+function openReport() {
+    document.getElementById('report-modal').classList.add('open');
+    setReportMonths(reportMonths);
+}
+
+function closeReport() {
+    document.getElementById('report-modal').classList.remove('open');
+}
+
+function setReportMonths(months) {
+    reportMonths = months;
+    document.querySelectorAll('.modal-actions [data-months]').forEach(function(btn) {
+        btn.classList.toggle('active', Number(btn.dataset.months) === months);
+    });
+    loadReport();
+}
+
+// reportParams são os parâmetros do resumo mensal: do primeiro dia de reportMonths - 1 meses
+// atrás até agora, com os mesmos filtros do dashboard
+function reportParams() {
+    const now = new Date();
+    const start = new Date(now.getFullYear(), now.getMonth() - reportMonths + 1, 1);
+    const params = filterParams();
+    params.set('from', start.getFullYear() + '-' + String(start.getMonth() + 1).padStart(2, '0') + '-01');
+    params.set('interval', 'month');
+    return params;
+}
+
+function formatDuration(seconds) {
+    if (seconds < 3600) return t('ui.duration.minutes', Math.round(seconds / 60));
+    if (seconds < 86400) return t('ui.duration.hours', (seconds / 3600).toFixed(1));
+    return t('ui.duration.days', (seconds / 86400).toFixed(1));
+}
+
+// monthLabel usa o ano e o mês do início do período como o servidor os enviou (fuso do servidor)
+function monthLabel(from) {
+    const date = new Date(Number(from.slice(0, 4)), Number(from.slice(5, 7)) - 1, 1);
+    return date.toLocaleDateString(LANG, { month: 'short', year: 'numeric' });
+}
+
+// uptimeCell mostra a disponibilidade do período com as faixas da página de status (99,9% e 95%)
+function uptimeCell(period) {
+    if (period.uptime_percent === null) {
+        return '<td class="uptime-cell">–</td>';
+    }
+    const percent = period.uptime_percent;
+    const level = percent >= 99.9 ? 'up' : percent >= 95 ? 'degraded' : 'down';
+    const title = t('ui.report.cell_title', formatDuration(period.downtime_seconds), formatDuration(period.longest_downtime_seconds), period.incidents);
+    return '<td class="uptime-cell ' + level + '" title="' + escapeHtml(title) + '">' + percent.toFixed(2) + '%</td>';
+}
+
+function reportRow(className, name, item) {
+    return '<tr class="' + className + '"><td>' + escapeHtml(name) + '</td>' +
+        item.periods.map(uptimeCell).join('') + uptimeCell(item) + '</tr>';
+}
+
+function renderReport(data) {
+    let html = '<table class="report-table"><thead><tr><th></th>' +
+        data.periods.map(function(p) { return '<th>' + escapeHtml(monthLabel(p.from)) + '</th>'; }).join('') +
+        '<th>' + t('ui.report.total') + '</th></tr></thead><tbody>';
+
+    data.groups.forEach(function(group) {
+        html += reportRow('report-group', (group.group || 'default') + ' (' + group.machines + ')', group);
+        data.machines.forEach(function(m) {
+            if (m.group === group.group) html += reportRow('report-machine', m.display_name || m.hostname, m);
+        });
+    });
+    data.total.periods = data.periods;
+    html += reportRow('report-total', t('ui.report.all_machines'), data.total);
+    return html + '</tbody></table>';
+}
+
+async function loadReport() {
+    const months = reportMonths;
+    const params = reportParams();
+    const body = document.getElementById('report-body');
+    body.innerHTML = '<div class="loading"><div class="spinner"></div></div>';
+
+    const download = new URLSearchParams(params);
+    download.set('format', 'csv');
+    document.getElementById('report-csv').href = '/api/reports/uptime?' + download;
+    download.set('format', 'json');
+    download.set('download', 'true');
+    document.getElementById('report-json').href = '/api/reports/uptime?' + download;
+
+    try {
+        const response = await fetch('/api/reports/uptime?' + params);
+        if (!response.ok) throw new Error('HTTP ' + response.status);
+        const data = await response.json();
+        if (months !== reportMonths) return;
+
+        if (data.machines.length === 0) {
+            body.innerHTML = '<div class="empty-state"><p>' + t('ui.report.empty') + '</p></div>';
+            return;
+        }
+        body.innerHTML = renderReport(data);
+    } catch (error) {
+        console.error('Erro ao carregar disponibilidade:', error);
+        body.innerHTML = '<div class="empty-state"><p>' + t('ui.report.error') + '</p></div>';
+    }
+}
+
+// renderMachineActions preenche o formulário de edição (apenas editores e administradores)
+function renderMachineActions(machine) {
+    const actions = document.getElementById('machine-actions');
+    actions.style.display = canEdit ? 'block' : 'none';
+    historyArchived = !!machine.archived_at;
+    historyGroup = machine.group || '';
+    historyLabels = machine.labels || {};
+    document.getElementById('edit-labels').value = formatLabels(historyLabels);
+    document.getElementById('edit-display-name').value = machine.display_name || '';
+    document.getElementById('edit-group').value = machine.group || '';
+    document.getElementById('edit-notes').value = machine.notes || '';
+    document.getElementById('archive-btn').textContent = t(historyArchived ? 'ui.machine.unarchive' : 'ui.machine.archive');
+}
+
+async function updateMachine(changes) {
+    const response = await fetch('/api/machines/' + historyMachine, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(changes)
+    });
+    const data = await response.json();
+    if (!response.ok) {
+        alert(data.message || t('ui.error.update_machine'));
+        return null;
+    }
+    fetchData();
+    return data;
+}
+
+async function saveMachine(event) {
+    event.preventDefault();
+    const changes = {
+        display_name: document.getElementById('edit-display-name').value,
+        notes: document.getElementById('edit-notes').value
+    };
+    // Enviar o grupo só quando alterado: alterar o grupo o fixa contra o --group do agent
+    const group = document.getElementById('edit-group').value;
+    if (group !== historyGroup) changes.group = group;
+
+    // Enviar só os labels alterados (null remove); os demais continuam com a origem atual
+    const labels = parseLabels(document.getElementById('edit-labels').value);
+    const labelChanges = {};
+    Object.keys(labels).forEach(function(key) {
+        if (historyLabels[key] !== labels[key]) labelChanges[key] = labels[key];
+    });
+    Object.keys(historyLabels).forEach(function(key) {
+        if (!(key in labels)) labelChanges[key] = null;
+    });
+    if (Object.keys(labelChanges).length > 0) changes.labels = labelChanges;
+    if (await updateMachine(changes)) loadHistory();
+}
+
+async function toggleArchive() {
+    const archive = !historyArchived;
+    if (archive && !confirm(t('ui.confirm.archive'))) return;
+    if (await updateMachine({ archived: archive })) closeHistory();
+}
+
+async function deleteMachine() {
+    if (!confirm(t('ui.confirm.delete'))) return;
+    const response = await fetch('/api/machines/' + historyMachine, { method: 'DELETE' });
+    if (!response.ok) {
+        const data = await response.json();
+        alert(data.message || t('ui.error.delete_machine'));
+        return;
+    }
+    closeHistory();
+    fetchData();
+}
+
+// Visões salvas no servidor (/api/views); ?view=ID na URL abre uma visão
+let views = [];
+
+async function loadViews(selectID) {
+    try {
+        const response = await fetch('/api/views');
+        if (!response.ok) return;
+        const data = await response.json();
+        setViews(data.views, selectID);
+    } catch (error) {
+        console.error('Erro ao carregar visões:', error);
+    }
+}
+
+function setViews(list, selectID) {
+    views = list || [];
+    const select = document.getElementById('view-select');
+    select.innerHTML = '<option value="">' + t('ui.views.placeholder') + '</option>' + views.map(function(v) {
+        return '<option value="' + v.id + '">' + escapeHtml(v.shared ? t('ui.views.shared', v.name) : v.name) + '</option>';
+    }).join('');
+    if (selectID && views.some(function(v) { return String(v.id) === String(selectID); })) {
+        select.value = selectID;
+        selectView();
+    } else {
+        document.getElementById('view-delete').style.display = 'none';
+    }
+}
+
+function selectView() {
+    const id = document.getElementById('view-select').value;
+    const view = views.find(function(v) { return String(v.id) === id; });
+    document.getElementById('view-delete').style.display = view ? '' : 'none';
+
+    const url = new URL(window.location.href);
+    if (view) url.searchParams.set('view', id);
+    else url.searchParams.delete('view');
+    history.replaceState(null, '', url);
+    if (!view) return;
+
+    const f = view.filters || {};
+    document.getElementById('filter-search').value = f.search || '';
+    document.getElementById('filter-status').value = f.status || '';
+    filters.group = f.group || '';
+    updateGroupOptions(Object.values(machinesById));
+    document.getElementById('filter-role').value = f.swarm_role || '';
+    document.getElementById('filter-sort').value = f.sort || '';
+    applyFilters();
+
+    selector = f.selector || '';
+    document.getElementById('selector-input').value = selector;
+    showArchived = !!f.include_archived;
+    document.getElementById('archived-toggle').classList.toggle('active', showArchived);
+    fetchData();
+    connectEvents();
+}
+
+async function saveView() {
+    const current = views.find(function(v) { return String(v.id) === document.getElementById('view-select').value; });
+    const name = prompt(t('ui.views.name_prompt'), current ? current.name : '');
+    if (!name) return;
+    const shared = confirm(t('ui.views.share_confirm'));
+
+    const response = await fetch('/api/views', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            name: name,
+            shared: shared,
+            filters: Object.assign({}, filters, { selector: selector, include_archived: showArchived })
+        })
+    });
+    const data = await response.json();
+    if (!response.ok) {
+        alert(data.message || t('ui.error.save_view'));
+        return;
+    }
+    loadViews(data.id);
+}
+
+async function deleteView() {
+    const id = document.getElementById('view-select').value;
+    if (!id || !confirm(t('ui.views.delete_confirm'))) return;
+    const response = await fetch('/api/views/' + id, { method: 'DELETE' });
+    if (!response.ok) {
+        const data = await response.json();
+        alert(data.message || t('ui.error.delete_view'));
+        return;
+    }
+    document.getElementById('view-select').value = '';
+    selectView();
+    loadViews();
+}
+
+// Idioma: a preferência fica no usuário logado (PATCH /api/me) ou, sem login, no cookie lang
+let loggedIn = false;
+
+async function setLanguage(lang) {
+    document.cookie = 'lang=' + encodeURIComponent(lang) + '; path=/; max-age=31536000; SameSite=Lax';
+    if (loggedIn) {
+        try {
+            await fetch('/api/me', {
+                method: 'PATCH',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ language: lang })
+            });
+        } catch (error) {
+            console.error('Erro ao salvar idioma:', error);
+        }
+    }
+    window.location.reload();
+}
+
+function applyUser(me) {
+    canEdit = !!me.can_edit;
+    loggedIn = !!me.username;
+    if (me.username) {
+        document.getElementById('current-user').textContent = me.username;
+        document.getElementById('logout-form').style.display = 'flex';
+    }
+}
+
+// Inicializar com o estado renderizado pelo servidor (INITIAL_STATE), sem esperar a API
+document.getElementById('language-select').value = LANG;
+applyUser(INITIAL_STATE.me || {});
+render(INITIAL_STATE);
+updateTime();
+connectEvents();
+setViews(INITIAL_STATE.views, new URLSearchParams(window.location.search).get('view'));
+// Com o stream ativo, apenas redesenhar (tempos relativos); sem ele, consultar a API
+setInterval(function() {
+    if (live) renderAll();
+    else fetchData();
+}, REFRESH_INTERVAL);
@@ -0,0 +1,353 @@
+package i18n
+
+// en é o catálogo em inglês
+var en = map[string]string{
+	// Erros da API (códigos estáveis do campo "code")
+	"already_enrolled":            "Machine already enrolled; ask an administrator to rotate its credential",
+	"agent_not_found":             "Agent not found",
+	"api_key_not_found":           "API key not found",
+	"api_key_read_only":           "API keys are read-only",
+	"audit_query_failed":          "Failed to query the audit log",
+	"auth_check_failed":           "Failed to verify authentication",
+	"auth_required":               "Authentication required",
+	"cert_hostname_mismatch":      "hostname does not match the agent certificate",
+	"cert_uuid_mismatch":          "machine_uuid does not match the agent certificate",
+	"change_password_failed":      "Failed to change password",
+	"create_api_key_failed":       "Failed to create API key",
+	"create_user_failed":          "Failed to create user",
+	"credential_uuid_mismatch":    "machine_uuid does not match the agent credential",
+	"delete_machine_failed":       "Failed to delete machine",
+	"delete_threshold_failed":     "Failed to delete threshold",
+	"delete_user_failed":          "Failed to delete user",
+	"delete_view_failed":          "Failed to delete view",
+	"digest_disabled":             "Email digest disabled (set --smtp-host and --digest-to)",
+	"digest_failed":               "Failed to build digest",
+	"digest_send_failed":          "Failed to send digest email",
+	"empty_batch":                 "Empty batch",
+	"enroll_disabled":             "Agent enrollment is disabled",
+	"enroll_failed":               "Failed to enroll agent",
+	"forbidden_role":              "Insufficient permissions",
+	"forecast_failed":             "Failed to forecast disk usage",
+	"get_agent_failed":            "Failed to fetch agent",
+	"get_machine_failed":          "Failed to fetch machine",
+	"group_out_of_scope":          "Group outside your scope",
+	"history_failed":              "Failed to fetch history",
+	"invalid_badge_metric":        "metric must be cpu, memory, disk or uptime (got %q)",
+	"invalid_batch":               "Failed to decode batch (expected an array of payloads)",
+	"invalid_before":              "Invalid before",
+	"invalid_days_param":          "%s must be a whole number of days between %d and %d",
+	"invalid_gzip":                "Invalid gzip body",
+	"invalid_id":                  "Invalid ID",
+	"invalid_json":                "Failed to decode payload",
+	"invalid_language":            "Unsupported language: %q",
+	"invalid_limit":               "Invalid limit",
+	"invalid_machine_uuid":        "Invalid machine_uuid",
+	"invalid_payload":             "Invalid payload: %s",
+	"invalid_report_format":       "format must be json or csv (got %q)",
+	"invalid_report_interval":     "interval must be day or month (got %q)",
+	"invalid_report_range":        "from must be before to",
+	"invalid_report_time":         "%s must be in RFC3339 or YYYY-MM-DD",
+	"invalid_role":                "role must be admin, editor or viewer",
+	"invalid_selector":            "invalid selector: %q",
+	"invalid_time_param":          "%s must be in RFC3339",
+	"language_requires_user":      "The language preference is stored per user; without a login, use the lang cookie",
+	"last_admin":                  "Cannot remove the last administrator",
+	"list_agents_failed":          "Failed to list agents",
+	"list_alerts_failed":          "Failed to fetch alerts",
+	"list_api_keys_failed":        "Failed to list API keys",
+	"list_machines_failed":        "Failed to fetch machines",
+	"list_thresholds_failed":      "Failed to list thresholds",
+	"list_users_failed":           "Failed to list users",
+	"list_views_failed":           "Failed to list views",
+	"machine_id_required":         "Machine ID is required",
+	"machine_not_found":           "Machine not found",
+	"merge_failed":                "Failed to merge machines",
+	"merge_same_machine":          "Source and target must be different machines",
+	"merge_target_required":       `Provide the target machine ID in "into"`,
+	"name_required":               "name is required",
+	"origin_not_allowed":          "Origin not allowed",
+	"password_too_short":          "password must be at least %d characters",
+	"payload_too_large":           "Payload exceeds the %d-byte limit",
+	"rate_limited":                "Rate limit exceeded; try again in %ds",
+	"read_body_failed":            "Failed to read payload",
+	"replayed_request":            "Replayed request",
+	"revoke_agent_failed":         "Failed to revoke credential",
+	"revoke_api_key_failed":       "Failed to revoke API key",
+	"rotate_agent_failed":         "Failed to rotate credential",
+	"save_metrics_failed":         "Failed to save metrics",
+	"save_threshold_failed":       "Failed to save threshold",
+	"save_view_failed":            "Failed to save view",
+	"server_shutting_down":        "Server shutting down",
+	"signature_expired":           "Signature expired",
+	"signature_invalid":           "Invalid signature",
+	"signature_missing":           "Missing signature",
+	"signature_timestamp_invalid": "Invalid signature timestamp",
+	"stats_failed":                "Failed to fetch statistics",
+	"threshold_not_found":         "Threshold not found",
+	"threshold_out_of_scope":      "Threshold outside your scope (default thresholds require access to all groups)",
+	"too_many_report_periods":     "Period too long: at most %d intervals",
+	"streaming_unsupported":       "Streaming not supported",
+	"update_language_failed":      "Failed to change language",
+	"update_machine_failed":       "Failed to update machine",
+	"update_user_failed":          "Failed to update user",
+	"uptime_report_failed":        "Failed to compute availability",
+	"user_exists":                 "User already exists",
+	"username_required":           "username is required",
+	"user_not_found":              "User not found",
+	"verify_credential_failed":    "Failed to verify credential",
+	"verify_identity_failed":      "Failed to verify agent identity",
+	"view_not_found":              "View not found",
+
+	// Campos inválidos (códigos de "errors[].code")
+	"before_retention":   "is older than the retention period (%d days)",
+	"above_critical":     "greater than the critical threshold",
+	"control_chars":      "contains control characters",
+	"count_range":        "must be between 0 and %d (got %d)",
+	"empty":              "cannot be empty",
+	"group_and_machine":  "use group or machine_id, not both",
+	"in_future":          "is %.0fs in the future (maximum: %.0fs)",
+	"invalid_ip":         "invalid IP address",
+	"invalid_label_key":  "invalid key (letters, digits and . _ - /, up to %d characters)",
+	"invalid_metric":     "must be cpu, memory or disk (got %q)",
+	"invalid_sort":       "invalid sort field (got %q)",
+	"invalid_status":     "must be online, warning, critical or offline (got %q)",
+	"invalid_swarm_role": "must be none, manager or worker (got %q)",
+	"invalid_type":       "invalid type (got %s, expected %s)",
+	"invalid_uuid":       "must be a UUID",
+	"min_above_max":      "greater than %s_max",
+	"negative":           "cannot be negative",
+	"not_numeric":        "not a number",
+	"percent_range":      "must be between 0 and 100 (got %g)",
+	"required":           "is required",
+	"too_long":           "must be at most %d characters",
+	"too_many_labels":    "at most %d labels",
+	"unknown_field":      "unknown field",
+
+	// Avisos do stream de eventos
+	"alert.anomaly.cpu":       "CPU out of pattern: %.1f%% (expected %.1f%% ± %.1f)",
+	"alert.anomaly.disk":      "disk out of pattern: %.1f%% (expected %.1f%% ± %.1f)",
+	"alert.anomaly.memory":    "memory out of pattern: %.1f%% (expected %.1f%% ± %.1f)",
+	"alert.clock_drift":       "clock %.0fs off from the server",
+	"alert.disk_full":         "disk expected to fill up in %.1f days (%s)",
+	"alert.hostname_conflict": "hostname in use by another online machine",
+	"alert.rejected":          "payload rejected: %s",
+
+	// Dashboard, login e página de status (templates do dashboard e catálogo da função t() do JavaScript)
+	"ui.archived_toggle":              "Archived",
+	"ui.updating":                     "Updating...",
+	"ui.updated":                      "Updated: %s",
+	"ui.live":                         "live",
+	"ui.language":                     "Language",
+	"ui.logout":                       "Log out",
+	"ui.loading":                      "Loading...",
+	"ui.save":                         "Save",
+	"ui.containers":                   "Containers",
+	"ui.unknown_ip":                   "Unknown IP",
+	"ui.status.online":                "Online",
+	"ui.status.warning":               "Warning",
+	"ui.status.critical":              "Critical",
+	"ui.status.offline":               "Offline",
+	"ui.status_name.online":           "online",
+	"ui.status_name.warning":          "in warning",
+	"ui.status_name.critical":         "critical",
+	"ui.status_name.offline":          "offline",
+	"ui.toast.status":                 "%s is %s",
+	"ui.role.manager":                 "Manager",
+	"ui.role.worker":                  "Worker",
+	"ui.role.none":                    "No Swarm",
+	"ui.filter.search":                "Search hostname or IP",
+	"ui.filter.all_statuses":          "All statuses",
+	"ui.filter.all_groups":            "All groups",
+	"ui.filter.any_role":              "Any role",
+	"ui.filter.no_match":              "No machines match the filters",
+	"ui.sort.group":                   "Grouped by group",
+	"ui.sort.name":                    "Name",
+	"ui.sort.cpu":                     "Highest CPU",
+	"ui.sort.memory":                  "Highest memory",
+	"ui.sort.disk":                    "Highest disk",
+	"ui.sort.containers":              "Most containers",
+	"ui.sort.last_seen":               "Oldest last report",
+	"ui.views.placeholder":            "Saved views",
+	"ui.views.save":                   "Save view",
+	"ui.views.delete":                 "Delete view",
+	"ui.views.shared":                 "%s (shared)",
+	"ui.views.name_prompt":            "View name:",
+	"ui.views.share_confirm":          "Share this view with all users?",
+	"ui.views.delete_confirm":         "Delete this view?",
+	"ui.time.now":                     "Just now",
+	"ui.time.minutes":                 "%d min ago",
+	"ui.time.hours":                   "%dh ago",
+	"ui.time.days":                    "%dd ago",
+	"ui.badge.anomaly":                "Anomaly",
+	"ui.badge.anomaly_title":          "Out of pattern for this hour of the week: %s",
+	"ui.badge.archived":               "Archived",
+	"ui.badge.clock":                  "Clock",
+	"ui.badge.clock_title":            "Clock %ss off from the server",
+	"ui.badge.conflict":               "Conflict",
+	"ui.badge.conflict_title":         "Another online machine is using this hostname",
+	"ui.badge.rejected":               "Rejected",
+	"ui.badge.rejected_title":         "%d rejected; last: %s",
+	"ui.metric.memory":                "MEM",
+	"ui.metric.disk":                  "DISK",
+	"ui.docker.running":               "running",
+	"ui.docker.stopped":               "stopped",
+	"ui.empty.title":                  "No machines registered",
+	"ui.empty.hint":                   "Install the agent on your servers to start monitoring:",
+	"ui.empty.token":                  "YOUR_TOKEN",
+	"ui.empty.name":                   "my-server",
+	"ui.error.load":                   "Failed to load data",
+	"ui.error.update_machine":         "Failed to update machine",
+	"ui.error.delete_machine":         "Failed to delete machine",
+	"ui.error.save_view":              "Failed to save view",
+	"ui.error.delete_view":            "Failed to delete view",
+	"ui.history.title":                "History",
+	"ui.history.legend":               "Line: average · Dashed: p95 · Band: min–max across reports",
+	"ui.history.empty":                "No metrics in this period",
+	"ui.history.error":                "Failed to load history",
+	"ui.alerts.title":                 "Recent alerts",
+	"ui.forecast.full_in":             "Disk trend: %s/day · full in %s days (%s)",
+	"ui.forecast.stable":              "Disk trend: %s/day · not expected to fill up",
+	"ui.report.open":                  "Availability",
+	"ui.report.title":                 "Monthly availability",
+	"ui.report.legend":                "Online time over monitored time, from the gaps between reports; green ≥ 99.9%, yellow ≥ 95%",
+	"ui.report.total":                 "Period",
+	"ui.report.all_machines":          "All machines",
+	"ui.report.cell_title":            "Down: %s · longest gap: %s · outages: %d",
+	"ui.report.empty":                 "No machines in this period",
+	"ui.report.error":                 "Failed to load availability",
+	"ui.duration.minutes":             "%d min",
+	"ui.duration.hours":               "%s h",
+	"ui.duration.days":                "%s days",
+	"ui.chart.memory":                 "Memory",
+	"ui.chart.disk":                   "Disk",
+	"ui.machine.display_name":         "Display name",
+	"ui.machine.group":                "Group",
+	"ui.machine.notes":                "Notes",
+	"ui.machine.delete":               "Delete",
+	"ui.machine.archive":              "Archive",
+	"ui.machine.unarchive":            "Unarchive",
+	"ui.confirm.archive":              "Archive this machine? It will no longer appear on the dashboard, but its history is kept.",
+	"ui.confirm.delete":               "Delete this machine and all its history? This cannot be undone.",
+	"ui.login.title":                  "Sign in",
+	"ui.login.invalid":                "Invalid username or password",
+	"ui.login.username":               "Username",
+	"ui.login.password":               "Password",
+	"ui.login.submit":                 "Sign in",
+	"ui.status_page.heading":          "Status",
+	"ui.status_page.overall.up":       "All systems operational",
+	"ui.status_page.overall.degraded": "Some systems are degraded",
+	"ui.status_page.overall.down":     "Systems unavailable",
+	"ui.status_page.state.up":         "Operational",
+	"ui.status_page.state.degraded":   "Degraded",
+	"ui.status_page.state.down":       "Down",
+	"ui.status_page.machines":         "%d of %d machines operational",
+	"ui.status_page.uptime":           "%s%% uptime",
+	"ui.status_page.days_ago":         "90 days ago",
+	"ui.status_page.today":            "Today",
+	"ui.status_page.no_data":          "no data",
+	"ui.status_page.empty":            "No groups to show",
+	"ui.status_page.updated":          "Updated %s",
+
+	// Resumo por e-mail (templates/digest.html)
+	"digest.subject":           "Monitor Infra: %d machines, %d offline (%s to %s)",
+	"digest.title":             "Infrastructure digest",
+	"digest.period":            "From %s to %s",
+	"digest.machines":          "Machines",
+	"digest.machine":           "Machine",
+	"digest.group":             "Group",
+	"digest.uptime":            "Availability in the period: %s%%",
+	"digest.no_data":           "No reports in this period",
+	"digest.offline.title":     "Offline machines",
+	"digest.offline.empty":     "No machines offline",
+	"digest.offline.last_seen": "Last report",
+	"digest.top.title":         "Top consumers (period average)",
+	"digest.top.cpu":           "CPU",
+	"digest.top.memory":        "Memory",
+	"digest.top.disk":          "Disk",
+	"digest.forecast.title":    "Disks expected to fill within %d days",
+	"digest.forecast.empty":    "No disk is expected to fill in the next %d days",
+	"digest.forecast.current":  "Current usage",
+	"digest.forecast.trend":    "Trend",
+	"digest.forecast.growth":   "%+.2f%%/day",
+	"digest.forecast.full_at":  "Full on",
+	"digest.alerts.title":      "Alerts fired",
+	"digest.alerts.empty":      "No alerts in this period",
+	"digest.alerts.alert":      "Alert",
+	"digest.alerts.count":      "Occurrences",
+	"digest.alerts.last":       "Last",
+	"digest.alerts.became":     "became %s",
+	"digest.alerts.more":       "and %d more alerts",
+	"digest.footer":            "Sent by Monitor Infra. Archived machines are not included.",
+
+	// Logs do agent
+	"agent.labels_env_invalid":   "Invalid LABELS: %v",
+	"agent.server_required":      "Error: server URL is required (--server or SERVER_URL)",
+	"agent.hostname_failed":      "Failed to get hostname: %v",
+	"agent.error":                "Error: %v",
+	"agent.warning":              "Warning: %v",
+	"agent.identity_not_saved":   "Warning: %v (identity not saved)",
+	"agent.enroll_failed":        "Warning: agent enrollment failed: %v",
+	"agent.enrolled":             "Agent enrolled; credential saved to %s",
+	"agent.starting":             "Monitor-Infra Agent v%s starting...",
+	"agent.server":               "Server: %s",
+	"agent.machine":              "Machine: %s (group: %s, id: %s)",
+	"agent.labels":               "Labels: %s",
+	"agent.interval":             "Interval: %d minutes",
+	"agent.sampling":             "Sampling: every %d seconds",
+	"agent.spool_disabled":       "Warning: spool disabled: %v",
+	"agent.spool_pending":        "Spool: %d collections waiting to be sent",
+	"agent.spool_deferred":       "Spool: resend postponed: %v",
+	"agent.spool_resent":         "Spool: %d collections resent",
+	"agent.spool_full":           "Warning: spool full (%d), discarding oldest payload: %s",
+	"agent.first_collect":        "Sending first collection...",
+	"agent.first_collect_failed": "Warning: first collection failed: %v",
+	"agent.first_collect_sent":   "First collection sent successfully!",
+	"agent.running":              "Agent running. Next collection in %d minutes...",
+	"agent.collecting":           "Starting collection...",
+	"agent.collect_failed":       "Collection failed: %v",
+	"agent.next_collect":         "Next collection in %d minutes...",
+	"agent.sent":                 "Metrics sent successfully!",
+	"agent.sample_failed":        "Warning: sampling failed: %v",
+	"agent.discarded":            "Warning: %v (discarded)",
+	"agent.payload_rejected":     "Warning: payload from %s rejected by the server (%v), discarding",
+	"agent.batch_rejected":       "Warning: server rejected the batch of %d collections, resending one by one",
+	"agent.rate_limited":         "Warning: server rate limit reached, waiting %s",
+	"agent.signal":               "Signal received: %v. Shutting down...",
+
+	// Erros do agent (exibidos nos logs)
+	"agent.err.state_dir":              "failed to create state directory: %v",
+	"agent.err.credential_write":       "failed to write credential: %v",
+	"agent.err.enroll_state_dir":       "enrollment requires --state-dir to store the credential",
+	"agent.err.enroll_encode":          "failed to encode enrollment: %v",
+	"agent.err.request_create":         "failed to create request: %v",
+	"agent.err.enroll_send":            "failed to send enrollment: %v",
+	"agent.err.enroll_refused_message": "enrollment refused (status %d): %s",
+	"agent.err.enroll_refused":         "enrollment refused (status %d)",
+	"agent.err.enroll_no_credential":   "server returned no credential",
+	"agent.err.identity_write":         "failed to write machine identity: %v",
+	"agent.err.identity_generate":      "failed to generate machine identity: %v",
+	"agent.err.label_invalid":          "invalid label %q (use key=value)",
+	"agent.err.collect":                "failed to collect metrics: %v",
+	"agent.err.spool_pending":          "%v (%d collections pending in the spool)",
+	"agent.err.payload_encode":         "failed to encode payload: %v",
+	"agent.err.batch_encode":           "failed to encode batch: %v",
+	"agent.err.batch_compress":         "failed to compress batch: %v",
+	"agent.err.batch_response":         "failed to read batch response: %v",
+	"agent.err.send_deferred":          "sending postponed for %s (server rate limit)",
+	"agent.err.request_send":           "failed to send request: %v",
+	"agent.err.nonce":                  "failed to generate nonce: %v",
+	"agent.err.spool_dir":              "failed to create spool directory: %v",
+	"agent.err.spool_write":            "failed to write payload to the spool: %v",
+	"agent.err.spool_read":             "failed to read spool: %v",
+	"agent.err.spool_load":             "failed to read payload from the spool: %v",
+	"agent.err.spool_corrupt":          "corrupted payload in the spool (%s): %v",
+	"agent.err.spool_remove":           "failed to remove payload from the spool: %v",
+	"agent.err.ca_read":                "failed to read CA: %v",
+	"agent.err.ca_invalid":             "no valid certificate in %s",
+	"agent.err.client_cert_pair":       "--client-cert and --client-key must be set together",
+	"agent.err.client_cert_load":       "failed to load client certificate: %v",
+	"agent.err.batch_unsupported":      "server does not support batch sending",
+	"agent.err.batch_too_large":        "batch exceeds the server maximum size",
+	"agent.err.batch_rejected":         "server rejected the batch",
+	"agent.err.http_status":            "server returned status %d",
+}
@@ -0,0 +1,110 @@
+// Package i18n traduz as mensagens do dashboard, da API, do resumo por e-mail e do agent.
+//
+// As mensagens ficam em catálogos por idioma, indexados por chave. Códigos de erro da API
+// e dos campos inválidos são as próprias chaves (ex.: "machine_not_found"); textos do
+// dashboard usam o prefixo "ui.", os do resumo por e-mail, "digest." e logs do agent, "agent.".
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Idiomas suportados
+const (
+	PtBR = "pt-BR"
+	En   = "en"
+
+	// Default é usado quando o idioma pedido não é suportado e como fallback de chaves sem tradução
+	Default = PtBR
+)
+
+// catalogs guarda as mensagens de cada idioma
+var catalogs = map[string]map[string]string{
+	PtBR: ptBR,
+	En:   en,
+}
+
+// Supported retorna os idiomas suportados
+func Supported() []string {
+	return []string{PtBR, En}
+}
+
+// T retorna a mensagem da chave no idioma, formatada com args (fmt.Sprintf).
+// Sem tradução no idioma, usa o idioma padrão; sem nenhuma, a própria chave.
+func T(lang, key string, args ...interface{}) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[Default][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Messages retorna as mensagens do idioma com o prefixo informado, completando com o idioma padrão
+func Messages(lang, prefix string) map[string]string {
+	messages := make(map[string]string)
+	for _, l := range []string{Default, lang} {
+		for key, msg := range catalogs[l] {
+			if strings.HasPrefix(key, prefix) {
+				messages[key] = msg
+			}
+		}
+	}
+	return messages
+}
+
+// Match retorna o idioma suportado correspondente à tag (ex.: "en-US" → "en", "pt" → "pt-BR"),
+// ou "" se não houver
+func Match(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case tag == "pt" || strings.HasPrefix(tag, "pt-"):
+		return PtBR
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return En
+	}
+	return ""
+}
+
+// Negotiate escolhe o idioma a partir de um header Accept-Language, respeitando os pesos (q)
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if lang := Match(tag); lang != "" && q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// FromLocale converte um locale POSIX (ex.: LANG=en_US.UTF-8) em idioma suportado,
+// ou "" se não houver
+func FromLocale(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	return Match(strings.ReplaceAll(locale, "_", "-"))
+}
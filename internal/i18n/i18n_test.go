@@ -0,0 +1,105 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+// verbPattern encontra os verbos de formatação de uma mensagem (exceto %%)
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z]`)
+
+func TestCatalogsMatch(t *testing.T) {
+	for _, lang := range Supported() {
+		for key, msg := range catalogs[Default] {
+			translated, ok := catalogs[lang][key]
+			if !ok {
+				t.Errorf("%s: chave %q sem tradução", lang, key)
+				continue
+			}
+			if got, want := len(verbPattern.FindAllString(translated, -1)), len(verbPattern.FindAllString(msg, -1)); got != want {
+				t.Errorf("%s: %q tem %d argumentos, o padrão tem %d", lang, key, got, want)
+			}
+		}
+		for key := range catalogs[lang] {
+			if _, ok := catalogs[Default][key]; !ok {
+				t.Errorf("%s: chave %q ausente no idioma padrão", lang, key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"inglês", En, "auth_required", nil, "Authentication required"},
+		{"português", PtBR, "required", nil, "é obrigatório"},
+		{"idioma não suportado usa o padrão", "fr", "required", nil, "é obrigatório"},
+		{"chave desconhecida", En, "chave.inexistente", nil, "chave.inexistente"},
+		{"com argumentos", En, "too_long", []interface{}{64}, "must be at most 64 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("T = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", Default},
+		{"en-US,en;q=0.9", En},
+		{"fr-FR,en;q=0.5", En},
+		{"en;q=0.3,pt-BR;q=0.8", PtBR},
+		{"pt", PtBR},
+		{"en;q=0", Default},
+		{"de, fr", Default},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, esperado %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestFromLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en_US.UTF-8", En},
+		{"pt_BR.UTF-8", PtBR},
+		{"pt_PT", PtBR},
+		{"C", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := FromLocale(tt.locale); got != tt.want {
+			t.Errorf("FromLocale(%q) = %q, esperado %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	messages := Messages(En, "ui.")
+	if len(messages) == 0 {
+		t.Fatal("nenhuma mensagem com o prefixo ui.")
+	}
+	for key := range messages {
+		if key[:3] != "ui." {
+			t.Errorf("chave %q fora do prefixo", key)
+		}
+	}
+}
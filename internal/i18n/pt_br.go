@@ -0,0 +1,353 @@
+package i18n
+
+// ptBR é o catálogo em português (idioma padrão)
+var ptBR = map[string]string{
+	// Erros da API (códigos estáveis do campo "code")
+	"already_enrolled":            "Máquina já inscrita; peça ao administrador para rotacionar a credencial",
+	"agent_not_found":             "Agent não encontrado",
+	"api_key_not_found":           "Chave de API não encontrada",
+	"api_key_read_only":           "Chaves de API são somente leitura",
+	"audit_query_failed":          "Erro ao buscar auditoria",
+	"auth_check_failed":           "Erro ao verificar autenticação",
+	"auth_required":               "Autenticação necessária",
+	"cert_hostname_mismatch":      "hostname não corresponde ao certificado do agent",
+	"cert_uuid_mismatch":          "machine_uuid não corresponde ao certificado do agent",
+	"change_password_failed":      "Erro ao trocar senha",
+	"create_api_key_failed":       "Erro ao criar chave de API",
+	"create_user_failed":          "Erro ao criar usuário",
+	"credential_uuid_mismatch":    "machine_uuid não corresponde à credencial do agent",
+	"delete_machine_failed":       "Erro ao remover máquina",
+	"delete_threshold_failed":     "Erro ao remover limite",
+	"delete_user_failed":          "Erro ao remover usuário",
+	"delete_view_failed":          "Erro ao remover visão",
+	"digest_disabled":             "Resumo por e-mail desativado (configure --smtp-host e --digest-to)",
+	"digest_failed":               "Erro ao montar resumo",
+	"digest_send_failed":          "Erro ao enviar resumo por e-mail",
+	"empty_batch":                 "Lote vazio",
+	"enroll_disabled":             "Inscrição de agents desativada",
+	"enroll_failed":               "Erro ao inscrever agent",
+	"forbidden_role":              "Permissão insuficiente",
+	"forecast_failed":             "Erro ao projetar uso de disco",
+	"get_agent_failed":            "Erro ao buscar agent",
+	"get_machine_failed":          "Erro ao buscar máquina",
+	"group_out_of_scope":          "Grupo fora do seu escopo",
+	"history_failed":              "Erro ao buscar histórico",
+	"invalid_badge_metric":        "metric deve ser cpu, memory, disk ou uptime (recebido %q)",
+	"invalid_batch":               "Erro ao decodificar lote (esperado um array de payloads)",
+	"invalid_before":              "before inválido",
+	"invalid_days_param":          "%s deve ser um número inteiro de dias entre %d e %d",
+	"invalid_gzip":                "Corpo gzip inválido",
+	"invalid_id":                  "ID inválido",
+	"invalid_json":                "Erro ao decodificar payload",
+	"invalid_language":            "Idioma não suportado: %q",
+	"invalid_limit":               "limit inválido",
+	"invalid_machine_uuid":        "machine_uuid inválido",
+	"invalid_payload":             "Payload inválido: %s",
+	"invalid_report_format":       "format deve ser json ou csv (recebido %q)",
+	"invalid_report_interval":     "interval deve ser day ou month (recebido %q)",
+	"invalid_report_range":        "from deve ser anterior a to",
+	"invalid_report_time":         "%s deve estar em RFC3339 ou AAAA-MM-DD",
+	"invalid_role":                "role deve ser admin, editor ou viewer",
+	"invalid_selector":            "seletor inválido: %q",
+	"invalid_time_param":          "%s deve estar em RFC3339",
+	"language_requires_user":      "A preferência de idioma é guardada por usuário; sem login, use o cookie lang",
+	"last_admin":                  "Não é possível remover o último administrador",
+	"list_agents_failed":          "Erro ao listar agents",
+	"list_alerts_failed":          "Erro ao buscar alertas",
+	"list_api_keys_failed":        "Erro ao listar chaves de API",
+	"list_machines_failed":        "Erro ao buscar máquinas",
+	"list_thresholds_failed":      "Erro ao listar limites",
+	"list_users_failed":           "Erro ao listar usuários",
+	"list_views_failed":           "Erro ao listar visões",
+	"machine_id_required":         "ID da máquina é obrigatório",
+	"machine_not_found":           "Máquina não encontrada",
+	"merge_failed":                "Erro ao mesclar máquinas",
+	"merge_same_machine":          "Origem e destino devem ser máquinas diferentes",
+	"merge_target_required":       `Informe o ID da máquina de destino em "into"`,
+	"name_required":               "name é obrigatório",
+	"origin_not_allowed":          "Origem não permitida",
+	"password_too_short":          "password deve ter pelo menos %d caracteres",
+	"payload_too_large":           "Payload excede o limite de %d bytes",
+	"rate_limited":                "Limite de envios excedido; tente novamente em %ds",
+	"read_body_failed":            "Erro ao ler payload",
+	"replayed_request":            "Requisição repetida",
+	"revoke_agent_failed":         "Erro ao revogar credencial",
+	"revoke_api_key_failed":       "Erro ao revogar chave de API",
+	"rotate_agent_failed":         "Erro ao rotacionar credencial",
+	"save_metrics_failed":         "Erro ao salvar métricas",
+	"save_threshold_failed":       "Erro ao salvar limite",
+	"save_view_failed":            "Erro ao salvar visão",
+	"server_shutting_down":        "Servidor encerrando",
+	"signature_expired":           "Assinatura expirada",
+	"signature_invalid":           "Assinatura inválida",
+	"signature_missing":           "Assinatura ausente",
+	"signature_timestamp_invalid": "Timestamp da assinatura inválido",
+	"stats_failed":                "Erro ao buscar estatísticas",
+	"threshold_not_found":         "Limite não encontrado",
+	"threshold_out_of_scope":      "Limite fora do seu escopo (limites padrão exigem acesso a todos os grupos)",
+	"too_many_report_periods":     "Período longo demais: no máximo %d intervalos",
+	"streaming_unsupported":       "Streaming não suportado",
+	"update_language_failed":      "Erro ao alterar idioma",
+	"update_machine_failed":       "Erro ao atualizar máquina",
+	"update_user_failed":          "Erro ao alterar usuário",
+	"uptime_report_failed":        "Erro ao calcular disponibilidade",
+	"user_exists":                 "Usuário já existe",
+	"username_required":           "username é obrigatório",
+	"user_not_found":              "Usuário não encontrado",
+	"verify_credential_failed":    "Erro ao verificar credencial",
+	"verify_identity_failed":      "Erro ao verificar identidade do agent",
+	"view_not_found":              "Visão não encontrada",
+
+	// Campos inválidos (códigos de "errors[].code")
+	"before_retention":   "é anterior ao período de retenção (%d dias)",
+	"above_critical":     "maior que o limite crítico",
+	"control_chars":      "contém caracteres de controle",
+	"count_range":        "deve estar entre 0 e %d (recebido %d)",
+	"empty":              "não pode ser vazio",
+	"group_and_machine":  "informe group ou machine_id, não os dois",
+	"in_future":          "está %.0fs no futuro (máximo: %.0fs)",
+	"invalid_ip":         "endereço IP inválido",
+	"invalid_label_key":  "chave inválida (letras, números e . _ - /, até %d caracteres)",
+	"invalid_metric":     "deve ser cpu, memory ou disk (recebido %q)",
+	"invalid_sort":       "campo de ordenação inválido (recebido %q)",
+	"invalid_status":     "deve ser online, warning, critical ou offline (recebido %q)",
+	"invalid_swarm_role": "deve ser none, manager ou worker (recebido %q)",
+	"invalid_type":       "tipo inválido (recebido %s, esperado %s)",
+	"invalid_uuid":       "deve ser um UUID",
+	"min_above_max":      "maior que %s_max",
+	"negative":           "não pode ser negativo",
+	"not_numeric":        "valor não numérico",
+	"percent_range":      "deve estar entre 0 e 100 (recebido %g)",
+	"required":           "é obrigatório",
+	"too_long":           "deve ter no máximo %d caracteres",
+	"too_many_labels":    "máximo de %d labels",
+	"unknown_field":      "campo desconhecido",
+
+	// Avisos do stream de eventos
+	"alert.anomaly.cpu":       "CPU fora do padrão: %.1f%% (esperado %.1f%% ± %.1f)",
+	"alert.anomaly.disk":      "disco fora do padrão: %.1f%% (esperado %.1f%% ± %.1f)",
+	"alert.anomaly.memory":    "memória fora do padrão: %.1f%% (esperado %.1f%% ± %.1f)",
+	"alert.clock_drift":       "relógio %.0fs fora do servidor",
+	"alert.disk_full":         "disco deve encher em %.1f dias (%s)",
+	"alert.hostname_conflict": "hostname em uso por outra máquina online",
+	"alert.rejected":          "payload recusado: %s",
+
+	// Dashboard, login e página de status (templates do dashboard e catálogo da função t() do JavaScript)
+	"ui.archived_toggle":              "Arquivadas",
+	"ui.updating":                     "Atualizando...",
+	"ui.updated":                      "Atualizado: %s",
+	"ui.live":                         "ao vivo",
+	"ui.language":                     "Idioma",
+	"ui.logout":                       "Sair",
+	"ui.loading":                      "Carregando...",
+	"ui.save":                         "Salvar",
+	"ui.containers":                   "Containers",
+	"ui.unknown_ip":                   "IP desconhecido",
+	"ui.status.online":                "Online",
+	"ui.status.warning":               "Atenção",
+	"ui.status.critical":              "Crítico",
+	"ui.status.offline":               "Offline",
+	"ui.status_name.online":           "online",
+	"ui.status_name.warning":          "em atenção",
+	"ui.status_name.critical":         "crítica",
+	"ui.status_name.offline":          "offline",
+	"ui.toast.status":                 "%s está %s",
+	"ui.role.manager":                 "Manager",
+	"ui.role.worker":                  "Worker",
+	"ui.role.none":                    "Sem Swarm",
+	"ui.filter.search":                "Buscar hostname ou IP",
+	"ui.filter.all_statuses":          "Todos os estados",
+	"ui.filter.all_groups":            "Todos os grupos",
+	"ui.filter.any_role":              "Qualquer papel",
+	"ui.filter.no_match":              "Nenhuma máquina corresponde aos filtros",
+	"ui.sort.group":                   "Agrupar por grupo",
+	"ui.sort.name":                    "Nome",
+	"ui.sort.cpu":                     "Maior CPU",
+	"ui.sort.memory":                  "Maior memória",
+	"ui.sort.disk":                    "Maior disco",
+	"ui.sort.containers":              "Mais containers",
+	"ui.sort.last_seen":               "Último relatório mais antigo",
+	"ui.views.placeholder":            "Visões salvas",
+	"ui.views.save":                   "Salvar visão",
+	"ui.views.delete":                 "Excluir visão",
+	"ui.views.shared":                 "%s (compartilhada)",
+	"ui.views.name_prompt":            "Nome da visão:",
+	"ui.views.share_confirm":          "Compartilhar esta visão com todos os usuários?",
+	"ui.views.delete_confirm":         "Excluir esta visão?",
+	"ui.time.now":                     "Agora",
+	"ui.time.minutes":                 "Há %d min",
+	"ui.time.hours":                   "Há %dh",
+	"ui.time.days":                    "Há %dd",
+	"ui.badge.anomaly":                "Anomalia",
+	"ui.badge.anomaly_title":          "Fora do padrão desta hora da semana: %s",
+	"ui.badge.archived":               "Arquivada",
+	"ui.badge.clock":                  "Relógio",
+	"ui.badge.clock_title":            "Relógio %ss em relação ao servidor",
+	"ui.badge.conflict":               "Conflito",
+	"ui.badge.conflict_title":         "Outra máquina online está usando este hostname",
+	"ui.badge.rejected":               "Recusados",
+	"ui.badge.rejected_title":         "%d recusado(s); último: %s",
+	"ui.metric.memory":                "MEM",
+	"ui.metric.disk":                  "DISCO",
+	"ui.docker.running":               "rodando",
+	"ui.docker.stopped":               "parados",
+	"ui.empty.title":                  "Nenhuma máquina cadastrada",
+	"ui.empty.hint":                   "Instale o agent em suas VPS para começar o monitoramento:",
+	"ui.empty.token":                  "SEU_TOKEN",
+	"ui.empty.name":                   "minha-vps",
+	"ui.error.load":                   "Erro ao carregar dados",
+	"ui.error.update_machine":         "Erro ao atualizar máquina",
+	"ui.error.delete_machine":         "Erro ao remover máquina",
+	"ui.error.save_view":              "Erro ao salvar visão",
+	"ui.error.delete_view":            "Erro ao excluir visão",
+	"ui.history.title":                "Histórico",
+	"ui.history.legend":               "Linha: média · Tracejado: p95 · Faixa: mínimo–máximo entre relatórios",
+	"ui.history.empty":                "Sem métricas no período",
+	"ui.history.error":                "Erro ao carregar histórico",
+	"ui.alerts.title":                 "Alertas recentes",
+	"ui.forecast.full_in":             "Tendência do disco: %s/dia · cheio em %s dias (%s)",
+	"ui.forecast.stable":              "Tendência do disco: %s/dia · sem previsão de encher",
+	"ui.report.open":                  "Disponibilidade",
+	"ui.report.title":                 "Disponibilidade mensal",
+	"ui.report.legend":                "Tempo online sobre o tempo monitorado, a partir das lacunas entre relatórios; verde ≥ 99,9%, amarelo ≥ 95%",
+	"ui.report.total":                 "Período",
+	"ui.report.all_machines":          "Todas as máquinas",
+	"ui.report.cell_title":            "Indisponível: %s · maior lacuna: %s · quedas: %d",
+	"ui.report.empty":                 "Nenhuma máquina no período",
+	"ui.report.error":                 "Erro ao carregar disponibilidade",
+	"ui.duration.minutes":             "%d min",
+	"ui.duration.hours":               "%s h",
+	"ui.duration.days":                "%s dias",
+	"ui.chart.memory":                 "Memória",
+	"ui.chart.disk":                   "Disco",
+	"ui.machine.display_name":         "Nome de exibição",
+	"ui.machine.group":                "Grupo",
+	"ui.machine.notes":                "Notas",
+	"ui.machine.delete":               "Remover",
+	"ui.machine.archive":              "Arquivar",
+	"ui.machine.unarchive":            "Desarquivar",
+	"ui.confirm.archive":              "Arquivar esta máquina? Ela deixa de aparecer no dashboard, mas o histórico é mantido.",
+	"ui.confirm.delete":               "Remover esta máquina e todo o seu histórico? Esta ação não pode ser desfeita.",
+	"ui.login.title":                  "Entrar",
+	"ui.login.invalid":                "Usuário ou senha inválidos",
+	"ui.login.username":               "Usuário",
+	"ui.login.password":               "Senha",
+	"ui.login.submit":                 "Entrar",
+	"ui.status_page.heading":          "Status",
+	"ui.status_page.overall.up":       "Todos os sistemas operacionais",
+	"ui.status_page.overall.degraded": "Alguns sistemas com problemas",
+	"ui.status_page.overall.down":     "Sistemas indisponíveis",
+	"ui.status_page.state.up":         "Operacional",
+	"ui.status_page.state.degraded":   "Degradado",
+	"ui.status_page.state.down":       "Indisponível",
+	"ui.status_page.machines":         "%d de %d máquinas operacionais",
+	"ui.status_page.uptime":           "%s%% de disponibilidade",
+	"ui.status_page.days_ago":         "90 dias atrás",
+	"ui.status_page.today":            "Hoje",
+	"ui.status_page.no_data":          "sem dados",
+	"ui.status_page.empty":            "Nenhum grupo para exibir",
+	"ui.status_page.updated":          "Atualizado em %s",
+
+	// Resumo por e-mail (templates/digest.html)
+	"digest.subject":           "Monitor Infra: %d máquinas, %d offline (%s a %s)",
+	"digest.title":             "Resumo da infraestrutura",
+	"digest.period":            "De %s a %s",
+	"digest.machines":          "Máquinas",
+	"digest.machine":           "Máquina",
+	"digest.group":             "Grupo",
+	"digest.uptime":            "Disponibilidade no período: %s%%",
+	"digest.no_data":           "Sem relatórios no período",
+	"digest.offline.title":     "Máquinas offline",
+	"digest.offline.empty":     "Nenhuma máquina offline",
+	"digest.offline.last_seen": "Último relatório",
+	"digest.top.title":         "Maiores consumidores (média no período)",
+	"digest.top.cpu":           "CPU",
+	"digest.top.memory":        "Memória",
+	"digest.top.disk":          "Disco",
+	"digest.forecast.title":    "Discos que devem encher em até %d dias",
+	"digest.forecast.empty":    "Nenhum disco deve encher nos próximos %d dias",
+	"digest.forecast.current":  "Uso atual",
+	"digest.forecast.trend":    "Tendência",
+	"digest.forecast.growth":   "%+.2f%%/dia",
+	"digest.forecast.full_at":  "Cheio em",
+	"digest.alerts.title":      "Alertas disparados",
+	"digest.alerts.empty":      "Nenhum alerta no período",
+	"digest.alerts.alert":      "Alerta",
+	"digest.alerts.count":      "Ocorrências",
+	"digest.alerts.last":       "Última",
+	"digest.alerts.became":     "ficou %s",
+	"digest.alerts.more":       "e mais %d alertas",
+	"digest.footer":            "Enviado pelo Monitor Infra. Máquinas arquivadas não entram no resumo.",
+
+	// Logs do agent
+	"agent.labels_env_invalid":   "Erro em LABELS: %v",
+	"agent.server_required":      "Erro: URL do servidor é obrigatória (--server ou SERVER_URL)",
+	"agent.hostname_failed":      "Erro ao obter hostname: %v",
+	"agent.error":                "Erro: %v",
+	"agent.warning":              "Aviso: %v",
+	"agent.identity_not_saved":   "Aviso: %v (identidade não persistida)",
+	"agent.enroll_failed":        "Aviso: falha na inscrição do agent: %v",
+	"agent.enrolled":             "Agent inscrito; credencial salva em %s",
+	"agent.starting":             "Monitor-Infra Agent v%s iniciando...",
+	"agent.server":               "Servidor: %s",
+	"agent.machine":              "Máquina: %s (grupo: %s, id: %s)",
+	"agent.labels":               "Labels: %s",
+	"agent.interval":             "Intervalo: %d minutos",
+	"agent.sampling":             "Amostragem: a cada %d segundos",
+	"agent.spool_disabled":       "Aviso: spool desativado: %v",
+	"agent.spool_pending":        "Spool: %d coletas pendentes de envio",
+	"agent.spool_deferred":       "Spool: reenvio adiado: %v",
+	"agent.spool_resent":         "Spool: %d coletas reenviadas",
+	"agent.spool_full":           "Aviso: spool cheio (%d), descartando payload mais antigo: %s",
+	"agent.first_collect":        "Enviando primeira coleta...",
+	"agent.first_collect_failed": "Aviso: falha na primeira coleta: %v",
+	"agent.first_collect_sent":   "Primeira coleta enviada com sucesso!",
+	"agent.running":              "Agent rodando. Próxima coleta em %d minutos...",
+	"agent.collecting":           "Iniciando coleta...",
+	"agent.collect_failed":       "Erro na coleta: %v",
+	"agent.next_collect":         "Próxima coleta em %d minutos...",
+	"agent.sent":                 "Métricas enviadas com sucesso!",
+	"agent.sample_failed":        "Aviso: falha na amostragem: %v",
+	"agent.discarded":            "Aviso: %v (descartado)",
+	"agent.payload_rejected":     "Aviso: payload de %s rejeitado pelo servidor (%v), descartando",
+	"agent.batch_rejected":       "Aviso: servidor recusou o lote de %d coletas, reenviando uma a uma",
+	"agent.rate_limited":         "Aviso: limite de envios do servidor atingido, aguardando %s",
+	"agent.signal":               "Sinal recebido: %v. Encerrando...",
+
+	// Erros do agent (exibidos nos logs)
+	"agent.err.state_dir":              "erro ao criar diretório de estado: %v",
+	"agent.err.credential_write":       "erro ao gravar credencial: %v",
+	"agent.err.enroll_state_dir":       "inscrição requer --state-dir para guardar a credencial",
+	"agent.err.enroll_encode":          "erro ao serializar inscrição: %v",
+	"agent.err.request_create":         "erro ao criar request: %v",
+	"agent.err.enroll_send":            "erro ao enviar inscrição: %v",
+	"agent.err.enroll_refused_message": "inscrição recusada (status %d): %s",
+	"agent.err.enroll_refused":         "inscrição recusada (status %d)",
+	"agent.err.enroll_no_credential":   "servidor não retornou credencial",
+	"agent.err.identity_write":         "erro ao gravar identidade da máquina: %v",
+	"agent.err.identity_generate":      "erro ao gerar identidade da máquina: %v",
+	"agent.err.label_invalid":          "label inválido %q (use chave=valor)",
+	"agent.err.collect":                "erro ao coletar métricas: %v",
+	"agent.err.spool_pending":          "%v (%d coletas pendentes no spool)",
+	"agent.err.payload_encode":         "erro ao serializar payload: %v",
+	"agent.err.batch_encode":           "erro ao serializar lote: %v",
+	"agent.err.batch_compress":         "erro ao comprimir lote: %v",
+	"agent.err.batch_response":         "erro ao ler resposta do lote: %v",
+	"agent.err.send_deferred":          "envio adiado por %s (limite de envios do servidor)",
+	"agent.err.request_send":           "erro ao enviar request: %v",
+	"agent.err.nonce":                  "erro ao gerar nonce: %v",
+	"agent.err.spool_dir":              "erro ao criar diretório do spool: %v",
+	"agent.err.spool_write":            "erro ao gravar payload no spool: %v",
+	"agent.err.spool_read":             "erro ao ler spool: %v",
+	"agent.err.spool_load":             "erro ao ler payload do spool: %v",
+	"agent.err.spool_corrupt":          "payload corrompido no spool (%s): %v",
+	"agent.err.spool_remove":           "erro ao remover payload do spool: %v",
+	"agent.err.ca_read":                "erro ao ler CA: %v",
+	"agent.err.ca_invalid":             "nenhum certificado válido em %s",
+	"agent.err.client_cert_pair":       "--client-cert e --client-key devem ser informados juntos",
+	"agent.err.client_cert_load":       "erro ao carregar certificado de cliente: %v",
+	"agent.err.batch_unsupported":      "servidor não suporta envio em lote",
+	"agent.err.batch_too_large":        "lote excede o tamanho máximo do servidor",
+	"agent.err.batch_rejected":         "servidor recusou o lote",
+	"agent.err.http_status":            "servidor retornou status %d",
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AgentCredential representa a credencial própria de um agent, vinculada à identidade da máquina
+type AgentCredential struct {
+	ID          int64      `json:"id"`
+	MachineUUID string     `json:"machine_uuid"`
+	Hostname    string     `json:"hostname"`
+	TokenPrefix string     `json:"token_prefix"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	MachineID   *int64     `json:"machine_id,omitempty"`
+}
+
+// agentCredentialColumns são as colunas lidas por scanAgentCredential (alias c)
+const agentCredentialColumns = `
+	c.id, c.machine_uuid, COALESCE(c.hostname, ''), c.token_prefix,
+	c.created_at, c.rotated_at, c.last_used_at, c.revoked_at,
+	(SELECT id FROM machines WHERE uuid = c.machine_uuid)
+`
+
+// scanAgentCredential lê uma linha com as colunas de agentCredentialColumns
+func scanAgentCredential(row rowScanner) (*AgentCredential, error) {
+	var c AgentCredential
+	var createdAt string
+	var rotatedAt, lastUsedAt, revokedAt sql.NullString
+	var machineID sql.NullInt64
+
+	err := row.Scan(&c.ID, &c.MachineUUID, &c.Hostname, &c.TokenPrefix,
+		&createdAt, &rotatedAt, &lastUsedAt, &revokedAt, &machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.CreatedAt = parseDateTime(createdAt)
+	c.RotatedAt = parseNullDateTime(rotatedAt)
+	c.LastUsedAt = parseNullDateTime(lastUsedAt)
+	c.RevokedAt = parseNullDateTime(revokedAt)
+	if machineID.Valid {
+		c.MachineID = &machineID.Int64
+	}
+
+	return &c, nil
+}
+
+// parseNullDateTime converte uma data opcional do SQLite
+func parseNullDateTime(s sql.NullString) *time.Time {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	t := parseDateTime(s.String)
+	return &t
+}
+
+// CreateAgentCredential registra a credencial de um agent recém-inscrito
+func (s *Storage) CreateAgentCredential(machineUUID, hostname, tokenHash, tokenPrefix string) (*AgentCredential, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO agent_credentials (machine_uuid, hostname, token_hash, token_prefix)
+		VALUES (?, ?, ?, ?)
+	`, machineUUID, hostname, tokenHash, tokenPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar credencial do agent: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAgentCredential(id)
+}
+
+// GetAgentCredential retorna uma credencial pelo ID (nil se não existir)
+func (s *Storage) GetAgentCredential(id int64) (*AgentCredential, error) {
+	c, err := scanAgentCredential(s.db.QueryRow(`
+		SELECT `+agentCredentialColumns+` FROM agent_credentials c WHERE c.id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credencial: %w", err)
+	}
+	return c, nil
+}
+
+// FindAgentCredentialByHash retorna a credencial ativa (não revogada) com o hash informado
+func (s *Storage) FindAgentCredentialByHash(tokenHash string) (*AgentCredential, error) {
+	c, err := scanAgentCredential(s.db.QueryRow(`
+		SELECT `+agentCredentialColumns+` FROM agent_credentials c
+		WHERE c.token_hash = ? AND c.revoked_at IS NULL
+	`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credencial: %w", err)
+	}
+	return c, nil
+}
+
+// HasAgentCredential indica se já existe credencial (ativa ou revogada) para a máquina
+func (s *Storage) HasAgentCredential(machineUUID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM agent_credentials WHERE machine_uuid = ?)
+	`, machineUUID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar credencial: %w", err)
+	}
+	return exists, nil
+}
+
+// ListAgentCredentials retorna todas as credenciais de agents
+func (s *Storage) ListAgentCredentials() ([]AgentCredential, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + agentCredentialColumns + ` FROM agent_credentials c ORDER BY c.hostname, c.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar credenciais: %w", err)
+	}
+	defer rows.Close()
+
+	var credentials []AgentCredential
+	for rows.Next() {
+		c, err := scanAgentCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear credencial: %w", err)
+		}
+		credentials = append(credentials, *c)
+	}
+
+	return credentials, rows.Err()
+}
+
+// RotateAgentCredential troca o token de uma credencial; uma credencial revogada volta a ficar ativa
+func (s *Storage) RotateAgentCredential(id int64, tokenHash, tokenPrefix string) error {
+	result, err := s.db.Exec(`
+		UPDATE agent_credentials SET
+			token_hash = ?, token_prefix = ?, rotated_at = CURRENT_TIMESTAMP, revoked_at = NULL
+		WHERE id = ?
+	`, tokenHash, tokenPrefix, id)
+	if err != nil {
+		return fmt.Errorf("erro ao rotacionar credencial %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
+
+// RevokeAgentCredential revoga uma credencial; o agent deixa de poder enviar métricas
+func (s *Storage) RevokeAgentCredential(id int64) error {
+	result, err := s.db.Exec(`
+		UPDATE agent_credentials SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = ?
+	`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao revogar credencial %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
+
+// TouchAgentCredential registra o último uso de uma credencial
+func (s *Storage) TouchAgentCredential(id int64) error {
+	_, err := s.db.Exec(`UPDATE agent_credentials SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
+
+// requireAffected retorna ErrNotFound se nenhuma linha foi alterada
+func requireAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAgentCredentialLifecycle(t *testing.T) {
+	s := newTestStorage(t)
+
+	cred, err := s.CreateAgentCredential("8a3c9f1e-0000-4000-8000-000000000001", "web-01", "hash-1", "mia_aaaaaaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		action func() error
+		hash   string
+		active bool // se a credencial é encontrada pelo hash
+	}{
+		{"recém-criada", func() error { return nil }, "hash-1", true},
+		{"revogada", func() error { return s.RevokeAgentCredential(cred.ID) }, "hash-1", false},
+		{"revogar de novo mantém revogada", func() error { return s.RevokeAgentCredential(cred.ID) }, "hash-1", false},
+		{"rotação reativa com o novo hash", func() error { return s.RotateAgentCredential(cred.ID, "hash-2", "mia_bbbbbbbb") }, "hash-2", true},
+		{"hash antigo deixa de valer", func() error { return nil }, "hash-1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.action(); err != nil {
+				t.Fatal(err)
+			}
+			found, err := s.FindAgentCredentialByHash(tt.hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (found != nil) != tt.active {
+				t.Errorf("encontrada = %v, esperado %v", found != nil, tt.active)
+			}
+		})
+	}
+
+	exists, err := s.HasAgentCredential(cred.MachineUUID)
+	if err != nil || !exists {
+		t.Errorf("HasAgentCredential = %v, %v", exists, err)
+	}
+}
+
+func TestAgentCredentialNotFound(t *testing.T) {
+	s := newTestStorage(t)
+
+	tests := []struct {
+		name   string
+		action func() error
+	}{
+		{"revogar", func() error { return s.RevokeAgentCredential(42) }},
+		{"rotacionar", func() error { return s.RotateAgentCredential(42, "hash", "mia_") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.action(); !errors.Is(err, ErrNotFound) {
+				t.Errorf("erro = %v, esperado ErrNotFound", err)
+			}
+		})
+	}
+
+	cred, err := s.GetAgentCredential(42)
+	if err != nil || cred != nil {
+		t.Errorf("GetAgentCredential = %v, %v; esperado nil", cred, err)
+	}
+}
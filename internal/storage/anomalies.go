@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Baseline é o comportamento usual de uma métrica de uma máquina em uma hora da semana:
+// média e desvio padrão dos relatórios do histórico naquela hora
+type Baseline struct {
+	Mean    float64
+	StdDev  float64
+	Samples int
+}
+
+// BaselineKey identifica uma baseline: máquina, métrica (cpu, memory, disk) e hora da semana
+type BaselineKey struct {
+	MachineID  int64
+	Metric     string
+	HourOfWeek int
+}
+
+// Baselines são as baselines calculadas por ComputeBaselines
+type Baselines map[BaselineKey]Baseline
+
+// Anomaly é uma métrica da máquina fora do seu padrão: o valor do relatório, o esperado para
+// a hora da semana e quantos desvios padrão ele se afasta (negativo = abaixo do esperado)
+type Anomaly struct {
+	Metric   string    `json:"metric"`
+	Value    float64   `json:"value"`
+	Expected float64   `json:"expected"`
+	StdDev   float64   `json:"stddev"`
+	Sigma    float64   `json:"sigma"`
+	Since    time.Time `json:"since"`
+}
+
+// MetricSample é um relatório de métricas, lido em ordem de chegada por MetricsAfter
+type MetricSample struct {
+	ID          int64
+	MachineID   int64
+	CollectedAt time.Time
+	Values      map[string]float64 // por métrica: cpu, memory, disk
+}
+
+// HourOfWeek é a hora da semana em UTC, de 0 (domingo, 0h) a 167 (sábado, 23h)
+func HourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// ComputeBaselines calcula as baselines de cada máquina com os relatórios em [from, to). Horas
+// da semana com relatórios em menos de minWeeks semanas diferentes ficam de fora.
+func (s *Storage) ComputeBaselines(from, to time.Time, minWeeks int) (Baselines, error) {
+	rows, err := s.db.Query(`
+		SELECT machine_id,
+		       CAST(strftime('%w', collected_at) AS INTEGER) * 24 + CAST(strftime('%H', collected_at) AS INTEGER) AS hour_of_week,
+		       COUNT(*),
+		       AVG(cpu_percent), AVG(cpu_percent * cpu_percent),
+		       AVG(memory_percent), AVG(memory_percent * memory_percent),
+		       AVG(disk_percent), AVG(disk_percent * disk_percent)
+		FROM metrics
+		WHERE collected_at >= ? AND collected_at < ?
+		GROUP BY machine_id, hour_of_week
+		HAVING COUNT(DISTINCT date(collected_at)) >= ?
+	`, formatDateTime(from), formatDateTime(to), minWeeks)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular baselines: %w", err)
+	}
+	defer rows.Close()
+
+	baselines := make(Baselines)
+	for rows.Next() {
+		var machineID int64
+		var hour, samples int
+		var cpu, cpuSq, mem, memSq, disk, diskSq float64
+		if err := rows.Scan(&machineID, &hour, &samples, &cpu, &cpuSq, &mem, &memSq, &disk, &diskSq); err != nil {
+			return nil, fmt.Errorf("erro ao escanear baseline: %w", err)
+		}
+		for metric, avg := range map[string][2]float64{
+			MetricCPU:    {cpu, cpuSq},
+			MetricMemory: {mem, memSq},
+			MetricDisk:   {disk, diskSq},
+		} {
+			// Variância = média dos quadrados - quadrado da média (arredondamentos podem deixá-la negativa)
+			variance := math.Max(avg[1]-avg[0]*avg[0], 0)
+			baselines[BaselineKey{machineID, metric, hour}] = Baseline{
+				Mean:    avg[0],
+				StdDev:  math.Sqrt(variance),
+				Samples: samples,
+			}
+		}
+	}
+	return baselines, rows.Err()
+}
+
+// LastMetricID retorna o ID do relatório mais recente (0 sem relatórios)
+func (s *Storage) LastMetricID() (int64, error) {
+	var id int64
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM metrics`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("erro ao buscar último relatório: %w", err)
+	}
+	return id, nil
+}
+
+// MetricsAfter retorna até limit relatórios recebidos depois do relatório afterID, em ordem de
+// chegada (inclusive os enviados em lote com horário de coleta antigo)
+func (s *Storage) MetricsAfter(afterID int64, limit int) ([]MetricSample, error) {
+	rows, err := s.db.Query(`
+		SELECT id, machine_id, collected_at, cpu_percent, memory_percent, disk_percent
+		FROM metrics WHERE id > ? ORDER BY id LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar relatórios: %w", err)
+	}
+	defer rows.Close()
+
+	samples := []MetricSample{}
+	for rows.Next() {
+		var sample MetricSample
+		var collectedAt string
+		var cpu, mem, disk float64
+		if err := rows.Scan(&sample.ID, &sample.MachineID, &collectedAt, &cpu, &mem, &disk); err != nil {
+			return nil, fmt.Errorf("erro ao escanear relatório: %w", err)
+		}
+		sample.CollectedAt = parseDateTime(collectedAt)
+		sample.Values = map[string]float64{MetricCPU: cpu, MetricMemory: mem, MetricDisk: disk}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
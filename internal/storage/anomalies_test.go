@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHourOfWeek(t *testing.T) {
+	tests := []struct {
+		at   time.Time
+		want int
+	}{
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 0}, // domingo
+		{time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC), 32},
+		{time.Date(2026, 3, 7, 23, 59, 0, 0, time.UTC), 167}, // sábado
+		// Domingo 21h em UTC-3 é segunda 0h em UTC
+		{time.Date(2026, 3, 1, 21, 0, 0, 0, time.FixedZone("BRT", -3*3600)), 24},
+	}
+
+	for _, tt := range tests {
+		if got := HourOfWeek(tt.at); got != tt.want {
+			t.Errorf("HourOfWeek(%v) = %d, esperado %d", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestComputeBaselines(t *testing.T) {
+	s := newTestStorage(t)
+	id := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+	if _, err := s.db.Exec(`DELETE FROM metrics`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Segundas às 10h (hora 34) de três semanas e uma terça às 10h (hora 58) de uma semana só
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, m := range []struct {
+		at  time.Time
+		cpu float64
+	}{
+		{monday, 10},
+		{monday.Add(30 * time.Minute), 10},
+		{monday.AddDate(0, 0, 7), 20},
+		{monday.AddDate(0, 0, 14).Add(15 * time.Minute), 40},
+		{monday.AddDate(0, 0, 1), 90},
+	} {
+		if _, err := s.db.Exec(`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES (?, ?, ?, 50, 50)`,
+			id, formatDateTime(m.at), m.cpu); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		minWeeks int
+		hour     int
+		metric   string
+		found    bool
+		mean     float64
+		stddev   float64
+		samples  int
+	}{
+		{"cpu com três semanas", 2, 34, MetricCPU, true, 20, math.Sqrt(150), 4},
+		{"disco constante", 2, 34, MetricDisk, true, 50, 0, 4},
+		{"hora com uma semana", 2, 58, MetricCPU, false, 0, 0, 0},
+		{"semanas insuficientes", 4, 34, MetricCPU, false, 0, 0, 0},
+		{"uma semana basta", 1, 58, MetricCPU, true, 90, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baselines, err := s.ComputeBaselines(monday.AddDate(0, 0, -1), monday.AddDate(0, 0, 21), tt.minWeeks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, ok := baselines[BaselineKey{MachineID: id, Metric: tt.metric, HourOfWeek: tt.hour}]
+			if ok != tt.found {
+				t.Fatalf("baseline presente = %v, esperado %v", ok, tt.found)
+			}
+			if ok && (math.Abs(b.Mean-tt.mean) > 1e-9 || math.Abs(b.StdDev-tt.stddev) > 1e-6 || b.Samples != tt.samples) {
+				t.Errorf("baseline = %+v, esperado média %v, desvio %v, %d relatórios", b, tt.mean, tt.stddev, tt.samples)
+			}
+		})
+	}
+}
+
+func TestMetricsAfter(t *testing.T) {
+	s := newTestStorage(t)
+	if id, err := s.LastMetricID(); err != nil || id != 0 {
+		t.Fatalf("LastMetricID sem relatórios = %d, %v", id, err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := s.SaveMetrics(&MetricPayload{
+			MachineUUID: "8a3c9f1e-0000-4000-8000-000000000001", Hostname: "web-01", IP: "10.0.0.1",
+			CPUPercent: float64(10 * i), CollectedAt: time.Now().Add(-time.Duration(i) * time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last, err := s.LastMetricID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		afterID int64
+		limit   int
+		want    []float64 // cpu dos relatórios, em ordem de chegada
+	}{
+		{"todos", 0, 10, []float64{0, 10, 20}},
+		{"limite", 0, 2, []float64{0, 10}},
+		{"depois do primeiro", last - 2, 10, []float64{10, 20}},
+		{"nenhum novo", last, 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples, err := s.MetricsAfter(tt.afterID, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(samples) != len(tt.want) {
+				t.Fatalf("%d relatórios, esperado %d", len(samples), len(tt.want))
+			}
+			for i, sample := range samples {
+				if sample.Values[MetricCPU] != tt.want[i] {
+					t.Errorf("relatório %d: cpu = %v, esperado %v", i, sample.Values[MetricCPU], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditEntry representa um evento administrativo ou de autenticação
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Target    string          `json:"target,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"` // estado anterior
+	After     json.RawMessage `json:"after,omitempty"`  // estado posterior
+	Details   string          `json:"details,omitempty"`
+}
+
+// AuditFilter filtra a consulta do log de auditoria; campos vazios são ignorados
+type AuditFilter struct {
+	Actor    string
+	Action   string // prefixo, ex.: "auth." ou "user.create"
+	Target   string
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64 // paginação: apenas entradas com ID menor
+	Limit    int
+}
+
+// RecordAudit grava um evento no log de auditoria (somente inserção)
+func (s *Storage) RecordAudit(e AuditEntry) error {
+	_, err := s.db.Exec(`
+		INSERT INTO audit_log (created_at, actor, action, target, ip, before_state, after_state, details)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, formatDateTime(time.Now()), e.Actor, e.Action, e.Target, e.IP, nullJSON(e.Before), nullJSON(e.After), e.Details)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar auditoria: %w", err)
+	}
+	return nil
+}
+
+// ListAudit retorna entradas do log de auditoria, da mais recente para a mais antiga
+func (s *Storage) ListAudit(f AuditFilter) ([]AuditEntry, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.Actor != "" {
+		conds = append(conds, "actor = ?")
+		args = append(args, f.Actor)
+	}
+	if f.Action != "" {
+		conds = append(conds, "action LIKE ? ESCAPE '\\'")
+		args = append(args, escapeLike(f.Action)+"%")
+	}
+	if f.Target != "" {
+		conds = append(conds, "target = ?")
+		args = append(args, f.Target)
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, formatDateTime(f.Since))
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, formatDateTime(f.Until))
+	}
+	if f.BeforeID > 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, f.BeforeID)
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, f.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar auditoria: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// auditColumns são as colunas lidas por scanAudit
+const auditColumns = `
+	id, created_at, actor, action, COALESCE(target, ''), COALESCE(ip, ''),
+	COALESCE(before_state, ''), COALESCE(after_state, ''), COALESCE(details, '')
+`
+
+// scanAudit lê uma linha com as colunas de auditColumns
+func scanAudit(row rowScanner) (AuditEntry, error) {
+	var e AuditEntry
+	var createdAt, before, after string
+	if err := row.Scan(&e.ID, &createdAt, &e.Actor, &e.Action, &e.Target, &e.IP,
+		&before, &after, &e.Details); err != nil {
+		return e, fmt.Errorf("erro ao escanear auditoria: %w", err)
+	}
+	e.CreatedAt = parseDateTime(createdAt)
+	if before != "" {
+		e.Before = json.RawMessage(before)
+	}
+	if after != "" {
+		e.After = json.RawMessage(after)
+	}
+	return e, nil
+}
+
+// auditNoDeleteTrigger bloqueia DELETE no log de auditoria; só ArchiveAudit o suspende
+const auditNoDeleteTrigger = `
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log é somente inserção');
+	END;
+`
+
+// ArchiveAudit remove as entradas do log de auditoria anteriores a before e retorna quantas
+// foram removidas. Cada entrada é passada antes, da mais antiga para a mais recente, para
+// archive (opcional); se archive falhar nada é removido. É o único caminho de remoção do log:
+// o trigger que bloqueia DELETE é suspenso apenas dentro da transação.
+func (s *Storage) ArchiveAudit(before time.Time, archive func(AuditEntry) error) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cutoff := formatDateTime(before)
+	if archive != nil {
+		rows, err := tx.Query(`SELECT `+auditColumns+` FROM audit_log WHERE created_at < ? ORDER BY id`, cutoff)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao buscar auditoria antiga: %w", err)
+		}
+		for rows.Next() {
+			e, err := scanAudit(rows)
+			if err == nil {
+				err = archive(e)
+			}
+			if err != nil {
+				rows.Close()
+				return 0, err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("erro ao buscar auditoria antiga: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`DROP TRIGGER IF EXISTS audit_log_no_delete`); err != nil {
+		return 0, fmt.Errorf("erro ao suspender proteção da auditoria: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM audit_log WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao remover auditoria antiga: %w", err)
+	}
+	if _, err := tx.Exec(auditNoDeleteTrigger); err != nil {
+		return 0, fmt.Errorf("erro ao restaurar proteção da auditoria: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// nullJSON converte um JSON vazio em NULL
+func nullJSON(data json.RawMessage) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// escapeLike escapa os curingas do LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// recordAuditAt grava uma entrada de auditoria com o horário informado
+func recordAuditAt(t *testing.T, s *Storage, at time.Time, actor, action string) {
+	t.Helper()
+	if _, err := s.db.Exec(`
+		INSERT INTO audit_log (created_at, actor, action) VALUES (?, ?, ?)
+	`, formatDateTime(at), actor, action); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListAudit(t *testing.T) {
+	s := newTestStorage(t)
+	now := time.Now()
+	recordAuditAt(t, s, now.Add(-3*time.Hour), "user:ana", "auth.login")
+	recordAuditAt(t, s, now.Add(-2*time.Hour), "user:ana", "user.create")
+	recordAuditAt(t, s, now.Add(-time.Hour), "token", "auth.failure")
+	recordAuditAt(t, s, now, "user:bia", "auth_x.login")
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		count  int
+	}{
+		{"sem filtro", AuditFilter{Limit: 100}, 4},
+		{"por autor", AuditFilter{Actor: "user:ana", Limit: 100}, 2},
+		{"prefixo da ação", AuditFilter{Action: "auth.", Limit: 100}, 2},
+		{"curinga do LIKE escapado", AuditFilter{Action: "auth_", Limit: 100}, 1},
+		{"desde", AuditFilter{Since: now.Add(-90 * time.Minute), Limit: 100}, 2},
+		{"até", AuditFilter{Until: now.Add(-90 * time.Minute), Limit: 100}, 2},
+		{"limite", AuditFilter{Limit: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.ListAudit(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.count {
+				t.Errorf("%d entradas, esperado %d", len(entries), tt.count)
+			}
+		})
+	}
+}
+
+func TestArchiveAudit(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		archiveErr error // erro devolvido pelo arquivamento (nil: sucesso)
+		noArchive  bool  // sem função de arquivamento
+		deleted    int64
+		archived   int
+		remaining  int
+	}{
+		{"arquiva e remove as antigas", nil, false, 2, 2, 1},
+		{"remove sem arquivar", nil, true, 2, 0, 1},
+		{"falha no arquivamento não remove nada", errors.New("disco cheio"), false, 0, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			recordAuditAt(t, s, now.AddDate(0, 0, -40), "user:ana", "auth.login")
+			recordAuditAt(t, s, now.AddDate(0, 0, -35), "user:ana", "auth.logout")
+			recordAuditAt(t, s, now, "user:ana", "auth.login")
+
+			var archived []AuditEntry
+			archive := func(e AuditEntry) error {
+				archived = append(archived, e)
+				return tt.archiveErr
+			}
+			if tt.noArchive {
+				archive = nil
+			}
+
+			deleted, err := s.ArchiveAudit(now.AddDate(0, 0, -30), archive)
+			if (err != nil) != (tt.archiveErr != nil) {
+				t.Fatalf("erro = %v", err)
+			}
+			if deleted != tt.deleted || len(archived) != tt.archived {
+				t.Errorf("removidas %d, arquivadas %d; esperado %d e %d", deleted, len(archived), tt.deleted, tt.archived)
+			}
+			if len(archived) > 0 && archived[0].Action != "auth.login" {
+				t.Errorf("arquivamento fora de ordem: %+v", archived[0])
+			}
+			if n := countRows(t, s, "audit_log", "1"); n != tt.remaining {
+				t.Errorf("%d entradas restantes, esperado %d", n, tt.remaining)
+			}
+
+			// A proteção contra DELETE continua ativa depois do arquivamento
+			if _, err := s.db.Exec(`DELETE FROM audit_log`); err == nil {
+				t.Error("DELETE direto no log de auditoria foi aceito")
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AlertSummary são as ocorrências de um tipo de alerta de uma máquina em um período. Kind é o
+// tipo do aviso (hostname_conflict, clock_drift, rejected, disk_full, anomaly.<métrica>) ou "status.<estado>" para as
+// mudanças para atenção, crítico e offline; Args são os argumentos da ocorrência mais recente.
+type AlertSummary struct {
+	MachineID   int64
+	Hostname    string
+	DisplayName string
+	GroupName   string
+	Kind        string
+	Count       int
+	Last        time.Time
+	Args        []interface{}
+}
+
+// Alert é um aviso registrado sobre uma máquina; Args completam a mensagem "alert.<kind>"
+type Alert struct {
+	ID          int64         `json:"id"`
+	MachineID   int64         `json:"machine_id"`
+	Hostname    string        `json:"hostname"`
+	DisplayName string        `json:"display_name,omitempty"`
+	GroupName   string        `json:"group"`
+	Kind        string        `json:"kind"`
+	Args        []interface{} `json:"args"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// AlertFilter são os filtros de ListAlerts
+type AlertFilter struct {
+	MachineID int64
+	Kind      string // prefixo, ex.: "anomaly." ou "disk_full"
+	Since     time.Time
+	Until     time.Time
+	BeforeID  int64 // paginação: apenas alertas com ID menor
+	Limit     int
+}
+
+// RecordStatusEvent registra uma mudança de estado de uma máquina (ex.: online -> offline),
+// usada nos relatórios de disponibilidade
+func (s *Storage) RecordStatusEvent(machineID int64, from, to string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO status_events (machine_id, from_status, to_status, created_at) VALUES (?, ?, ?, ?)
+	`, machineID, from, to, formatDateTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("erro ao registrar mudança de estado: %w", err)
+	}
+	return nil
+}
+
+// LastStatuses retorna o estado de destino da mudança de estado mais recente de cada máquina
+func (s *Storage) LastStatuses() (map[int64]string, error) {
+	rows, err := s.db.Query(`
+		SELECT e.machine_id, e.to_status FROM status_events e
+		WHERE e.id = (
+			SELECT id FROM status_events WHERE machine_id = e.machine_id
+			ORDER BY created_at DESC, id DESC LIMIT 1
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar estados das máquinas: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[int64]string)
+	for rows.Next() {
+		var machineID int64
+		var status string
+		if err := rows.Scan(&machineID, &status); err != nil {
+			return nil, fmt.Errorf("erro ao escanear estado: %w", err)
+		}
+		statuses[machineID] = status
+	}
+	return statuses, rows.Err()
+}
+
+// RecordAlert registra um aviso sobre a máquina; args completam a mensagem "alert.<kind>" do
+// catálogo e são gravados em JSON
+func (s *Storage) RecordAlert(machineID int64, kind string, args []interface{}) error {
+	if args == nil {
+		args = []interface{}{}
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar alerta: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO alerts (machine_id, kind, args, created_at) VALUES (?, ?, ?, ?)
+	`, machineID, kind, string(data), formatDateTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("erro ao registrar alerta: %w", err)
+	}
+	return nil
+}
+
+// SummarizeAlerts agrupa por máquina e tipo os alertas e as mudanças para atenção, crítico e
+// offline registrados em [from, to), das máquinas não arquivadas; os mais frequentes primeiro
+func (s *Storage) SummarizeAlerts(from, to time.Time) ([]AlertSummary, error) {
+	// Em um SELECT com MAX(), o SQLite lê as demais colunas da linha do máximo: args é o da
+	// ocorrência mais recente
+	rows, err := s.db.Query(`
+		SELECT e.machine_id, m.hostname, COALESCE(m.display_name, ''), COALESCE(m.group_name, ''),
+		       e.kind, COUNT(*), MAX(e.created_at), e.args
+		FROM (
+			SELECT machine_id, kind, args, created_at FROM alerts
+			UNION ALL
+			SELECT machine_id, 'status.' || to_status, '[]', created_at FROM status_events
+			WHERE to_status IN (?, ?, ?)
+		) e
+		JOIN machines m ON m.id = e.machine_id
+		WHERE e.created_at >= ? AND e.created_at < ? AND m.archived_at IS NULL
+		GROUP BY e.machine_id, e.kind
+		ORDER BY COUNT(*) DESC, MAX(e.created_at) DESC
+	`, StatusWarning, StatusCritical, StatusOffline, formatDateTime(from), formatDateTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar alertas: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []AlertSummary{}
+	for rows.Next() {
+		var a AlertSummary
+		var last, args string
+		if err := rows.Scan(&a.MachineID, &a.Hostname, &a.DisplayName, &a.GroupName,
+			&a.Kind, &a.Count, &last, &args); err != nil {
+			return nil, fmt.Errorf("erro ao escanear alerta: %w", err)
+		}
+		a.Last = parseDateTime(last)
+		if err := json.Unmarshal([]byte(args), &a.Args); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar alerta: %w", err)
+		}
+		summaries = append(summaries, a)
+	}
+	return summaries, rows.Err()
+}
+
+// ListAlerts retorna os alertas das máquinas do escopo, do mais recente para o mais antigo
+func (s *Storage) ListAlerts(scope *Scope, f AlertFilter) ([]Alert, error) {
+	cond, args := scope.condition("m.group_name")
+	conds := []string{cond}
+
+	if f.MachineID > 0 {
+		conds = append(conds, "a.machine_id = ?")
+		args = append(args, f.MachineID)
+	}
+	if f.Kind != "" {
+		conds = append(conds, "a.kind LIKE ? ESCAPE '\\'")
+		args = append(args, escapeLike(f.Kind)+"%")
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "a.created_at >= ?")
+		args = append(args, formatDateTime(f.Since))
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, "a.created_at < ?")
+		args = append(args, formatDateTime(f.Until))
+	}
+	if f.BeforeID > 0 {
+		conds = append(conds, "a.id < ?")
+		args = append(args, f.BeforeID)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT a.id, a.machine_id, m.hostname, COALESCE(m.display_name, ''), COALESCE(m.group_name, ''),
+		       a.kind, a.args, a.created_at
+		FROM alerts a
+		JOIN machines m ON m.id = a.machine_id
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY a.id DESC LIMIT ?
+	`, append(args, f.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar alertas: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []Alert{}
+	for rows.Next() {
+		var a Alert
+		var args, createdAt string
+		if err := rows.Scan(&a.ID, &a.MachineID, &a.Hostname, &a.DisplayName, &a.GroupName,
+			&a.Kind, &args, &createdAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear alerta: %w", err)
+		}
+		a.CreatedAt = parseDateTime(createdAt)
+		if err := json.Unmarshal([]byte(args), &a.Args); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar alerta: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// CleanupOldEvents remove mudanças de estado e alertas anteriores à retenção, junto com as métricas
+func (s *Storage) CleanupOldEvents() (int64, error) {
+	var deleted int64
+	cutoff := formatDateTime(time.Now().AddDate(0, 0, -s.retentionDays))
+	for _, table := range []string{"status_events", "alerts"} {
+		result, err := s.db.Exec(`DELETE FROM `+table+` WHERE created_at < ?`, cutoff)
+		if err != nil {
+			return deleted, fmt.Errorf("erro ao limpar %s antigos: %w", table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
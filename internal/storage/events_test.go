@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeAlerts(t *testing.T) {
+	s := newTestStorage(t)
+	web := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+	db := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000002", "db-01", "db")
+	now := time.Now().Truncate(time.Second)
+	ago := func(minutes int) string { return formatDateTime(now.Add(-time.Duration(minutes) * time.Minute)) }
+
+	for _, a := range []struct {
+		machine int64
+		kind    string
+		args    string
+		at      string
+	}{
+		{web, "clock_drift", `[120]`, ago(30)},
+		{web, "clock_drift", `[300]`, ago(10)},
+		{web, "clock_drift", `[60]`, ago(60 * 48)}, // fora do período
+		{db, "disk_full", `[]`, ago(20)},
+	} {
+		if _, err := s.db.Exec(`INSERT INTO alerts (machine_id, kind, args, created_at) VALUES (?, ?, ?, ?)`,
+			a.machine, a.kind, a.args, a.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []struct {
+		machine  int64
+		from, to string
+	}{
+		{db, StatusOnline, StatusOffline},
+		{db, StatusOffline, StatusOnline}, // a volta para online não é alerta
+	} {
+		if _, err := s.db.Exec(`INSERT INTO status_events (machine_id, from_status, to_status, created_at) VALUES (?, ?, ?, ?)`,
+			e.machine, e.from, e.to, ago(5)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summaries, err := s.SummarizeAlerts(now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		machine int64
+		kind    string
+		count   int
+		args    []interface{}
+	}{
+		// Os mais frequentes primeiro; empates pelo mais recente
+		{web, "clock_drift", 2, []interface{}{300.0}},
+		{db, "status.offline", 1, []interface{}{}},
+		{db, "disk_full", 1, []interface{}{}},
+	}
+	if len(summaries) != len(tests) {
+		t.Fatalf("%d resumos, esperado %d: %+v", len(summaries), len(tests), summaries)
+	}
+	for i, tt := range tests {
+		a := summaries[i]
+		if a.MachineID != tt.machine || a.Kind != tt.kind || a.Count != tt.count || len(a.Args) != len(tt.args) {
+			t.Errorf("resumo %d = %+v, esperado %s de %d com %d ocorrências", i, a, tt.kind, tt.machine, tt.count)
+			continue
+		}
+		for j := range tt.args {
+			if a.Args[j] != tt.args[j] {
+				t.Errorf("%s: args = %v, esperado %v", tt.kind, a.Args, tt.args)
+			}
+		}
+	}
+}
+
+func TestLastStatuses(t *testing.T) {
+	s := newTestStorage(t)
+	web := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+	db := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000002", "db-01", "db")
+	saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000003", "api-01", "web")
+	now := time.Now().Truncate(time.Second)
+
+	for _, e := range []struct {
+		machine int64
+		to      string
+		at      time.Time
+	}{
+		{web, StatusOffline, now.Add(-time.Hour)},
+		{web, StatusOnline, now.Add(-time.Minute)},
+		{db, StatusCritical, now.Add(-time.Minute)},
+		{db, StatusOffline, now.Add(-2 * time.Hour)}, // gravado depois, mas mais antigo (ex.: mesclagem)
+	} {
+		if _, err := s.db.Exec(`INSERT INTO status_events (machine_id, from_status, to_status, created_at) VALUES (?, '', ?, ?)`,
+			e.machine, e.to, formatDateTime(e.at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statuses, err := s.LastStatuses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int64]string{web: StatusOnline, db: StatusCritical}
+	if len(statuses) != len(want) || statuses[web] != want[web] || statuses[db] != want[db] {
+		t.Errorf("LastStatuses = %v, esperado %v", statuses, want)
+	}
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// forecastMinSpan é o intervalo mínimo entre o primeiro e o último relatório para projetar o disco
+const forecastMinSpan = 6 * time.Hour
+
+// MetricAverages são as médias de CPU, memória e disco de uma máquina em um período
+type MetricAverages struct {
+	CPU     float64
+	Memory  float64
+	Disk    float64
+	Samples int
+}
+
+// DiskForecast é a projeção do uso de disco de uma máquina por regressão linear sobre os
+// relatórios do período: o uso atual, a variação em pontos percentuais por dia e, se o uso
+// está crescendo, quando o disco chega a 100% (e em quantos dias, contados do último relatório)
+type DiskForecast struct {
+	Current      float64    `json:"current_percent"`
+	GrowthPerDay float64    `json:"growth_percent_per_day"`
+	FullAt       *time.Time `json:"full_at"`
+	DaysToFull   *float64   `json:"days_to_full"`
+	Samples      int        `json:"samples"`
+}
+
+// FullWithin indica se o disco deve encher antes de t
+func (f *DiskForecast) FullWithin(t time.Time) bool {
+	return f.FullAt != nil && f.FullAt.Before(t)
+}
+
+// AverageMetrics retorna as médias das métricas de cada máquina com relatórios em [from, to)
+func (s *Storage) AverageMetrics(from, to time.Time) (map[int64]MetricAverages, error) {
+	rows, err := s.db.Query(`
+		SELECT machine_id, AVG(cpu_percent), AVG(memory_percent), AVG(disk_percent), COUNT(*)
+		FROM metrics
+		WHERE collected_at >= ? AND collected_at < ?
+		GROUP BY machine_id
+	`, formatDateTime(from), formatDateTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular médias: %w", err)
+	}
+	defer rows.Close()
+
+	averages := make(map[int64]MetricAverages)
+	for rows.Next() {
+		var machineID int64
+		var avg MetricAverages
+		if err := rows.Scan(&machineID, &avg.CPU, &avg.Memory, &avg.Disk, &avg.Samples); err != nil {
+			return nil, fmt.Errorf("erro ao escanear médias: %w", err)
+		}
+		averages[machineID] = avg
+	}
+	return averages, rows.Err()
+}
+
+// ForecastDisks projeta o uso de disco de cada máquina com relatórios em [from, to). Máquinas
+// com relatórios cobrindo menos de 6 horas ficam de fora.
+func (s *Storage) ForecastDisks(from, to time.Time) (map[int64]DiskForecast, error) {
+	return s.forecastDisks(0, from, to)
+}
+
+// ForecastDisk projeta o uso de disco de uma máquina (nil se não há relatórios suficientes)
+func (s *Storage) ForecastDisk(machineID int64, from, to time.Time) (*DiskForecast, error) {
+	forecasts, err := s.forecastDisks(machineID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := forecasts[machineID]; ok {
+		return &f, nil
+	}
+	return nil, nil
+}
+
+// forecastDisks calcula as projeções de uma máquina ou, com machineID 0, de todas
+func (s *Storage) forecastDisks(machineID int64, from, to time.Time) (map[int64]DiskForecast, error) {
+	rows, err := s.db.Query(`
+		SELECT machine_id, collected_at, disk_percent FROM metrics
+		WHERE collected_at >= ? AND collected_at < ? AND (? = 0 OR machine_id = ?)
+		ORDER BY machine_id, collected_at
+	`, formatDateTime(from), formatDateTime(to), machineID, machineID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar uso de disco: %w", err)
+	}
+	defer rows.Close()
+
+	// Somas da regressão por máquina; x em dias desde from
+	type sums struct {
+		n            int
+		x, y, xx, xy float64
+		first, last  time.Time
+		lastValue    float64
+	}
+	series := make(map[int64]*sums)
+	for rows.Next() {
+		var machineID int64
+		var collectedAt string
+		var disk float64
+		if err := rows.Scan(&machineID, &collectedAt, &disk); err != nil {
+			return nil, fmt.Errorf("erro ao escanear uso de disco: %w", err)
+		}
+		t := parseDateTime(collectedAt)
+		x := t.Sub(from).Hours() / 24
+
+		sm := series[machineID]
+		if sm == nil {
+			sm = &sums{first: t}
+			series[machineID] = sm
+		}
+		sm.n++
+		sm.x += x
+		sm.y += disk
+		sm.xx += x * x
+		sm.xy += x * disk
+		sm.last, sm.lastValue = t, disk
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	forecasts := make(map[int64]DiskForecast, len(series))
+	for machineID, sm := range series {
+		n := float64(sm.n)
+		denominator := n*sm.xx - sm.x*sm.x
+		if sm.last.Sub(sm.first) < forecastMinSpan || denominator == 0 {
+			continue
+		}
+		slope := (n*sm.xy - sm.x*sm.y) / denominator
+		forecast := DiskForecast{Current: sm.lastValue, GrowthPerDay: math.Round(slope*1000) / 1000, Samples: sm.n}
+		// Crescimento desprezível (mais de 10 anos para encher) fica sem data
+		if days := (100 - sm.lastValue) / slope; slope > 0 && days < 3650 {
+			fullAt := sm.last.Add(time.Duration(days * 24 * float64(time.Hour))).Truncate(time.Second)
+			days = math.Round(days*10) / 10
+			forecast.FullAt, forecast.DaysToFull = &fullAt, &days
+		}
+		forecasts[machineID] = forecast
+	}
+	return forecasts, nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestForecastDisks(t *testing.T) {
+	s := newTestStorage(t)
+	now := time.Now().Truncate(time.Second)
+	start := now.Add(-73 * time.Hour)
+
+	tests := []struct {
+		name     string
+		disk     func(days float64) float64
+		span     time.Duration // intervalo coberto pelos relatórios
+		found    bool
+		growth   float64
+		daysFull float64 // 0: sem previsão
+	}{
+		{"crescendo", func(d float64) float64 { return 50 + 2*d }, 72 * time.Hour, true, 2, 22},
+		{"estável", func(d float64) float64 { return 40 }, 72 * time.Hour, true, 0, 0},
+		{"diminuindo", func(d float64) float64 { return 80 - d }, 72 * time.Hour, true, -1, 0},
+		{"crescimento desprezível", func(d float64) float64 { return 10 + 0.01*d }, 72 * time.Hour, true, 0.01, 0},
+		{"histórico curto", func(d float64) float64 { return 50 + 10*d }, 5 * time.Hour, false, 0, 0},
+	}
+
+	ids := make([]int64, len(tests))
+	for i, tt := range tests {
+		ids[i] = saveTestMetrics(t, s, fmt.Sprintf("8a3c9f1e-0000-4000-8000-%012d", i+1), fmt.Sprintf("host-%d", i), "web")
+		if _, err := s.db.Exec(`DELETE FROM metrics WHERE machine_id = ?`, ids[i]); err != nil {
+			t.Fatal(err)
+		}
+		for offset := time.Duration(0); offset <= tt.span; offset += time.Hour {
+			days := offset.Hours() / 24
+			if _, err := s.db.Exec(`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES (?, ?, 0, 0, ?)`,
+				ids[i], formatDateTime(start.Add(offset)), tt.disk(days)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	forecasts, err := s.ForecastDisks(now.AddDate(0, 0, -7), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := forecasts[ids[i]]
+			if ok != tt.found {
+				t.Fatalf("projeção presente = %v, esperado %v", ok, tt.found)
+			}
+			if !ok {
+				return
+			}
+			if f.GrowthPerDay != tt.growth {
+				t.Errorf("crescimento = %v, esperado %v", f.GrowthPerDay, tt.growth)
+			}
+			if f.Current != tt.disk(tt.span.Hours()/24) {
+				t.Errorf("uso atual = %v, esperado %v", f.Current, tt.disk(tt.span.Hours()/24))
+			}
+			switch {
+			case tt.daysFull == 0 && (f.FullAt != nil || f.DaysToFull != nil):
+				t.Errorf("esperado sem previsão, recebido %v", f.FullAt)
+			case tt.daysFull > 0 && (f.DaysToFull == nil || *f.DaysToFull != tt.daysFull):
+				t.Errorf("dias para encher = %v, esperado %v", f.DaysToFull, tt.daysFull)
+			}
+		})
+	}
+
+	single, err := s.ForecastDisk(ids[0], now.AddDate(0, 0, -7), now)
+	if err != nil || single == nil || single.GrowthPerDay != 2 {
+		t.Errorf("ForecastDisk = %+v, %v", single, err)
+	}
+	if missing, err := s.ForecastDisk(ids[len(ids)-1], now.AddDate(0, 0, -7), now); err != nil || missing != nil {
+		t.Errorf("ForecastDisk com histórico curto = %+v, %v; esperado nil", missing, err)
+	}
+}
+
+func TestFullWithin(t *testing.T) {
+	now := time.Now()
+	fullAt := now.Add(48 * time.Hour)
+
+	tests := []struct {
+		name     string
+		forecast DiskForecast
+		horizon  time.Time
+		want     bool
+	}{
+		{"enche antes", DiskForecast{FullAt: &fullAt}, now.AddDate(0, 0, 3), true},
+		{"enche depois", DiskForecast{FullAt: &fullAt}, now.AddDate(0, 0, 1), false},
+		{"sem previsão", DiskForecast{}, now.AddDate(0, 0, 30), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.forecast.FullWithin(tt.horizon); got != tt.want {
+			t.Errorf("%s: FullWithin = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAverageMetrics(t *testing.T) {
+	s := newTestStorage(t)
+	id := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+	now := time.Now().Truncate(time.Second)
+	if _, err := s.db.Exec(`DELETE FROM metrics`); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []struct {
+		hoursAgo       int
+		cpu, mem, disk float64
+	}{{1, 10, 20, 30}, {2, 30, 40, 50}, {48, 90, 90, 90}} {
+		if _, err := s.db.Exec(`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES (?, ?, ?, ?, ?)`,
+			id, formatDateTime(now.Add(-time.Duration(m.hoursAgo)*time.Hour)), m.cpu, m.mem, m.disk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	averages, err := s.AverageMetrics(now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MetricAverages{CPU: 20, Memory: 30, Disk: 40, Samples: 2}
+	if got := averages[id]; got != want {
+		t.Errorf("médias = %+v, esperado %+v", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Origem de um label: enviado pelo agent (--label) ou definido pela API.
+// Labels da API prevalecem sobre os do agent com a mesma chave.
+const (
+	LabelSourceAgent = "agent"
+	LabelSourceAPI   = "api"
+)
+
+// Limites dos labels de uma máquina
+const (
+	MaxLabels           = 32
+	MaxLabelKeyLength   = 63
+	MaxLabelValueLength = 128
+)
+
+// ValidLabelKey indica se a chave usa apenas letras, números e . _ - /
+func ValidLabelKey(key string) bool {
+	if key == "" || len(key) > MaxLabelKeyLength {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("._-/", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLabels converte labels no formato chave=valor separados por quebra de linha
+func parseLabels(s string) map[string]string {
+	if s == "" {
+		return nil
+	}
+	labels := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		key, value, _ := strings.Cut(line, "=")
+		labels[key] = value
+	}
+	return labels
+}
+
+// syncAgentLabels substitui os labels enviados pelo agent, sem alterar os definidos pela API
+func syncAgentLabels(ex execer, machineID int64, labels map[string]string) error {
+	if _, err := ex.Exec(`DELETE FROM machine_labels WHERE machine_id = ? AND source = ?`, machineID, LabelSourceAgent); err != nil {
+		return fmt.Errorf("erro ao atualizar labels (machine_id=%d): %w", machineID, err)
+	}
+
+	for key, value := range labels {
+		_, err := ex.Exec(`
+			INSERT OR IGNORE INTO machine_labels (machine_id, key, value, source) VALUES (?, ?, ?, ?)
+		`, machineID, key, value, LabelSourceAgent)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar labels (machine_id=%d): %w", machineID, err)
+		}
+	}
+
+	return nil
+}
+
+// setAPILabels define (ou, com valor nil, remove) labels de uma máquina pela API
+func setAPILabels(ex execer, machineID int64, labels map[string]*string) error {
+	for key, value := range labels {
+		var err error
+		if value == nil {
+			_, err = ex.Exec(`DELETE FROM machine_labels WHERE machine_id = ? AND key = ?`, machineID, key)
+		} else {
+			_, err = ex.Exec(`
+				INSERT INTO machine_labels (machine_id, key, value, source) VALUES (?, ?, ?, ?)
+				ON CONFLICT (machine_id, key) DO UPDATE SET value = excluded.value, source = excluded.source
+			`, machineID, key, *value, LabelSourceAPI)
+		}
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar label %s (machine_id=%d): %w", key, machineID, err)
+		}
+	}
+	return nil
+}
+
+// Operadores dos seletores de labels
+const (
+	selectorEquals    = "="
+	selectorNotEquals = "!="
+	selectorExists    = "exists"
+	selectorNotExists = "!exists"
+)
+
+// LabelRequirement é um termo do seletor: chave, operador e valor
+type LabelRequirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// LabelSelector filtra máquinas por labels; todos os termos precisam ser atendidos.
+// Um seletor vazio aceita todas as máquinas.
+type LabelSelector []LabelRequirement
+
+// SelectorError indica um termo inválido em um seletor de labels
+type SelectorError struct {
+	Term string
+}
+
+func (e *SelectorError) Error() string {
+	return fmt.Sprintf("seletor inválido: %q", e.Term)
+}
+
+// ParseLabelSelector interpreta seletores como "env=prod,role!=db,gpu,!legacy":
+// chave=valor (ou ==), chave!=valor (inclui máquinas sem a chave), chave (existe) e !chave (não existe)
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	var selector LabelSelector
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var req LabelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			req.Key, req.Value, _ = strings.Cut(term, "!=")
+			req.Operator = selectorNotEquals
+		case strings.Contains(term, "="):
+			req.Key, req.Value, _ = strings.Cut(term, "=")
+			req.Value = strings.TrimPrefix(req.Value, "=")
+			req.Operator = selectorEquals
+		case strings.HasPrefix(term, "!"):
+			req.Key = strings.TrimPrefix(term, "!")
+			req.Operator = selectorNotExists
+		default:
+			req.Key = term
+			req.Operator = selectorExists
+		}
+
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if !ValidLabelKey(req.Key) {
+			return nil, &SelectorError{Term: term}
+		}
+		selector = append(selector, req)
+	}
+	return selector, nil
+}
+
+// Matches indica se os labels atendem a todos os termos do seletor
+func (sel LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range sel {
+		value, ok := labels[req.Key]
+		switch req.Operator {
+		case selectorEquals:
+			if !ok || value != req.Value {
+				return false
+			}
+		case selectorNotEquals:
+			if ok && value == req.Value {
+				return false
+			}
+		case selectorExists:
+			if !ok {
+				return false
+			}
+		case selectorNotExists:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// condition retorna a condição SQL (e os argumentos) do seletor para a máquina com o alias informado
+func (sel LabelSelector) condition(machineAlias string) (string, []interface{}) {
+	if len(sel) == 0 {
+		return "1", nil
+	}
+
+	var conds []string
+	var args []interface{}
+	for _, req := range sel {
+		match := "SELECT 1 FROM machine_labels l WHERE l.machine_id = " + machineAlias + ".id AND l.key = ?"
+		switch req.Operator {
+		case selectorEquals:
+			conds = append(conds, "EXISTS ("+match+" AND l.value = ?)")
+			args = append(args, req.Key, req.Value)
+		case selectorNotEquals:
+			conds = append(conds, "NOT EXISTS ("+match+" AND l.value = ?)")
+			args = append(args, req.Key, req.Value)
+		case selectorExists:
+			conds = append(conds, "EXISTS ("+match+")")
+			args = append(args, req.Key)
+		case selectorNotExists:
+			conds = append(conds, "NOT EXISTS ("+match+")")
+			args = append(args, req.Key)
+		}
+	}
+	return strings.Join(conds, " AND "), args
+}
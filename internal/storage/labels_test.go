@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		input string
+		want  LabelSelector
+		fails bool
+	}{
+		{"", nil, false},
+		{"env=prod", LabelSelector{{"env", selectorEquals, "prod"}}, false},
+		{"env==prod", LabelSelector{{"env", selectorEquals, "prod"}}, false},
+		{" role != db ", LabelSelector{{"role", selectorNotEquals, "db"}}, false},
+		{"gpu,!legacy", LabelSelector{{"gpu", selectorExists, ""}, {"legacy", selectorNotExists, ""}}, false},
+		{"env=prod,,team/infra", LabelSelector{{"env", selectorEquals, "prod"}, {"team/infra", selectorExists, ""}}, false},
+		{"=prod", nil, true},
+		{"env prod", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLabelSelector(tt.input)
+			if tt.fails {
+				if _, ok := err.(*SelectorError); !ok {
+					t.Errorf("erro = %v, esperado SelectorError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("seletor = %+v, esperado %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelSelectorQuery(t *testing.T) {
+	s := newTestStorage(t)
+	machines := map[string]map[string]string{
+		"web-01": {"env": "prod", "role": "web"},
+		"web-02": {"env": "staging", "role": "web"},
+		"db-01":  {"env": "prod", "role": "db", "gpu": ""},
+		"old-01": {"legacy": "yes"},
+	}
+	i := 0
+	for hostname, labels := range machines {
+		i++
+		if _, err := s.SaveMetrics(&MetricPayload{
+			MachineUUID: fmt.Sprintf("8a3c9f1e-0000-4000-8000-%012d", i),
+			Hostname:    hostname,
+			IP:          "10.0.0.1",
+			Labels:      labels,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"", []string{"db-01", "old-01", "web-01", "web-02"}},
+		{"env=prod", []string{"db-01", "web-01"}},
+		{"env!=prod", []string{"old-01", "web-02"}},
+		{"gpu", []string{"db-01"}},
+		{"!legacy,role=web", []string{"web-01", "web-02"}},
+		{"env=prod,role=db", []string{"db-01"}},
+		{"env=dev", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found, err := s.GetMachinesWithMetrics(nil, MachineFilter{Selector: selector})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, m := range found {
+				got = append(got, m.Hostname)
+				// A consulta SQL e Matches precisam concordar
+				if !selector.Matches(m.Labels) {
+					t.Errorf("%s retornada pela consulta mas recusada por Matches", m.Hostname)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("máquinas = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAgentAndAPILabels(t *testing.T) {
+	s := newTestStorage(t)
+	const uuid = "8a3c9f1e-0000-4000-8000-000000000001"
+	send := func(labels map[string]string) int64 {
+		result, err := s.SaveMetrics(&MetricPayload{MachineUUID: uuid, Hostname: "web-01", IP: "10.0.0.1", Labels: labels})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.MachineID
+	}
+	id := send(map[string]string{"env": "staging", "role": "web"})
+	value := func(v string) *string { return &v }
+
+	tests := []struct {
+		name   string
+		api    map[string]*string // alteração pela API (nil: nenhuma)
+		agent  map[string]string  // envio do agent depois (nil: nenhum)
+		labels map[string]string
+	}{
+		{"labels do agent", nil, nil, map[string]string{"env": "staging", "role": "web"}},
+		{"API sobrescreve chave do agent", map[string]*string{"env": value("prod")}, nil, map[string]string{"env": "prod", "role": "web"}},
+		{"envio do agent mantém o label da API", nil, map[string]string{"env": "staging", "role": "web"}, map[string]string{"env": "prod", "role": "web"}},
+		{"agent remove os seus labels", nil, map[string]string{}, map[string]string{"env": "prod"}},
+		{"API remove label", map[string]*string{"env": nil}, nil, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.api != nil {
+				if err := s.UpdateMachine(id, MachineUpdate{Labels: tt.api}, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.agent != nil {
+				send(tt.agent)
+			}
+
+			m, err := s.GetMachineByID(id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := m.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			if !reflect.DeepEqual(labels, tt.labels) {
+				t.Errorf("labels = %v, esperado %v", labels, tt.labels)
+			}
+		})
+	}
+}
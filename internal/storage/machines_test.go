@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+// saveTestMetrics grava um envio do agent para a máquina informada e retorna seu ID
+func saveTestMetrics(t *testing.T, s *Storage, uuid, hostname, group string) int64 {
+	t.Helper()
+	result, err := s.SaveMetrics(&MetricPayload{MachineUUID: uuid, Hostname: hostname, IP: "10.0.0.1", GroupName: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.MachineID
+}
+
+func TestUpdateMachine(t *testing.T) {
+	s := newTestStorage(t)
+	const uuid = "8a3c9f1e-0000-4000-8000-000000000001"
+	id := saveTestMetrics(t, s, uuid, "web-01", "web")
+	text := func(v string) *string { return &v }
+	flag := func(v bool) *bool { return &v }
+
+	tests := []struct {
+		name     string
+		update   MachineUpdate
+		report   string // grupo de um envio do agent depois da alteração ("-": sem envio)
+		display  string
+		group    string
+		archived bool
+	}{
+		{"renomear", MachineUpdate{DisplayName: text("Servidor web")}, "-", "Servidor web", "web", false},
+		{"envio do agent muda o grupo não fixado", MachineUpdate{}, "web-novo", "Servidor web", "web-novo", false},
+		{"regrupar fixa o grupo", MachineUpdate{GroupName: text("producao")}, "web", "Servidor web", "producao", false},
+		{"nome vazio volta ao hostname", MachineUpdate{DisplayName: text("")}, "-", "", "producao", false},
+		{"desativar", MachineUpdate{Archived: flag(true)}, "-", "", "producao", true},
+		{"envio do agent reativa", MachineUpdate{}, "web", "", "producao", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.UpdateMachine(id, tt.update, nil); err != nil {
+				t.Fatal(err)
+			}
+			if tt.report != "-" {
+				saveTestMetrics(t, s, uuid, "web-01", tt.report)
+			}
+
+			m, err := s.GetMachineByID(id, nil)
+			if err != nil || m == nil {
+				t.Fatalf("GetMachineByID = %v, %v", m, err)
+			}
+			if m.DisplayName != tt.display || m.GroupName != tt.group || (m.ArchivedAt != nil) != tt.archived {
+				t.Errorf("máquina = {%q %q arquivada:%v}, esperado {%q %q arquivada:%v}",
+					m.DisplayName, m.GroupName, m.ArchivedAt != nil, tt.display, tt.group, tt.archived)
+			}
+		})
+	}
+}
+
+func TestUpdateMachineArchivedHidden(t *testing.T) {
+	s := newTestStorage(t)
+	id := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+	archived := true
+	if err := s.UpdateMachine(id, MachineUpdate{Archived: &archived}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter MachineFilter
+		count  int
+	}{
+		{"oculta por padrão", MachineFilter{}, 0},
+		{"incluindo arquivadas", MachineFilter{IncludeArchived: true}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machines, err := s.GetMachinesWithMetrics(nil, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(machines) != tt.count {
+				t.Errorf("%d máquinas, esperado %d", len(machines), tt.count)
+			}
+		})
+	}
+}
+
+func TestDeleteMachine(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   *Scope
+		missing bool
+		deleted bool
+	}{
+		{"sem escopo", nil, false, true},
+		{"grupo no escopo", &Scope{Groups: []string{"web"}}, false, true},
+		{"fora do escopo", &Scope{Groups: []string{"db"}}, false, false},
+		{"inexistente", nil, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			id := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+			if tt.missing {
+				id += 100
+			}
+
+			err := s.DeleteMachine(id, tt.scope)
+			if tt.deleted {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if n := countRows(t, s, "metrics", "machine_id = ?", id); n != 0 {
+					t.Errorf("%d métricas restantes", n)
+				}
+				return
+			}
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("erro = %v, esperado ErrNotFound", err)
+			}
+			if n := countRows(t, s, "machines", "1"); n != 1 {
+				t.Errorf("%d máquinas restantes, esperado 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import "strings"
+
+// Papéis de usuário, do mais restrito ao mais amplo
+const (
+	RoleViewer = "viewer" // vê as máquinas dos seus grupos
+	RoleEditor = "editor" // viewer + altera as máquinas dos seus grupos
+	RoleAdmin  = "admin"  // acesso total, inclusive usuários e credenciais
+)
+
+// ValidRole indica se o papel é conhecido
+func ValidRole(role string) bool {
+	return role == RoleViewer || role == RoleEditor || role == RoleAdmin
+}
+
+// Scope limita as consultas aos grupos de máquinas que o autor do request pode acessar.
+// Um *Scope nil dá acesso a todos os grupos; um Scope sem grupos não dá acesso a nenhum.
+type Scope struct {
+	Groups []string
+}
+
+// Allows indica se o grupo está dentro do escopo
+func (sc *Scope) Allows(group string) bool {
+	if sc == nil {
+		return true
+	}
+	for _, g := range sc.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// condition retorna a condição SQL (e os argumentos) que restringe column aos grupos do escopo
+func (sc *Scope) condition(column string) (string, []interface{}) {
+	if sc == nil {
+		return "1", nil
+	}
+	if len(sc.Groups) == 0 {
+		return "0", nil
+	}
+
+	args := make([]interface{}, len(sc.Groups))
+	for i, g := range sc.Groups {
+		args[i] = g
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(sc.Groups)), ",")
+	return column + " IN (" + placeholders + ")", args
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUserScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    User
+		allowed map[string]bool // grupo → acesso esperado
+	}{
+		{"administrador vê todos os grupos", User{Role: RoleAdmin}, map[string]bool{"web": true, "db": true}},
+		{"leitor com grupos", User{Role: RoleViewer, Groups: []string{"web"}}, map[string]bool{"web": true, "db": false}},
+		{"editor com grupos", User{Role: RoleEditor, Groups: []string{"web", "db"}}, map[string]bool{"web": true, "db": true, "cache": false}},
+		{"leitor sem grupos não vê nenhum", User{Role: RoleViewer}, map[string]bool{"web": false, "": false}},
+		{"editor sem grupos não vê nenhum", User{Role: RoleEditor}, map[string]bool{"web": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := tt.user.Scope()
+			for group, want := range tt.allowed {
+				if got := scope.Allows(group); got != want {
+					t.Errorf("Allows(%q) = %v, esperado %v", group, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetMachinesWithMetricsScope(t *testing.T) {
+	s := newTestStorage(t)
+	for i, group := range []string{"web", "web", "db"} {
+		if _, err := s.SaveMetrics(&MetricPayload{
+			MachineUUID: fmt.Sprintf("8a3c9f1e-0000-4000-8000-00000000000%d", i+1),
+			Hostname:    fmt.Sprintf("host-%d", i+1),
+			IP:          "10.0.0.1",
+			GroupName:   group,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		scope *Scope
+		count int
+	}{
+		{"sem escopo", nil, 3},
+		{"um grupo", &Scope{Groups: []string{"web"}}, 2},
+		{"dois grupos", &Scope{Groups: []string{"web", "db"}}, 3},
+		{"grupo sem máquinas", &Scope{Groups: []string{"cache"}}, 0},
+		{"escopo vazio", &Scope{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			machines, err := s.GetMachinesWithMetrics(tt.scope, MachineFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(machines) != tt.count {
+				t.Errorf("%d máquinas, esperado %d", len(machines), tt.count)
+			}
+		})
+	}
+}
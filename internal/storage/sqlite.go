@@ -2,12 +2,17 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrNotFound indica que o registro solicitado não existe
+var ErrNotFound = errors.New("registro não encontrado")
+
 // Storage representa a conexão com o banco de dados SQLite
 type Storage struct {
 	db            *sql.DB
@@ -17,6 +22,7 @@ type Storage struct {
 // Machine representa uma máquina cadastrada
 type Machine struct {
 	ID        int64     `json:"id"`
+	UUID      string    `json:"uuid,omitempty"`
 	Hostname  string    `json:"hostname"`
 	IP        string    `json:"ip"`
 	GroupName string    `json:"group"`
@@ -25,6 +31,38 @@ type Machine struct {
 	LastSeen  time.Time `json:"last_seen"`
 	IsOnline  bool      `json:"is_online"`
 	Metrics   *Metrics  `json:"metrics,omitempty"`
+
+	// Estado calculado pelo servidor (online, warning, critical ou offline) e os limites
+	// aplicados à máquina (padrão, do grupo ou da própria máquina; ver thresholds.go)
+	Status     string     `json:"status"`
+	Thresholds Thresholds `json:"thresholds"`
+
+	// Dados definidos pela API de gerenciamento (não mudam com os envios do agent)
+	DisplayName string     `json:"display_name,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	GroupPinned bool       `json:"group_pinned"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+
+	// Labels livres (chave=valor) enviados pelo agent ou definidos pela API
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Diferença medida entre o relógio do agent e o do servidor (positivo = agent adiantado)
+	ClockSkew  *float64 `json:"clock_skew_seconds,omitempty"`
+	ClockDrift bool     `json:"clock_drift"`
+
+	// Outra máquina online (UUID diferente) está reportando o mesmo hostname
+	HostnameConflict bool `json:"hostname_conflict"`
+
+	// Payloads da máquina recusados pela validação do servidor
+	RejectedPayloads   int        `json:"rejected_payloads"`
+	LastRejectedAt     *time.Time `json:"last_rejected_at,omitempty"`
+	LastRejectedReason string     `json:"last_rejected_reason,omitempty"`
+
+	// Métricas fora do padrão da máquina no último relatório (ver anomalies.go)
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+
+	// Projeção do uso de disco (apenas em GET /api/machines/{id}; ver forecast.go)
+	DiskForecast *DiskForecast `json:"disk_forecast,omitempty"`
 }
 
 // Metrics representa as métricas coletadas
@@ -34,19 +72,44 @@ type Metrics struct {
 	DiskPercent   float64 `json:"disk_percent"`
 	DockerRunning int     `json:"docker_running"`
 	DockerStopped int     `json:"docker_stopped"`
+
+	*MetricAggregates
+}
+
+// MetricAggregates resume as amostras de alta resolução feitas pelo agent entre dois relatórios.
+// Quando presentes, cpu/memory/disk_percent são a média do período.
+type MetricAggregates struct {
+	SampleCount int     `json:"sample_count,omitempty"`
+	CPUMin      float64 `json:"cpu_min,omitempty"`
+	CPUMax      float64 `json:"cpu_max,omitempty"`
+	CPUP95      float64 `json:"cpu_p95,omitempty"`
+	MemoryMin   float64 `json:"memory_min,omitempty"`
+	MemoryMax   float64 `json:"memory_max,omitempty"`
+	MemoryP95   float64 `json:"memory_p95,omitempty"`
+	DiskMin     float64 `json:"disk_min,omitempty"`
+	DiskMax     float64 `json:"disk_max,omitempty"`
+	DiskP95     float64 `json:"disk_p95,omitempty"`
 }
 
 // MetricPayload representa o payload recebido do agent
 type MetricPayload struct {
-	Hostname      string  `json:"hostname"`
-	IP            string  `json:"ip"`
-	GroupName     string  `json:"group"`
-	SwarmRole     string  `json:"swarm_role"`
-	CPUPercent    float64 `json:"cpu_percent"`
-	MemoryPercent float64 `json:"memory_percent"`
-	DiskPercent   float64 `json:"disk_percent"`
-	DockerRunning int     `json:"docker_running"`
-	DockerStopped int     `json:"docker_stopped"`
+	MachineUUID   string    `json:"machine_uuid"` // identidade estável do agent (opcional em agents antigos)
+	Hostname      string    `json:"hostname"`
+	IP            string    `json:"ip"`
+	GroupName     string    `json:"group"`
+	SwarmRole     string    `json:"swarm_role"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	DiskPercent   float64   `json:"disk_percent"`
+	DockerRunning int       `json:"docker_running"`
+	DockerStopped int       `json:"docker_stopped"`
+	CollectedAt   time.Time `json:"collected_at"` // opcional: horário da coleta no agent
+	SentAt        time.Time `json:"sent_at"`      // opcional: horário do envio no agent (mede o clock skew)
+
+	// Labels do agent (--label); ausente em agents antigos, que mantêm os labels atuais
+	Labels map[string]string `json:"labels,omitempty"`
+
+	MetricAggregates
 }
 
 // parseDateTime tenta fazer parse de datetime em múltiplos formatos do SQLite
@@ -73,6 +136,12 @@ func parseDateTime(s string) time.Time {
 	return time.Time{}
 }
 
+// formatDateTime formata um horário no mesmo formato UTC usado por CURRENT_TIMESTAMP,
+// mantendo as comparações com datetime('now', ...) consistentes
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
 // New cria uma nova conexão com o banco de dados
 func New(dbPath string, retentionDays int) (*Storage, error) {
 	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL")
@@ -102,10 +171,11 @@ func New(dbPath string, retentionDays int) (*Storage, error) {
 // initSchema cria as tabelas se não existirem
 func (s *Storage) initSchema() error {
 	schema := `
-	-- Máquinas cadastradas automaticamente
+	-- Máquinas cadastradas automaticamente (identidade: uuid do agent)
 	CREATE TABLE IF NOT EXISTS machines (
 		id          INTEGER PRIMARY KEY AUTOINCREMENT,
-		hostname    TEXT UNIQUE NOT NULL,
+		uuid        TEXT UNIQUE,
+		hostname    TEXT NOT NULL,
 		ip          TEXT,
 		group_name  TEXT DEFAULT 'default',
 		swarm_role  TEXT DEFAULT 'none',
@@ -126,63 +196,604 @@ func (s *Storage) initSchema() error {
 		FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
 	);
 
+	-- Credenciais próprias de cada agent (apenas o hash do token é guardado)
+	CREATE TABLE IF NOT EXISTS agent_credentials (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		machine_uuid  TEXT NOT NULL,
+		hostname      TEXT,
+		token_hash    TEXT UNIQUE NOT NULL,
+		token_prefix  TEXT NOT NULL,
+		created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+		rotated_at    DATETIME,
+		last_used_at  DATETIME,
+		revoked_at    DATETIME
+	);
+
+	-- Usuários do dashboard e da API de leitura
+	CREATE TABLE IF NOT EXISTS users (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		username       TEXT UNIQUE NOT NULL,
+		password_hash  TEXT NOT NULL,
+		created_at     DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_login_at  DATETIME
+	);
+
+	-- Sessões do dashboard (apenas o hash do cookie é guardado)
+	CREATE TABLE IF NOT EXISTS sessions (
+		token_hash  TEXT PRIMARY KEY,
+		user_id     INTEGER NOT NULL,
+		created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at  DATETIME NOT NULL,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	-- Chaves de API para leitura via bearer token
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id       INTEGER,
+		name          TEXT NOT NULL,
+		key_hash      TEXT UNIQUE NOT NULL,
+		key_prefix    TEXT NOT NULL,
+		created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_used_at  DATETIME,
+		revoked_at    DATETIME,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	-- Grupos de máquinas visíveis para cada usuário (sem linhas = nenhum grupo, exceto para admin)
+	CREATE TABLE IF NOT EXISTS user_groups (
+		user_id     INTEGER NOT NULL,
+		group_name  TEXT NOT NULL,
+		PRIMARY KEY (user_id, group_name),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	-- Labels livres das máquinas (source: agent ou api; os da API prevalecem)
+	CREATE TABLE IF NOT EXISTS machine_labels (
+		machine_id  INTEGER NOT NULL,
+		key         TEXT NOT NULL,
+		value       TEXT NOT NULL,
+		source      TEXT NOT NULL,
+		PRIMARY KEY (machine_id, key),
+		FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+	);
+
+	-- Visões salvas do dashboard (user_id NULL = criada com o AUTH_TOKEN ou no modo aberto)
+	CREATE TABLE IF NOT EXISTS saved_views (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		name        TEXT NOT NULL,
+		user_id     INTEGER,
+		shared      BOOLEAN NOT NULL DEFAULT 0,
+		filters     TEXT NOT NULL,
+		created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	-- Limites de atenção e crítico por métrica: padrão (group_name e machine_id NULL), por grupo
+	-- ou por máquina; o mais específico prevalece
+	CREATE TABLE IF NOT EXISTS thresholds (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		metric      TEXT NOT NULL,
+		group_name  TEXT,
+		machine_id  INTEGER,
+		warning     REAL NOT NULL,
+		critical    REAL NOT NULL,
+		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+	);
+
+	-- Mudanças de estado das máquinas (ex.: online -> offline), para os relatórios de disponibilidade
+	CREATE TABLE IF NOT EXISTS status_events (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		machine_id   INTEGER NOT NULL,
+		from_status  TEXT NOT NULL,
+		to_status    TEXT NOT NULL,
+		created_at   DATETIME NOT NULL,
+		FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+	);
+
+	-- Avisos sobre as máquinas (conflito de hostname, relógio, payload recusado); args são os
+	-- argumentos da mensagem, em JSON
+	CREATE TABLE IF NOT EXISTS alerts (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		machine_id  INTEGER NOT NULL,
+		kind        TEXT NOT NULL,
+		args        TEXT NOT NULL DEFAULT '[]',
+		created_at  DATETIME NOT NULL,
+		FOREIGN KEY (machine_id) REFERENCES machines(id) ON DELETE CASCADE
+	);
+
+	-- Log de auditoria (somente inserção; UPDATE e DELETE são bloqueados por triggers, e a
+	-- retenção passa por ArchiveAudit)
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at    DATETIME NOT NULL,
+		actor         TEXT NOT NULL,
+		action        TEXT NOT NULL,
+		target        TEXT,
+		ip            TEXT,
+		before_state  TEXT,
+		after_state   TEXT,
+		details       TEXT
+	);
+
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log é somente inserção');
+	END;
+
+	` + auditNoDeleteTrigger + `
+
 	-- Índices para performance
+	CREATE INDEX IF NOT EXISTS idx_agent_credentials_uuid ON agent_credentials(machine_uuid);
 	CREATE INDEX IF NOT EXISTS idx_metrics_machine_time ON metrics(machine_id, collected_at DESC);
 	CREATE INDEX IF NOT EXISTS idx_machines_hostname ON machines(hostname);
 	CREATE INDEX IF NOT EXISTS idx_metrics_collected ON metrics(collected_at);
+	CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+	CREATE INDEX IF NOT EXISTS idx_machine_labels_key ON machine_labels(key, value);
+	CREATE INDEX IF NOT EXISTS idx_status_events_machine_time ON status_events(machine_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_alerts_created ON alerts(created_at);
+	CREATE INDEX IF NOT EXISTS idx_alerts_machine ON alerts(machine_id, id);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return s.migrate()
 }
 
-// UpsertMachine cria ou atualiza uma máquina e retorna seu ID
-func (s *Storage) UpsertMachine(hostname, ip, groupName, swarmRole string) (int64, error) {
-	// Primeiro, tenta inserir ou atualizar
-	_, err := s.db.Exec(`
-		INSERT INTO machines (hostname, ip, group_name, swarm_role, last_seen)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(hostname) DO UPDATE SET
-			ip = excluded.ip,
-			group_name = COALESCE(NULLIF(excluded.group_name, ''), group_name),
-			swarm_role = excluded.swarm_role,
-			last_seen = CURRENT_TIMESTAMP
-	`, hostname, ip, groupName, swarmRole)
+// migrate aplica alterações de schema em bancos criados por versões anteriores
+func (s *Storage) migrate() error {
+	// Clock skew medido no último envio de cada máquina
+	if err := s.addColumnIfMissing("machines", "clock_skew", "REAL"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("machines", "clock_skew_at", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Agregados da amostragem de alta resolução (NULL quando o agent não amostra)
+	for _, column := range []string{
+		"cpu_min", "cpu_max", "cpu_p95",
+		"memory_min", "memory_max", "memory_p95",
+		"disk_min", "disk_max", "disk_p95",
+	} {
+		if err := s.addColumnIfMissing("metrics", column, "REAL"); err != nil {
+			return err
+		}
+	}
+	if err := s.addColumnIfMissing("metrics", "sample_count", "INTEGER"); err != nil {
+		return err
+	}
+
+	// Papel dos usuários; os usuários existentes continuam administradores
+	if err := s.addColumnIfMissing("users", "role", "TEXT NOT NULL DEFAULT 'admin'"); err != nil {
+		return err
+	}
+
+	// Idioma preferido dos usuários (vazio = negociado pelo Accept-Language)
+	if err := s.addColumnIfMissing("users", "language", "TEXT"); err != nil {
+		return err
+	}
+
+	// Identidade por UUID: bancos antigos têm hostname UNIQUE e precisam recriar a tabela
+	if err := s.migrateMachineIdentity(); err != nil {
+		return err
+	}
+
+	// Contador de payloads recusados pela validação (depois da recriação da tabela machines)
+	if err := s.addColumnIfMissing("machines", "rejected_payloads", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("machines", "last_rejected_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := s.addColumnIfMissing("machines", "last_rejected_reason", "TEXT"); err != nil {
+		return err
+	}
+
+	// Gerenciamento: nome de exibição, notas, grupo fixado pela API e arquivamento
+	for _, col := range []struct{ name, definition string }{
+		{"display_name", "TEXT"},
+		{"notes", "TEXT"},
+		{"group_pinned", "BOOLEAN NOT NULL DEFAULT 0"},
+		{"archived_at", "DATETIME"},
+	} {
+		if err := s.addColumnIfMissing("machines", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+
+	// Deduplicação por (máquina, horário de coleta): roda uma vez, registrada em user_version
+	return s.migrateMetricsUnique()
+}
+
+// schemaVersionMetricsUnique é a versão do schema (PRAGMA user_version) a partir da qual as
+// métricas têm o índice único por (máquina, horário de coleta)
+const schemaVersionMetricsUnique = 1
+
+// migrateMetricsUnique remove as métricas duplicadas de versões anteriores e cria o índice
+// único. A varredura da tabela roda só em bancos com user_version anterior.
+func (s *Storage) migrateMetricsUnique() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("erro ao ler versão do schema: %w", err)
+	}
+	if version >= schemaVersionMetricsUnique {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM metrics WHERE id NOT IN (
+			SELECT MIN(id) FROM metrics GROUP BY machine_id, collected_at
+		)
+	`); err != nil {
+		return fmt.Errorf("erro ao remover métricas duplicadas: %w", err)
+	}
+	if _, err := tx.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_metrics_machine_collected_unique ON metrics(machine_id, collected_at)
+	`); err != nil {
+		return fmt.Errorf("erro ao criar índice de deduplicação: %w", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersionMetricsUnique)); err != nil {
+		return fmt.Errorf("erro ao gravar versão do schema: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateMachineIdentity recria a tabela machines sem UNIQUE em hostname e com a coluna uuid.
+// Segue o procedimento recomendado pelo SQLite para alterar constraints (nova tabela + cópia).
+func (s *Storage) migrateMachineIdentity() error {
+	hasUUID, err := s.hasColumn("machines", "uuid")
+	if err != nil || hasUUID {
+		return err
+	}
+
+	// Chaves estrangeiras desligadas para o DROP não apagar as métricas em cascata
+	if _, err := s.db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer s.db.Exec("PRAGMA foreign_keys = ON")
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE TABLE machines_new (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			uuid          TEXT UNIQUE,
+			hostname      TEXT NOT NULL,
+			ip            TEXT,
+			group_name    TEXT DEFAULT 'default',
+			swarm_role    TEXT DEFAULT 'none',
+			first_seen    DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen     DATETIME DEFAULT CURRENT_TIMESTAMP,
+			clock_skew    REAL,
+			clock_skew_at DATETIME
+		)`,
+		`INSERT INTO machines_new (id, hostname, ip, group_name, swarm_role, first_seen, last_seen, clock_skew, clock_skew_at)
+			SELECT id, hostname, ip, group_name, swarm_role, first_seen, last_seen, clock_skew, clock_skew_at FROM machines`,
+		`DROP TABLE machines`,
+		`ALTER TABLE machines_new RENAME TO machines`,
+		`CREATE INDEX IF NOT EXISTS idx_machines_hostname ON machines(hostname)`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("erro ao migrar identidade das máquinas: %w", err)
+		}
+	}
 
+	return tx.Commit()
+}
+
+// hasColumn verifica se uma tabela possui a coluna informada
+func (s *Storage) hasColumn(table, column string) (bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
-		return 0, fmt.Errorf("erro ao upsert máquina: %w", err)
+		return false, fmt.Errorf("erro ao ler schema de %s: %w", table, err)
 	}
+	defer rows.Close()
 
-	// Sempre busca o ID pelo hostname (mais confiável que LastInsertId com UPSERT)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// addColumnIfMissing adiciona uma coluna a uma tabela existente, se ela ainda não existir
+func (s *Storage) addColumnIfMissing(table, column, definition string) error {
+	exists, err := s.hasColumn(table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("erro ao adicionar coluna %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
+
+// execer abstrai *sql.DB e *sql.Tx, permitindo reutilizar as escritas dentro de transações
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// SaveResult representa o resultado da gravação de um payload
+type SaveResult struct {
+	MachineID        int64
+	Duplicate        bool
+	HostnameConflict bool
+}
+
+// UpsertMachine cria ou atualiza uma máquina e retorna seu ID.
+// Com uuid, a máquina é identificada por ele (hostname pode mudar); sem uuid
+// (agents antigos), pelo hostname. Um grupo fixado pela API prevalece sobre o do agent,
+// e uma máquina arquivada que volta a enviar métricas é desarquivada.
+func (s *Storage) UpsertMachine(uuid, hostname, ip, groupName, swarmRole string) (int64, error) {
+	return upsertMachine(s.db, uuid, hostname, ip, groupName, swarmRole)
+}
+
+func upsertMachine(ex execer, uuid, hostname, ip, groupName, swarmRole string) (int64, error) {
+	machineID, err := findMachineID(ex, uuid, hostname)
+	if err != nil {
+		return 0, err
+	}
+
+	if machineID == 0 {
+		var uuidValue interface{}
+		if uuid != "" {
+			uuidValue = uuid
+		}
+
+		result, err := ex.Exec(`
+			INSERT INTO machines (uuid, hostname, ip, group_name, swarm_role, last_seen)
+			VALUES (?, ?, ?, COALESCE(NULLIF(?, ''), 'default'), ?, CURRENT_TIMESTAMP)
+		`, uuidValue, hostname, ip, groupName, swarmRole)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao inserir máquina '%s': %w", hostname, err)
+		}
+		return result.LastInsertId()
+	}
+
+	_, err = ex.Exec(`
+		UPDATE machines SET
+			uuid = COALESCE(NULLIF(?, ''), uuid),
+			hostname = ?,
+			ip = ?,
+			group_name = CASE WHEN group_pinned THEN group_name ELSE COALESCE(NULLIF(?, ''), group_name) END,
+			swarm_role = ?,
+			last_seen = CURRENT_TIMESTAMP,
+			archived_at = NULL
+		WHERE id = ?
+	`, uuid, hostname, ip, groupName, swarmRole, machineID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao atualizar máquina '%s': %w", hostname, err)
+	}
+
+	return machineID, nil
+}
+
+// findMachineID localiza a máquina de um payload (0 se for nova):
+// pelo uuid; se não existir, adota um registro antigo (sem uuid) com o mesmo hostname
+func findMachineID(ex execer, uuid, hostname string) (int64, error) {
 	var machineID int64
-	err = s.db.QueryRow("SELECT id FROM machines WHERE hostname = ?", hostname).Scan(&machineID)
+
+	if uuid != "" {
+		err := ex.QueryRow("SELECT id FROM machines WHERE uuid = ?", uuid).Scan(&machineID)
+		if err == nil {
+			return machineID, nil
+		}
+		if err != sql.ErrNoRows {
+			return 0, fmt.Errorf("erro ao buscar máquina '%s': %w", uuid, err)
+		}
+	}
+
+	err := ex.QueryRow(`
+		SELECT id FROM machines WHERE hostname = ? AND uuid IS NULL
+		ORDER BY last_seen DESC LIMIT 1
+	`, hostname).Scan(&machineID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
 	if err != nil {
-		return 0, fmt.Errorf("erro ao buscar ID da máquina '%s': %w", hostname, err)
+		return 0, fmt.Errorf("erro ao buscar máquina '%s': %w", hostname, err)
 	}
 
 	return machineID, nil
 }
 
-// InsertMetrics insere novas métricas para uma máquina
-func (s *Storage) InsertMetrics(machineID int64, m *Metrics) error {
+// HostnameOwnsUUID informa se o machine_uuid pode enviar métricas com o hostname de um
+// certificado de cliente: a máquina do uuid precisa ter esse hostname, e um uuid novo só é
+// aceito se nenhuma máquina com o hostname já estiver ligada a um uuid
+func (s *Storage) HostnameOwnsUUID(hostname, uuid string) (bool, error) {
+	var owns bool
+	err := s.db.QueryRow(`
+		SELECT CASE
+			WHEN EXISTS (SELECT 1 FROM machines WHERE uuid = ? COLLATE NOCASE)
+			THEN EXISTS (SELECT 1 FROM machines WHERE uuid = ? COLLATE NOCASE AND hostname = ? COLLATE NOCASE)
+			ELSE NOT EXISTS (SELECT 1 FROM machines WHERE hostname = ? COLLATE NOCASE AND uuid IS NOT NULL)
+		END
+	`, uuid, uuid, hostname, hostname).Scan(&owns)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar máquina do hostname '%s': %w", hostname, err)
+	}
+	return owns, nil
+}
+
+// hasHostnameConflict verifica se outra máquina online reporta o mesmo hostname
+func hasHostnameConflict(ex execer, machineID int64, hostname string) (bool, error) {
+	var conflict bool
+	err := ex.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM machines
+			WHERE hostname = ? AND id <> ? AND last_seen > `+onlineSince+`
+		)
+	`, hostname, machineID).Scan(&conflict)
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar conflito de hostname: %w", err)
+	}
+	return conflict, nil
+}
+
+// InsertMetrics insere novas métricas para uma máquina.
+// Se collectedAt for zero, usa o horário atual do servidor. Retorna false
+// quando já existe uma amostra da máquina com o mesmo horário (reenvio).
+func (s *Storage) InsertMetrics(machineID int64, m *Metrics, collectedAt time.Time) (bool, error) {
+	return insertMetrics(s.db, machineID, m, collectedAt)
+}
+
+func insertMetrics(ex execer, machineID int64, m *Metrics, collectedAt time.Time) (bool, error) {
+	if collectedAt.IsZero() {
+		collectedAt = time.Now()
+	}
+
+	// Agregados ficam NULL quando o relatório não traz amostragem
+	agg := make([]interface{}, 10)
+	if a := m.MetricAggregates; a != nil && a.SampleCount > 0 {
+		agg = []interface{}{
+			a.SampleCount,
+			a.CPUMin, a.CPUMax, a.CPUP95,
+			a.MemoryMin, a.MemoryMax, a.MemoryP95,
+			a.DiskMin, a.DiskMax, a.DiskP95,
+		}
+	}
+
+	args := append([]interface{}{
+		machineID, formatDateTime(collectedAt),
+		m.CPUPercent, m.MemoryPercent, m.DiskPercent, m.DockerRunning, m.DockerStopped,
+	}, agg...)
+
+	result, err := ex.Exec(`
+		INSERT OR IGNORE INTO metrics (
+			machine_id, collected_at,
+			cpu_percent, memory_percent, disk_percent, docker_running, docker_stopped,
+			sample_count,
+			cpu_min, cpu_max, cpu_p95,
+			memory_min, memory_max, memory_p95,
+			disk_min, disk_max, disk_p95
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, args...)
+
+	if err != nil {
+		return false, fmt.Errorf("erro ao inserir métricas (machine_id=%d): %w", machineID, err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return inserted > 0, nil
+}
+
+// RecordClockSkew registra a diferença de relógio medida no último envio da máquina
+func (s *Storage) RecordClockSkew(machineID int64, skewSeconds float64) error {
 	_, err := s.db.Exec(`
-		INSERT INTO metrics (machine_id, cpu_percent, memory_percent, disk_percent, docker_running, docker_stopped)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, machineID, m.CPUPercent, m.MemoryPercent, m.DiskPercent, m.DockerRunning, m.DockerStopped)
+		UPDATE machines SET clock_skew = ?, clock_skew_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, skewSeconds, machineID)
 
 	if err != nil {
-		return fmt.Errorf("erro ao inserir métricas (machine_id=%d): %w", machineID, err)
+		return fmt.Errorf("erro ao registrar clock skew (machine_id=%d): %w", machineID, err)
 	}
 
 	return nil
 }
 
+// RecordRejectedPayload conta um payload recusado pela validação para a máquina identificada
+// pelo uuid (ou, sem uuid, pelo hostname). Retorna o ID da máquina, ou 0 se ela ainda não existe.
+func (s *Storage) RecordRejectedPayload(uuid, hostname, reason string) (int64, error) {
+	machineID, err := findMachineID(s.db, uuid, hostname)
+	if err != nil || machineID == 0 {
+		return 0, err
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE machines SET
+			rejected_payloads = rejected_payloads + 1,
+			last_rejected_at = CURRENT_TIMESTAMP,
+			last_rejected_reason = ?
+		WHERE id = ?
+	`, reason, machineID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao registrar payload recusado (machine_id=%d): %w", machineID, err)
+	}
+
+	return machineID, nil
+}
+
 // SaveMetrics salva métricas completas (upsert machine + insert metrics)
-func (s *Storage) SaveMetrics(payload *MetricPayload) (int64, error) {
+func (s *Storage) SaveMetrics(payload *MetricPayload) (SaveResult, error) {
+	return saveMetrics(s.db, payload)
+}
+
+// SaveMetricsBatch salva vários payloads em uma única transação.
+// Qualquer erro de banco desfaz o lote inteiro.
+func (s *Storage) SaveMetricsBatch(payloads []*MetricPayload) ([]SaveResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]SaveResult, len(payloads))
+	for i, payload := range payloads {
+		result, err := saveMetrics(tx, payload)
+		if err != nil {
+			return nil, fmt.Errorf("item %d (%s): %w", i, payload.Hostname, err)
+		}
+		results[i] = result
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("erro ao confirmar transação: %w", err)
+	}
+
+	return results, nil
+}
+
+func saveMetrics(ex execer, payload *MetricPayload) (SaveResult, error) {
 	// Upsert da máquina
-	machineID, err := s.UpsertMachine(payload.Hostname, payload.IP, payload.GroupName, payload.SwarmRole)
+	machineID, err := upsertMachine(ex, payload.MachineUUID, payload.Hostname, payload.IP, payload.GroupName, payload.SwarmRole)
 	if err != nil {
-		return 0, err
+		return SaveResult{}, err
+	}
+
+	conflict, err := hasHostnameConflict(ex, machineID, payload.Hostname)
+	if err != nil {
+		return SaveResult{}, err
+	}
+
+	if payload.Labels != nil {
+		if err := syncAgentLabels(ex, machineID, payload.Labels); err != nil {
+			return SaveResult{}, err
+		}
 	}
 
 	// Inserir métricas
@@ -193,25 +804,151 @@ func (s *Storage) SaveMetrics(payload *MetricPayload) (int64, error) {
 		DockerRunning: payload.DockerRunning,
 		DockerStopped: payload.DockerStopped,
 	}
+	if payload.SampleCount > 0 {
+		aggregates := payload.MetricAggregates
+		metrics.MetricAggregates = &aggregates
+	}
 
-	if err := s.InsertMetrics(machineID, metrics); err != nil {
-		return 0, err
+	inserted, err := insertMetrics(ex, machineID, metrics, payload.CollectedAt)
+	if err != nil {
+		return SaveResult{}, err
 	}
 
-	return machineID, nil
+	return SaveResult{MachineID: machineID, Duplicate: !inserted, HostnameConflict: conflict}, nil
 }
 
-// GetMachinesWithMetrics retorna todas as máquinas com suas últimas métricas
-func (s *Storage) GetMachinesWithMetrics() ([]Machine, error) {
+// onlineThreshold é o tempo sem relatórios após o qual a máquina é considerada offline
+const onlineThreshold = 70 * time.Minute // 1h + 10min de margem
+
+// onlineSince é o início da janela de onlineThreshold em SQL, comparável a last_seen
+var onlineSince = fmt.Sprintf("datetime('now', '-%d seconds')", int(onlineThreshold/time.Second))
+
+// Estados de uma máquina, como exibidos no dashboard
+const (
+	StatusOnline   = "online"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+	StatusOffline  = "offline"
+)
+
+// evaluate aplica os limites à máquina e calcula o estado: offline sem relatórios recentes,
+// critical ou warning quando alguma métrica atinge o limite correspondente e online nos demais casos
+func (m *Machine) evaluate(thresholds Thresholds) {
+	m.Thresholds = thresholds
+	m.Status = StatusOnline
+	if !m.IsOnline {
+		m.Status = StatusOffline
+		return
+	}
+	if m.Metrics == nil {
+		return
+	}
+	for metric, value := range map[string]float64{
+		MetricCPU:    m.Metrics.CPUPercent,
+		MetricMemory: m.Metrics.MemoryPercent,
+		MetricDisk:   m.Metrics.DiskPercent,
+	} {
+		limit := thresholds[metric]
+		switch {
+		case value >= limit.Critical:
+			m.Status = StatusCritical
+			return
+		case value >= limit.Warning:
+			m.Status = StatusWarning
+		}
+	}
+}
+
+// machineColumns são as colunas lidas por scanMachine (aliases m = machines, met = última métrica)
+var machineColumns = `
+	m.id, COALESCE(m.uuid, ''), m.hostname, m.ip, m.group_name, m.swarm_role,
+	m.first_seen, m.last_seen, m.clock_skew,
+	m.rejected_payloads, m.last_rejected_at, COALESCE(m.last_rejected_reason, ''),
+	COALESCE(m.display_name, ''), COALESCE(m.notes, ''), m.group_pinned, m.archived_at,
+	COALESCE((SELECT group_concat(key || '=' || value, char(10)) FROM machine_labels WHERE machine_id = m.id), ''),
+	EXISTS (
+		SELECT 1 FROM machines o
+		WHERE o.hostname = m.hostname AND o.id <> m.id
+		  AND o.last_seen > ` + onlineSince + `
+		  AND m.last_seen > ` + onlineSince + `
+	),
+	COALESCE(met.cpu_percent, 0),
+	COALESCE(met.memory_percent, 0),
+	COALESCE(met.disk_percent, 0),
+	COALESCE(met.docker_running, 0),
+	COALESCE(met.docker_stopped, 0)
+`
+
+// rowScanner abstrai *sql.Row e *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMachine lê uma linha com as colunas de machineColumns
+func scanMachine(row rowScanner) (*Machine, error) {
+	var m Machine
+	var metrics Metrics
+	var firstSeen, lastSeen string
+	var clockSkew sql.NullFloat64
+	var lastRejectedAt, archivedAt sql.NullString
+	var labels string
+
+	err := row.Scan(
+		&m.ID, &m.UUID, &m.Hostname, &m.IP, &m.GroupName, &m.SwarmRole,
+		&firstSeen, &lastSeen, &clockSkew,
+		&m.RejectedPayloads, &lastRejectedAt, &m.LastRejectedReason,
+		&m.DisplayName, &m.Notes, &m.GroupPinned, &archivedAt, &labels,
+		&m.HostnameConflict,
+		&metrics.CPUPercent, &metrics.MemoryPercent, &metrics.DiskPercent,
+		&metrics.DockerRunning, &metrics.DockerStopped,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse das datas (tentar múltiplos formatos do SQLite)
+	m.FirstSeen = parseDateTime(firstSeen)
+	m.LastSeen = parseDateTime(lastSeen)
+
+	// Determinar se está online
+	m.IsOnline = time.Since(m.LastSeen) < onlineThreshold
+
+	if clockSkew.Valid {
+		m.ClockSkew = &clockSkew.Float64
+	}
+	m.LastRejectedAt = parseNullDateTime(lastRejectedAt)
+	m.ArchivedAt = parseNullDateTime(archivedAt)
+	m.Labels = parseLabels(labels)
+
+	m.Metrics = &metrics
+	return &m, nil
+}
+
+// MachineFilter restringe a listagem de máquinas
+type MachineFilter struct {
+	IncludeArchived bool          // incluir máquinas arquivadas
+	Selector        LabelSelector // seletor de labels (vazio = todas)
+}
+
+// Matches indica se a máquina atende ao filtro
+func (f MachineFilter) Matches(m *Machine) bool {
+	if m.ArchivedAt != nil && !f.IncludeArchived {
+		return false
+	}
+	return f.Selector.Matches(m.Labels)
+}
+
+// GetMachinesWithMetrics retorna as máquinas do escopo que atendem ao filtro, com suas últimas métricas
+func (s *Storage) GetMachinesWithMetrics(scope *Scope, filter MachineFilter) ([]Machine, error) {
+	cond, args := scope.condition("m.group_name")
+	if !filter.IncludeArchived {
+		cond += " AND m.archived_at IS NULL"
+	}
+	selCond, selArgs := filter.Selector.condition("m")
+	cond += " AND " + selCond
+	args = append(args, selArgs...)
 	query := `
-		SELECT
-			m.id, m.hostname, m.ip, m.group_name, m.swarm_role,
-			m.first_seen, m.last_seen,
-			COALESCE(met.cpu_percent, 0),
-			COALESCE(met.memory_percent, 0),
-			COALESCE(met.disk_percent, 0),
-			COALESCE(met.docker_running, 0),
-			COALESCE(met.docker_stopped, 0)
+		SELECT ` + machineColumns + `
 		FROM machines m
 		LEFT JOIN (
 			SELECT machine_id, cpu_percent, memory_percent, disk_percent,
@@ -219,59 +956,43 @@ func (s *Storage) GetMachinesWithMetrics() ([]Machine, error) {
 				   ROW_NUMBER() OVER (PARTITION BY machine_id ORDER BY collected_at DESC) as rn
 			FROM metrics
 		) met ON m.id = met.machine_id AND met.rn = 1
+		WHERE ` + cond + `
 		ORDER BY m.group_name, m.hostname
 	`
 
-	rows, err := s.db.Query(query)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar máquinas: %w", err)
 	}
 	defer rows.Close()
 
 	var machines []Machine
-	now := time.Now()
-	onlineThreshold := 70 * time.Minute // 1h + 10min de margem
-
 	for rows.Next() {
-		var m Machine
-		var metrics Metrics
-		var firstSeen, lastSeen string
-
-		err := rows.Scan(
-			&m.ID, &m.Hostname, &m.IP, &m.GroupName, &m.SwarmRole,
-			&firstSeen, &lastSeen,
-			&metrics.CPUPercent, &metrics.MemoryPercent, &metrics.DiskPercent,
-			&metrics.DockerRunning, &metrics.DockerStopped,
-		)
+		m, err := scanMachine(rows)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao escanear linha: %w", err)
 		}
-
-		// Parse das datas (tentar múltiplos formatos do SQLite)
-		m.FirstSeen = parseDateTime(firstSeen)
-		m.LastSeen = parseDateTime(lastSeen)
-
-		// Determinar se está online
-		m.IsOnline = now.Sub(m.LastSeen) < onlineThreshold
-
-		m.Metrics = &metrics
-		machines = append(machines, m)
+		machines = append(machines, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return machines, rows.Err()
+	rules, err := s.ListThresholds()
+	if err != nil {
+		return nil, err
+	}
+	for i := range machines {
+		machines[i].evaluate(rules.resolve(&machines[i]))
+	}
+	return machines, nil
 }
 
-// GetMachineByID retorna uma máquina específica com suas métricas
-func (s *Storage) GetMachineByID(id int64) (*Machine, error) {
+// GetMachineByID retorna uma máquina específica com suas métricas (nil se não existir ou estiver fora do escopo)
+func (s *Storage) GetMachineByID(id int64, scope *Scope) (*Machine, error) {
+	cond, args := scope.condition("m.group_name")
 	query := `
-		SELECT
-			m.id, m.hostname, m.ip, m.group_name, m.swarm_role,
-			m.first_seen, m.last_seen,
-			COALESCE(met.cpu_percent, 0),
-			COALESCE(met.memory_percent, 0),
-			COALESCE(met.disk_percent, 0),
-			COALESCE(met.docker_running, 0),
-			COALESCE(met.docker_stopped, 0)
+		SELECT ` + machineColumns + `
 		FROM machines m
 		LEFT JOIN (
 			SELECT machine_id, cpu_percent, memory_percent, disk_percent,
@@ -281,19 +1002,10 @@ func (s *Storage) GetMachineByID(id int64) (*Machine, error) {
 			ORDER BY collected_at DESC
 			LIMIT 1
 		) met ON m.id = met.machine_id
-		WHERE m.id = ?
+		WHERE m.id = ? AND ` + cond + `
 	`
 
-	var m Machine
-	var metrics Metrics
-	var firstSeen, lastSeen string
-
-	err := s.db.QueryRow(query, id, id).Scan(
-		&m.ID, &m.Hostname, &m.IP, &m.GroupName, &m.SwarmRole,
-		&firstSeen, &lastSeen,
-		&metrics.CPUPercent, &metrics.MemoryPercent, &metrics.DiskPercent,
-		&metrics.DockerRunning, &metrics.DockerStopped,
-	)
+	m, err := scanMachine(s.db.QueryRow(query, append([]interface{}{id, id}, args...)...))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -301,30 +1013,177 @@ func (s *Storage) GetMachineByID(id int64) (*Machine, error) {
 		return nil, fmt.Errorf("erro ao buscar máquina: %w", err)
 	}
 
-	// Parse das datas (tentar múltiplos formatos do SQLite)
-	m.FirstSeen = parseDateTime(firstSeen)
-	m.LastSeen = parseDateTime(lastSeen)
+	rules, err := s.ListThresholds()
+	if err != nil {
+		return nil, err
+	}
+	m.evaluate(rules.resolve(m))
+	return m, nil
+}
 
-	// Determinar se está online
-	now := time.Now()
-	onlineThreshold := 70 * time.Minute
-	m.IsOnline = now.Sub(m.LastSeen) < onlineThreshold
+// MergeMachines move o histórico de sourceID para targetID e remove sourceID.
+// Usado para unir registros duplicados da mesma máquina (ex.: antes da identidade por UUID).
+// Mudanças de estado e alertas também são movidos; amostras com o mesmo horário, labels com
+// a mesma chave e limites da mesma métrica nas duas máquinas mantêm os do destino.
+// As duas máquinas precisam estar no escopo; caso contrário retorna ErrNotFound.
+func (s *Storage) MergeMachines(sourceID, targetID int64, scope *Scope) error {
+	if sourceID == targetID {
+		return fmt.Errorf("não é possível mesclar uma máquina com ela mesma")
+	}
 
-	m.Metrics = &metrics
-	return &m, nil
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sourceFirstSeen, sourceGroup string
+	var sourceUUID sql.NullString
+	err = tx.QueryRow("SELECT first_seen, uuid, group_name FROM machines WHERE id = ?", sourceID).Scan(&sourceFirstSeen, &sourceUUID, &sourceGroup)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar máquina %d: %w", sourceID, err)
+	}
+
+	var targetGroup string
+	err = tx.QueryRow("SELECT group_name FROM machines WHERE id = ?", targetID).Scan(&targetGroup)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar máquina %d: %w", targetID, err)
+	}
+
+	if !scope.Allows(sourceGroup) || !scope.Allows(targetGroup) {
+		return ErrNotFound
+	}
+
+	// A origem é removida antes de atualizar o destino para liberar o uuid (UNIQUE); métricas,
+	// labels e limites que não puderam ser movidos (o destino já tem) são apagados em cascata
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE OR IGNORE metrics SET machine_id = ? WHERE machine_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE status_events SET machine_id = ? WHERE machine_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE alerts SET machine_id = ? WHERE machine_id = ?`, []interface{}{targetID, sourceID}},
+		{`INSERT OR IGNORE INTO machine_labels (machine_id, key, value, source)
+		  SELECT ?, key, value, source FROM machine_labels WHERE machine_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE thresholds SET machine_id = ? WHERE machine_id = ? AND metric NOT IN (
+			SELECT metric FROM thresholds WHERE machine_id = ?
+		  )`, []interface{}{targetID, sourceID, targetID}},
+		{`DELETE FROM machines WHERE id = ?`, []interface{}{sourceID}},
+		{`UPDATE machines SET
+			first_seen = MIN(first_seen, ?),
+			uuid = COALESCE(uuid, ?)
+		  WHERE id = ?`, []interface{}{formatDateTime(parseDateTime(sourceFirstSeen)), sourceUUID, targetID}},
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("erro ao mesclar máquinas %d -> %d: %w", sourceID, targetID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MachineUpdate são as alterações de uma máquina pela API de gerenciamento (nil = não alterar).
+// Em Labels, um valor nil remove o label.
+type MachineUpdate struct {
+	DisplayName *string            `json:"display_name"`
+	GroupName   *string            `json:"group"`
+	Notes       *string            `json:"notes"`
+	Archived    *bool              `json:"archived"`
+	Labels      map[string]*string `json:"labels"`
+}
+
+// UpdateMachine aplica as alterações informadas. Alterar o grupo o fixa: os envios do agent
+// deixam de mudá-lo. Retorna ErrNotFound se a máquina não existir ou estiver fora do escopo.
+func (s *Storage) UpdateMachine(id int64, update MachineUpdate, scope *Scope) error {
+	var sets []string
+	var args []interface{}
+
+	if update.DisplayName != nil {
+		sets = append(sets, "display_name = NULLIF(?, '')")
+		args = append(args, *update.DisplayName)
+	}
+	if update.GroupName != nil {
+		sets = append(sets, "group_name = ?", "group_pinned = 1")
+		args = append(args, *update.GroupName)
+	}
+	if update.Notes != nil {
+		sets = append(sets, "notes = NULLIF(?, '')")
+		args = append(args, *update.Notes)
+	}
+	if update.Archived != nil {
+		if *update.Archived {
+			sets = append(sets, "archived_at = COALESCE(archived_at, CURRENT_TIMESTAMP)")
+		} else {
+			sets = append(sets, "archived_at = NULL")
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	cond, scopeArgs := scope.condition("group_name")
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM machines WHERE id = ? AND "+cond+")",
+		append([]interface{}{id}, scopeArgs...)...).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar máquina %d: %w", id, err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	if len(sets) > 0 {
+		_, err := tx.Exec("UPDATE machines SET "+strings.Join(sets, ", ")+" WHERE id = ?", append(args, id)...)
+		if err != nil {
+			return fmt.Errorf("erro ao atualizar máquina %d: %w", id, err)
+		}
+	}
+
+	if err := setAPILabels(tx, id, update.Labels); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteMachine remove uma máquina e todo o seu histórico.
+// Retorna ErrNotFound se a máquina não existir ou estiver fora do escopo.
+func (s *Storage) DeleteMachine(id int64, scope *Scope) error {
+	cond, args := scope.condition("group_name")
+	result, err := s.db.Exec("DELETE FROM machines WHERE id = ? AND "+cond, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return fmt.Errorf("erro ao remover máquina %d: %w", id, err)
+	}
+	return requireAffected(result)
 }
 
-// GetMetricsHistory retorna o histórico de métricas de uma máquina
-func (s *Storage) GetMetricsHistory(machineID int64, hours int) ([]map[string]interface{}, error) {
+// GetMetricsHistory retorna o histórico de métricas de uma máquina (vazio se estiver fora do escopo)
+func (s *Storage) GetMetricsHistory(machineID int64, hours int, scope *Scope) ([]map[string]interface{}, error) {
+	cond, args := scope.condition("m.group_name")
 	query := `
 		SELECT collected_at, cpu_percent, memory_percent, disk_percent,
-			   docker_running, docker_stopped
+			   docker_running, docker_stopped,
+			   sample_count, cpu_min, cpu_max, cpu_p95,
+			   memory_min, memory_max, memory_p95,
+			   disk_min, disk_max, disk_p95
 		FROM metrics
 		WHERE machine_id = ? AND collected_at > datetime('now', ?)
+		  AND EXISTS (SELECT 1 FROM machines m WHERE m.id = metrics.machine_id AND ` + cond + `)
 		ORDER BY collected_at DESC
 	`
 
-	rows, err := s.db.Query(query, machineID, fmt.Sprintf("-%d hours", hours))
+	rows, err := s.db.Query(query, append([]interface{}{machineID, fmt.Sprintf("-%d hours", hours)}, args...)...)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar histórico: %w", err)
 	}
@@ -335,19 +1194,34 @@ func (s *Storage) GetMetricsHistory(machineID int64, hours int) ([]map[string]in
 		var collectedAt string
 		var cpu, mem, disk float64
 		var running, stopped int
+		var sampleCount sql.NullInt64
+		var cpuMin, cpuMax, cpuP95, memMin, memMax, memP95, diskMin, diskMax, diskP95 sql.NullFloat64
 
-		if err := rows.Scan(&collectedAt, &cpu, &mem, &disk, &running, &stopped); err != nil {
+		if err := rows.Scan(&collectedAt, &cpu, &mem, &disk, &running, &stopped,
+			&sampleCount, &cpuMin, &cpuMax, &cpuP95,
+			&memMin, &memMax, &memP95,
+			&diskMin, &diskMax, &diskP95); err != nil {
 			return nil, err
 		}
 
-		history = append(history, map[string]interface{}{
+		point := map[string]interface{}{
 			"collected_at":   collectedAt,
 			"cpu_percent":    cpu,
 			"memory_percent": mem,
 			"disk_percent":   disk,
 			"docker_running": running,
 			"docker_stopped": stopped,
-		})
+		}
+
+		// Agregados só existem para relatórios com amostragem de alta resolução
+		if sampleCount.Valid && sampleCount.Int64 > 0 {
+			point["sample_count"] = sampleCount.Int64
+			point["cpu_min"], point["cpu_max"], point["cpu_p95"] = cpuMin.Float64, cpuMax.Float64, cpuP95.Float64
+			point["memory_min"], point["memory_max"], point["memory_p95"] = memMin.Float64, memMax.Float64, memP95.Float64
+			point["disk_min"], point["disk_max"], point["disk_p95"] = diskMin.Float64, diskMax.Float64, diskP95.Float64
+		}
+
+		history = append(history, point)
 	}
 
 	return history, rows.Err()
@@ -367,52 +1241,47 @@ func (s *Storage) CleanupOldMetrics() (int64, error) {
 	return result.RowsAffected()
 }
 
-// GetStats retorna estatísticas gerais
-func (s *Storage) GetStats() (map[string]interface{}, error) {
-	stats := make(map[string]interface{})
-
-	// Total de máquinas
-	var totalMachines int
-	s.db.QueryRow("SELECT COUNT(*) FROM machines").Scan(&totalMachines)
-	stats["total_machines"] = totalMachines
-
-	// Máquinas online (última métrica < 70 min)
-	var onlineMachines int
-	s.db.QueryRow(`
-		SELECT COUNT(*) FROM machines
-		WHERE last_seen > datetime('now', '-70 minutes')
-	`).Scan(&onlineMachines)
-	stats["online"] = onlineMachines
-
-	// Máquinas com atenção (CPU/MEM/Disco > 85%)
-	var warningMachines int
-	s.db.QueryRow(`
-		SELECT COUNT(DISTINCT m.id) FROM machines m
-		JOIN (
-			SELECT machine_id, cpu_percent, memory_percent, disk_percent,
-				   ROW_NUMBER() OVER (PARTITION BY machine_id ORDER BY collected_at DESC) as rn
-			FROM metrics
-		) met ON m.id = met.machine_id AND met.rn = 1
-		WHERE met.cpu_percent > 85 OR met.memory_percent > 85 OR met.disk_percent > 85
-	`).Scan(&warningMachines)
-	stats["warning"] = warningMachines
-
-	// Máquinas offline
-	stats["offline"] = totalMachines - onlineMachines
-
-	// Total de containers rodando
-	var totalContainers int
-	s.db.QueryRow(`
-		SELECT COALESCE(SUM(met.docker_running), 0) FROM machines m
-		JOIN (
-			SELECT machine_id, docker_running,
-				   ROW_NUMBER() OVER (PARTITION BY machine_id ORDER BY collected_at DESC) as rn
-			FROM metrics
-		) met ON m.id = met.machine_id AND met.rn = 1
-	`).Scan(&totalContainers)
-	stats["total_containers"] = totalContainers
+// GetStats retorna estatísticas gerais das máquinas do escopo. online conta as máquinas que
+// estão reportando (inclusive em atenção ou críticas); warning e critical seguem o estado
+// calculado com os limites de cada máquina.
+func (s *Storage) GetStats(scope *Scope, selector LabelSelector) (map[string]interface{}, error) {
+	machines, err := s.GetMachinesWithMetrics(scope, MachineFilter{IncludeArchived: true, Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var total, archived, online, warning, critical, containers int
+	for i := range machines {
+		m := &machines[i]
+		// Máquinas arquivadas ficam fora das contagens
+		if m.ArchivedAt != nil {
+			archived++
+			continue
+		}
+		total++
+		if m.IsOnline {
+			online++
+		}
+		switch m.Status {
+		case StatusWarning:
+			warning++
+		case StatusCritical:
+			critical++
+		}
+		if m.Metrics != nil {
+			containers += m.Metrics.DockerRunning
+		}
+	}
 
-	return stats, nil
+	return map[string]interface{}{
+		"total_machines":   total,
+		"online":           online,
+		"warning":          warning,
+		"critical":         critical,
+		"offline":          total - online,
+		"total_containers": containers,
+		"archived":         archived,
+	}, nil
 }
 
 // Close fecha a conexão com o banco de dados
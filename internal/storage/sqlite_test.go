@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestStorage abre um banco novo em um diretório temporário do teste
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	s, err := New(filepath.Join(t.TempDir(), "monitor.db"), 90)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// countRows conta as linhas de uma tabela que atendem à condição
+func countRows(t *testing.T, s *Storage, table, where string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+where, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSaveMetricsCollectedAt(t *testing.T) {
+	s := newTestStorage(t)
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name        string
+		collectedAt time.Time
+		duplicate   bool
+		stored      time.Time // zero: horário do servidor
+	}{
+		{"sem horário usa o do servidor", time.Time{}, false, time.Time{}},
+		{"amostra atrasada", old, false, old},
+		{"reenvio da mesma amostra", old, true, old},
+		{"outro horário", old.Add(time.Minute), false, old.Add(time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now().Add(-time.Second)
+			result, err := s.SaveMetrics(&MetricPayload{
+				MachineUUID: "8a3c9f1e-0000-4000-8000-000000000001",
+				Hostname:    "web-01",
+				IP:          "10.0.0.1",
+				CPUPercent:  10,
+				CollectedAt: tt.collectedAt,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Duplicate != tt.duplicate {
+				t.Errorf("Duplicate = %v, esperado %v", result.Duplicate, tt.duplicate)
+			}
+
+			if tt.stored.IsZero() {
+				if n := countRows(t, s, "metrics", "collected_at >= ?", formatDateTime(before)); n != 1 {
+					t.Errorf("%d amostras com o horário do servidor, esperado 1", n)
+				}
+				return
+			}
+			if n := countRows(t, s, "metrics", "collected_at = ?", formatDateTime(tt.stored)); n != 1 {
+				t.Errorf("%d amostras em %s, esperado 1", n, tt.stored)
+			}
+		})
+	}
+
+	if n := countRows(t, s, "metrics", "1"); n != 3 {
+		t.Errorf("%d métricas gravadas, esperado 3", n)
+	}
+}
+
+func TestSaveMetricsAggregates(t *testing.T) {
+	s := newTestStorage(t)
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		aggregates MetricAggregates
+		stored     bool // agregados gravados (NULL sem amostragem)
+	}{
+		{"sem amostragem", MetricAggregates{}, false},
+		{"com amostragem", MetricAggregates{SampleCount: 6, CPUMin: 5, CPUMax: 90, CPUP95: 80, DiskMin: 40, DiskMax: 41, DiskP95: 41}, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collectedAt := base.Add(time.Duration(i) * time.Minute)
+			if _, err := s.SaveMetrics(&MetricPayload{
+				Hostname:         "web-01",
+				IP:               "10.0.0.1",
+				CPUPercent:       30,
+				CollectedAt:      collectedAt,
+				MetricAggregates: tt.aggregates,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			var sampleCount sql.NullInt64
+			var cpuMax sql.NullFloat64
+			if err := s.db.QueryRow(`SELECT sample_count, cpu_max FROM metrics WHERE collected_at = ?`,
+				formatDateTime(collectedAt)).Scan(&sampleCount, &cpuMax); err != nil {
+				t.Fatal(err)
+			}
+			if sampleCount.Valid != tt.stored || cpuMax.Valid != tt.stored {
+				t.Fatalf("sample_count/cpu_max = %v/%v, esperado gravados = %v", sampleCount, cpuMax, tt.stored)
+			}
+			if tt.stored && (sampleCount.Int64 != int64(tt.aggregates.SampleCount) || cpuMax.Float64 != tt.aggregates.CPUMax) {
+				t.Errorf("sample_count/cpu_max = %d/%v", sampleCount.Int64, cpuMax.Float64)
+			}
+		})
+	}
+}
+
+func TestMachineIdentity(t *testing.T) {
+	s := newTestStorage(t)
+	const uuidA = "8a3c9f1e-0000-4000-8000-00000000000a"
+	const uuidB = "8a3c9f1e-0000-4000-8000-00000000000b"
+
+	// Máquina antiga, anterior à identidade por UUID
+	legacy, err := s.UpsertMachine("", "legado", "10.0.0.9", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		uuid     string
+		hostname string
+		same     string // envio anterior que deve ser a mesma máquina (vazio: máquina nova)
+		conflict bool
+	}{
+		{"primeiro envio", uuidA, "web-01", "", false},
+		{"hostname renomeado", uuidA, "web-02", "primeiro envio", false},
+		{"outro uuid com o mesmo hostname", uuidB, "web-02", "", true},
+		{"agent atualizado adota o registro sem uuid", "8a3c9f1e-0000-4000-8000-00000000000c", "legado", "legado", false},
+	}
+
+	ids := map[string]int64{"legado": legacy}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := s.SaveMetrics(&MetricPayload{MachineUUID: tt.uuid, Hostname: tt.hostname, IP: "10.0.0.1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids[tt.name] = result.MachineID
+
+			if tt.same != "" && result.MachineID != ids[tt.same] {
+				t.Errorf("máquina %d, esperado a mesma de %q (%d)", result.MachineID, tt.same, ids[tt.same])
+			}
+			if tt.same == "" {
+				for name, id := range ids {
+					if name != tt.name && id == result.MachineID {
+						t.Errorf("reaproveitou a máquina de %q", name)
+					}
+				}
+			}
+			if result.HostnameConflict != tt.conflict {
+				t.Errorf("HostnameConflict = %v, esperado %v", result.HostnameConflict, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestHostnameOwnsUUID(t *testing.T) {
+	s := newTestStorage(t)
+	const web = "8a3c9f1e-0000-4000-8000-00000000000a"
+	const db = "8a3c9f1e-0000-4000-8000-00000000000b"
+	for uuid, hostname := range map[string]string{web: "web-01", db: "db-01"} {
+		if _, err := s.UpsertMachine(uuid, hostname, "10.0.0.1", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.UpsertMachine("", "legado", "10.0.0.9", "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		hostname string
+		uuid     string
+		owns     bool
+	}{
+		{"uuid da máquina do hostname", "WEB-01", web, true},
+		{"uuid de outra máquina", "web-01", db, false},
+		{"uuid novo em hostname com uuid", "web-01", "8a3c9f1e-0000-4000-8000-00000000000c", false},
+		{"uuid novo em hostname sem uuid", "legado", "8a3c9f1e-0000-4000-8000-00000000000c", true},
+		{"uuid novo em hostname novo", "api-01", "8a3c9f1e-0000-4000-8000-00000000000c", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owns, err := s.HostnameOwnsUUID(tt.hostname, tt.uuid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owns != tt.owns {
+				t.Errorf("HostnameOwnsUUID = %v, esperado %v", owns, tt.owns)
+			}
+		})
+	}
+}
+
+func TestMergeMachinesMovesHistory(t *testing.T) {
+	s := newTestStorage(t)
+	now := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	source, _ := s.UpsertMachine("8a3c9f1e-0000-4000-8000-000000000001", "web-01", "10.0.0.1", "web", "")
+	target, _ := s.UpsertMachine("", "web-01", "10.0.0.1", "web", "")
+
+	seed := []string{
+		`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES
+			(?1, ?3, 1, 1, 1), (?1, ?4, 2, 2, 2), (?2, ?4, 3, 3, 3)`,
+		`INSERT INTO status_events (machine_id, from_status, to_status, created_at) VALUES (?1, 'online', 'offline', ?3)`,
+		`INSERT INTO alerts (machine_id, kind, created_at) VALUES (?1, 'clock_drift', ?3)`,
+		`INSERT INTO machine_labels (machine_id, key, value, source) VALUES
+			(?1, 'env', 'prod', 'api'), (?1, 'role', 'db', 'api'), (?2, 'env', 'staging', 'api')`,
+		`INSERT INTO thresholds (metric, machine_id, warning, critical) VALUES
+			('cpu', ?1, 50, 60), ('disk', ?1, 70, 80), ('cpu', ?2, 10, 20)`,
+	}
+	for _, query := range seed {
+		if _, err := s.db.Exec(query, source, target, formatDateTime(now), formatDateTime(now.Add(time.Minute))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.MergeMachines(source, target, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		table string
+		where string
+		want  int
+	}{
+		{"métricas sem conflito movidas", "metrics", "machine_id = ?", 2},
+		{"amostra do mesmo horário mantém a do destino", "metrics", "machine_id = ? AND cpu_percent = 3", 1},
+		{"mudanças de estado movidas", "status_events", "machine_id = ?", 1},
+		{"alertas movidos", "alerts", "machine_id = ?", 1},
+		{"labels movidos", "machine_labels", "machine_id = ?", 2},
+		{"label do destino prevalece", "machine_labels", "machine_id = ? AND key = 'env' AND value = 'staging'", 1},
+		{"limites movidos", "thresholds", "machine_id = ?", 2},
+		{"limite do destino prevalece", "thresholds", "machine_id = ? AND metric = 'cpu' AND warning = 10", 1},
+		{"uuid herdado", "machines", "id = ? AND uuid = '8a3c9f1e-0000-4000-8000-000000000001'", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := countRows(t, s, tt.table, tt.where, target); n != tt.want {
+				t.Errorf("%d linhas, esperado %d", n, tt.want)
+			}
+		})
+	}
+
+	if n := countRows(t, s, "machines", "id = ?", source); n != 0 {
+		t.Error("máquina de origem não foi removida")
+	}
+}
+
+func TestMergeMachinesScope(t *testing.T) {
+	s := newTestStorage(t)
+	web, _ := s.UpsertMachine("", "web-01", "10.0.0.1", "web", "")
+	db, _ := s.UpsertMachine("", "db-01", "10.0.0.2", "db", "")
+
+	tests := []struct {
+		name   string
+		source int64
+		target int64
+		scope  *Scope
+		err    error
+	}{
+		{"mesma máquina", web, web, nil, nil},
+		{"origem inexistente", 999, web, nil, ErrNotFound},
+		{"destino fora do escopo", web, db, &Scope{Groups: []string{"web"}}, ErrNotFound},
+		{"sem grupos", web, db, &Scope{}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.MergeMachines(tt.source, tt.target, tt.scope)
+			if err == nil {
+				t.Fatal("mesclagem deveria falhar")
+			}
+			if tt.err != nil && err != tt.err {
+				t.Errorf("erro = %v, esperado %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMigrateMetricsUnique(t *testing.T) {
+	s := newTestStorage(t)
+	machine, _ := s.UpsertMachine("", "web-01", "10.0.0.1", "", "")
+	at := formatDateTime(time.Now().Add(-time.Hour))
+
+	// Banco de uma versão anterior: sem o índice único e com duplicatas
+	for _, query := range []string{
+		`DROP INDEX idx_metrics_machine_collected_unique`,
+		`PRAGMA user_version = 0`,
+	} {
+		if _, err := s.db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		s.db.Exec(`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES (?, ?, ?, 0, 0)`, machine, at, i)
+	}
+
+	tests := []struct {
+		name    string
+		prepare string // executado antes da migração
+		want    int    // amostras depois da migração
+	}{
+		{"remove as duplicatas", "", 1},
+		{"não roda de novo", `DROP INDEX idx_metrics_machine_collected_unique`, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != "" {
+				if _, err := s.db.Exec(tt.prepare); err != nil {
+					t.Fatal(err)
+				}
+				// Sem o índice (removido só para o teste), uma duplicata passa
+				s.db.Exec(`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES (?, ?, 9, 0, 0)`, machine, at)
+			}
+			if err := s.migrateMetricsUnique(); err != nil {
+				t.Fatal(err)
+			}
+			if n := countRows(t, s, "metrics", "machine_id = ?", machine); n != tt.want {
+				t.Errorf("%d amostras, esperado %d", n, tt.want)
+			}
+
+			var version int
+			s.db.QueryRow(`PRAGMA user_version`).Scan(&version)
+			if version != schemaVersionMetricsUnique {
+				t.Errorf("user_version = %d, esperado %d", version, schemaVersionMetricsUnique)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Métricas com limites configuráveis
+const (
+	MetricCPU    = "cpu"
+	MetricMemory = "memory"
+	MetricDisk   = "disk"
+)
+
+// ThresholdMetrics são as métricas aceitas nos limites
+var ThresholdMetrics = []string{MetricCPU, MetricMemory, MetricDisk}
+
+// Escopos de um limite, do mais geral ao mais específico
+const (
+	ThresholdDefault = "default"
+	ThresholdGroup   = "group"
+	ThresholdMachine = "machine"
+)
+
+// Limit são os percentuais a partir dos quais a métrica deixa a máquina em atenção e crítica
+type Limit struct {
+	Warning  float64 `json:"warning"`
+	Critical float64 `json:"critical"`
+}
+
+// Thresholds são os limites de cada métrica (cpu, memory, disk)
+type Thresholds map[string]Limit
+
+// DefaultThresholds retorna os limites usados quando não há nenhum configurado: 85% e 95%
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MetricCPU:    {Warning: 85, Critical: 95},
+		MetricMemory: {Warning: 85, Critical: 95},
+		MetricDisk:   {Warning: 85, Critical: 95},
+	}
+}
+
+// ThresholdRule é um limite configurado para uma métrica: padrão (sem grupo nem máquina),
+// de um grupo ou de uma máquina
+type ThresholdRule struct {
+	ID        int64     `json:"id"`
+	Scope     string    `json:"scope"`
+	Metric    string    `json:"metric"`
+	GroupName string    `json:"group,omitempty"`
+	MachineID *int64    `json:"machine_id,omitempty"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Warning   float64   `json:"warning"`
+	Critical  float64   `json:"critical"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Grupo da máquina, para filtrar limites de máquina pelo escopo do usuário
+	MachineGroup string `json:"-"`
+}
+
+// ThresholdRules são os limites configurados
+type ThresholdRules []ThresholdRule
+
+// resolve calcula os limites da máquina: os padrão, sobrescritos pelos do grupo e pelos da máquina
+func (rules ThresholdRules) resolve(m *Machine) Thresholds {
+	thresholds := DefaultThresholds()
+	for _, scope := range []string{ThresholdDefault, ThresholdGroup, ThresholdMachine} {
+		for _, rule := range rules {
+			if rule.Scope != scope ||
+				scope == ThresholdGroup && rule.GroupName != m.GroupName ||
+				scope == ThresholdMachine && *rule.MachineID != m.ID {
+				continue
+			}
+			thresholds[rule.Metric] = Limit{Warning: rule.Warning, Critical: rule.Critical}
+		}
+	}
+	return thresholds
+}
+
+// thresholdColumns são as colunas lidas por scanThreshold (aliases t = thresholds, m = machines)
+const thresholdColumns = `
+	t.id, t.metric, COALESCE(t.group_name, ''), t.machine_id,
+	COALESCE(m.hostname, ''), COALESCE(m.group_name, ''),
+	t.warning, t.critical, t.updated_at
+`
+
+// scanThreshold lê uma linha com as colunas de thresholdColumns
+func scanThreshold(row rowScanner) (*ThresholdRule, error) {
+	var rule ThresholdRule
+	var machineID sql.NullInt64
+	var updatedAt string
+
+	err := row.Scan(&rule.ID, &rule.Metric, &rule.GroupName, &machineID,
+		&rule.Hostname, &rule.MachineGroup, &rule.Warning, &rule.Critical, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case machineID.Valid:
+		rule.MachineID = &machineID.Int64
+		rule.Scope = ThresholdMachine
+	case rule.GroupName != "":
+		rule.Scope = ThresholdGroup
+	default:
+		rule.Scope = ThresholdDefault
+	}
+	rule.UpdatedAt = parseDateTime(updatedAt)
+	return &rule, nil
+}
+
+// ListThresholds retorna os limites configurados: padrão, por grupo e por máquina
+func (s *Storage) ListThresholds() (ThresholdRules, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + thresholdColumns + ` FROM thresholds t
+		LEFT JOIN machines m ON m.id = t.machine_id
+		ORDER BY t.machine_id IS NOT NULL, t.group_name IS NOT NULL, t.group_name, t.machine_id, t.metric
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar limites: %w", err)
+	}
+	defer rows.Close()
+
+	rules := ThresholdRules{}
+	for rows.Next() {
+		rule, err := scanThreshold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear limite: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetThreshold retorna um limite configurado (nil se não existir)
+func (s *Storage) GetThreshold(id int64) (*ThresholdRule, error) {
+	rule, err := scanThreshold(s.db.QueryRow(`
+		SELECT `+thresholdColumns+` FROM thresholds t
+		LEFT JOIN machines m ON m.id = t.machine_id
+		WHERE t.id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar limite %d: %w", id, err)
+	}
+	return rule, nil
+}
+
+// SaveThreshold cria o limite da métrica no escopo (padrão, grupo ou máquina) ou, se já
+// existir, substitui seus valores
+func (s *Storage) SaveThreshold(rule ThresholdRule) (*ThresholdRule, error) {
+	var group *string
+	if rule.GroupName != "" {
+		group = &rule.GroupName
+	}
+
+	var id int64
+	err := s.db.QueryRow(`
+		SELECT id FROM thresholds WHERE metric = ? AND group_name IS ? AND machine_id IS ?
+	`, rule.Metric, group, rule.MachineID).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := s.db.Exec(`
+			INSERT INTO thresholds (metric, group_name, machine_id, warning, critical) VALUES (?, ?, ?, ?, ?)
+		`, rule.Metric, group, rule.MachineID, rule.Warning, rule.Critical)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao salvar limite: %w", err)
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("erro ao buscar limite: %w", err)
+	default:
+		_, err := s.db.Exec(`
+			UPDATE thresholds SET warning = ?, critical = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+		`, rule.Warning, rule.Critical, id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao salvar limite %d: %w", id, err)
+		}
+	}
+
+	return s.GetThreshold(id)
+}
+
+// DeleteThreshold remove um limite; a métrica volta a seguir o escopo mais geral
+func (s *Storage) DeleteThreshold(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM thresholds WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao remover limite %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
@@ -0,0 +1,109 @@
+package storage
+
+import "testing"
+
+func TestThresholdRulesResolve(t *testing.T) {
+	machine := func(id int64) *int64 { return &id }
+	rules := ThresholdRules{
+		{Scope: ThresholdDefault, Metric: MetricDisk, Warning: 80, Critical: 90},
+		{Scope: ThresholdGroup, Metric: MetricDisk, GroupName: "db", Warning: 70, Critical: 80},
+		{Scope: ThresholdGroup, Metric: MetricCPU, GroupName: "db", Warning: 60, Critical: 70},
+		{Scope: ThresholdMachine, Metric: MetricDisk, MachineID: machine(2), Warning: 95, Critical: 99},
+	}
+
+	tests := []struct {
+		name    string
+		machine Machine
+		disk    Limit
+		cpu     Limit
+	}{
+		{"padrão configurado", Machine{ID: 1, GroupName: "web"}, Limit{80, 90}, Limit{85, 95}},
+		{"grupo sobrescreve o padrão", Machine{ID: 3, GroupName: "db"}, Limit{70, 80}, Limit{60, 70}},
+		{"máquina sobrescreve o grupo", Machine{ID: 2, GroupName: "db"}, Limit{95, 99}, Limit{60, 70}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules.resolve(&tt.machine)
+			if got[MetricDisk] != tt.disk || got[MetricCPU] != tt.cpu {
+				t.Errorf("disk %v cpu %v, esperado %v e %v", got[MetricDisk], got[MetricCPU], tt.disk, tt.cpu)
+			}
+			if got[MetricMemory] != DefaultThresholds()[MetricMemory] {
+				t.Errorf("memory %v, esperado o padrão embutido", got[MetricMemory])
+			}
+		})
+	}
+}
+
+func TestMachineEvaluate(t *testing.T) {
+	thresholds := Thresholds{
+		MetricCPU:    {Warning: 80, Critical: 90},
+		MetricMemory: {Warning: 80, Critical: 90},
+		MetricDisk:   {Warning: 70, Critical: 80},
+	}
+
+	tests := []struct {
+		name    string
+		online  bool
+		metrics *Metrics
+		status  string
+	}{
+		{"sem relatórios recentes", false, &Metrics{CPUPercent: 99}, StatusOffline},
+		{"sem métricas", true, nil, StatusOnline},
+		{"abaixo dos limites", true, &Metrics{CPUPercent: 50, DiskPercent: 69}, StatusOnline},
+		{"no limite de atenção", true, &Metrics{DiskPercent: 70}, StatusWarning},
+		{"crítico prevalece sobre atenção", true, &Metrics{CPUPercent: 85, MemoryPercent: 95}, StatusCritical},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Machine{IsOnline: tt.online, Metrics: tt.metrics}
+			m.evaluate(thresholds)
+			if m.Status != tt.status {
+				t.Errorf("status = %s, esperado %s", m.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestSaveThreshold(t *testing.T) {
+	s := newTestStorage(t)
+	id := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "db-01", "db")
+
+	first, err := s.SaveThreshold(ThresholdRule{Metric: MetricDisk, GroupName: "db", Warning: 70, Critical: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		rule  ThresholdRule
+		scope string
+		same  bool // substitui o limite do grupo criado acima
+	}{
+		{"mesmo escopo substitui", ThresholdRule{Metric: MetricDisk, GroupName: "db", Warning: 60, Critical: 75}, ThresholdGroup, true},
+		{"outra métrica", ThresholdRule{Metric: MetricCPU, GroupName: "db", Warning: 60, Critical: 75}, ThresholdGroup, false},
+		{"padrão", ThresholdRule{Metric: MetricDisk, Warning: 80, Critical: 90}, ThresholdDefault, false},
+		{"máquina", ThresholdRule{Metric: MetricDisk, MachineID: &id, Warning: 90, Critical: 95}, ThresholdMachine, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, err := s.SaveThreshold(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Scope != tt.scope || (saved.ID == first.ID) != tt.same || saved.Warning != tt.rule.Warning {
+				t.Errorf("limite salvo = %+v", saved)
+			}
+		})
+	}
+
+	m, err := s.GetMachineByID(id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Thresholds[MetricDisk] != (Limit{90, 95}) || m.Thresholds[MetricCPU] != (Limit{60, 75}) {
+		t.Errorf("limites da máquina = %v", m.Thresholds)
+	}
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UptimeWindow é a disponibilidade de uma máquina (ou soma de máquinas) em um período:
+// o tempo monitorado — desde que a máquina existe e dentro da retenção — e quanto dele
+// ela passou online, a maior indisponibilidade contínua e quantas vezes o servidor
+// registrou a máquina ficando offline
+type UptimeWindow struct {
+	From        time.Time
+	To          time.Time
+	Monitored   time.Duration
+	Up          time.Duration
+	LongestDown time.Duration
+	Incidents   int
+}
+
+// Percent retorna o percentual do tempo monitorado em que a máquina esteve online;
+// ok é false se o período não tem tempo monitorado
+func (w UptimeWindow) Percent() (percent float64, ok bool) {
+	if w.Monitored <= 0 {
+		return 0, false
+	}
+	return 100 * w.Up.Seconds() / w.Monitored.Seconds(), true
+}
+
+// Down retorna o tempo monitorado em que a máquina esteve indisponível
+func (w UptimeWindow) Down() time.Duration {
+	return w.Monitored - w.Up
+}
+
+// Add soma o tempo monitorado e online e os incidentes de outro período (ex.: outra máquina
+// do grupo); a maior indisponibilidade é a maior das duas
+func (w *UptimeWindow) Add(other UptimeWindow) {
+	w.Monitored += other.Monitored
+	w.Up += other.Up
+	w.LongestDown = max(w.LongestDown, other.LongestDown)
+	w.Incidents += other.Incidents
+}
+
+// span é um intervalo [start, end)
+type span struct {
+	start, end time.Time
+}
+
+// Uptime calcula a disponibilidade das máquinas nos períodos consecutivos delimitados por
+// bounds (bounds[i] a bounds[i+1]). Cada relatório conta como online pelos 70 minutos
+// seguintes (o mesmo critério de IsOnline); lacunas maiores entre relatórios são indisponibilidade.
+// Os incidentes são as mudanças para offline registradas em status_events.
+func (s *Storage) Uptime(machines []Machine, bounds []time.Time) (map[int64][]UptimeWindow, error) {
+	result := make(map[int64][]UptimeWindow, len(machines))
+	if len(machines) == 0 || len(bounds) < 2 {
+		return result, nil
+	}
+
+	now := time.Now()
+	from, to := bounds[0], bounds[len(bounds)-1]
+	if to.After(now) {
+		to = now
+	}
+
+	ids := make([]interface{}, len(machines))
+	for i := range machines {
+		ids[i] = machines[i].ID
+	}
+	args := append(ids, formatDateTime(from.Add(-onlineThreshold)), formatDateTime(to))
+	rows, err := s.db.Query(`
+		SELECT machine_id, collected_at FROM metrics
+		WHERE machine_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		  AND collected_at >= ? AND collected_at < ?
+		ORDER BY machine_id, collected_at
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar relatórios: %w", err)
+	}
+	defer rows.Close()
+
+	// Intervalos online de cada máquina, já unidos e em ordem
+	spans := make(map[int64][]span)
+	for rows.Next() {
+		var machineID int64
+		var collectedAt string
+		if err := rows.Scan(&machineID, &collectedAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear relatório: %w", err)
+		}
+		t := parseDateTime(collectedAt)
+		up := span{t, t.Add(onlineThreshold)}
+		list := spans[machineID]
+		if n := len(list); n > 0 && !up.start.After(list[n-1].end) {
+			if up.end.After(list[n-1].end) {
+				list[n-1].end = up.end
+			}
+			continue
+		}
+		spans[machineID] = append(list, up)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	incidents, err := s.offlineEvents(ids, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Antes da retenção não há relatórios para medir
+	retentionStart := now.AddDate(0, 0, -s.retentionDays)
+	for i := range machines {
+		m := &machines[i]
+		start := latest(m.FirstSeen, retentionStart)
+		windows := make([]UptimeWindow, len(bounds)-1)
+		for j := range windows {
+			w := &windows[j]
+			w.From, w.To = bounds[j], bounds[j+1]
+			for _, t := range incidents[m.ID] {
+				if !t.Before(w.From) && t.Before(w.To) {
+					w.Incidents++
+				}
+			}
+			monitored := span{latest(w.From, start), earliest(w.To, now)}
+			if !monitored.end.After(monitored.start) {
+				continue
+			}
+			w.Monitored = monitored.end.Sub(monitored.start)
+			w.Up = overlap(spans[m.ID], monitored)
+			w.LongestDown = longestGap(spans[m.ID], monitored)
+		}
+		result[m.ID] = windows
+	}
+	return result, nil
+}
+
+// overlap soma a interseção dos intervalos (ordenados e disjuntos) com window
+func overlap(spans []span, window span) time.Duration {
+	// Primeiro intervalo que termina depois do início da janela
+	i := sort.Search(len(spans), func(i int) bool { return spans[i].end.After(window.start) })
+	var total time.Duration
+	for ; i < len(spans) && spans[i].start.Before(window.end); i++ {
+		start, end := latest(spans[i].start, window.start), earliest(spans[i].end, window.end)
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// offlineEvents retorna os horários em que cada máquina ficou offline no período
+func (s *Storage) offlineEvents(ids []interface{}, from, to time.Time) (map[int64][]time.Time, error) {
+	args := append(ids, StatusOffline, formatDateTime(from), formatDateTime(to))
+	rows, err := s.db.Query(`
+		SELECT machine_id, created_at FROM status_events
+		WHERE machine_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		  AND to_status = ? AND created_at >= ? AND created_at < ?
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar mudanças de estado: %w", err)
+	}
+	defer rows.Close()
+
+	events := make(map[int64][]time.Time)
+	for rows.Next() {
+		var machineID int64
+		var createdAt string
+		if err := rows.Scan(&machineID, &createdAt); err != nil {
+			return nil, fmt.Errorf("erro ao escanear mudança de estado: %w", err)
+		}
+		events[machineID] = append(events[machineID], parseDateTime(createdAt))
+	}
+	return events, rows.Err()
+}
+
+// longestGap retorna o maior trecho de window fora dos intervalos (ordenados e disjuntos)
+func longestGap(spans []span, window span) time.Duration {
+	i := sort.Search(len(spans), func(i int) bool { return spans[i].end.After(window.start) })
+	var longest time.Duration
+	cursor := window.start
+	for ; i < len(spans) && spans[i].start.Before(window.end); i++ {
+		longest = max(longest, spans[i].start.Sub(cursor))
+		cursor = latest(cursor, spans[i].end)
+	}
+	return max(longest, window.end.Sub(cursor))
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
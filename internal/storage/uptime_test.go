@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverlapAndLongestGap(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return base.Add(time.Duration(hours) * time.Hour) }
+	spans := []span{{at(1), at(3)}, {at(5), at(6)}, {at(8), at(12)}}
+
+	tests := []struct {
+		name    string
+		window  span
+		overlap time.Duration
+		gap     time.Duration
+	}{
+		{"janela inteira", span{at(0), at(10)}, 5 * time.Hour, 2 * time.Hour},
+		{"começa dentro de um intervalo", span{at(2), at(7)}, 2 * time.Hour, 2 * time.Hour},
+		{"sem intervalos", span{at(13), at(16)}, 0, 3 * time.Hour},
+		{"toda dentro de um intervalo", span{at(9), at(11)}, 2 * time.Hour, 0},
+		{"lacuna no fim", span{at(5), at(8)}, time.Hour, 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overlap(spans, tt.window); got != tt.overlap {
+				t.Errorf("overlap = %v, esperado %v", got, tt.overlap)
+			}
+			if got := longestGap(spans, tt.window); got != tt.gap {
+				t.Errorf("longestGap = %v, esperado %v", got, tt.gap)
+			}
+		})
+	}
+}
+
+func TestUptimeWindow(t *testing.T) {
+	var w UptimeWindow
+	if _, ok := w.Percent(); ok {
+		t.Error("período sem tempo monitorado não deveria ter percentual")
+	}
+
+	w.Add(UptimeWindow{Monitored: 10 * time.Hour, Up: 9 * time.Hour, LongestDown: time.Hour, Incidents: 1})
+	w.Add(UptimeWindow{Monitored: 10 * time.Hour, Up: 10 * time.Hour})
+	if percent, ok := w.Percent(); !ok || percent != 95 {
+		t.Errorf("Percent() = %v, %v, esperado 95", percent, ok)
+	}
+	if w.Down() != time.Hour || w.LongestDown != time.Hour || w.Incidents != 1 {
+		t.Errorf("soma inesperada: %+v", w)
+	}
+}
+
+func TestUptime(t *testing.T) {
+	s := newTestStorage(t)
+	id := saveTestMetrics(t, s, "8a3c9f1e-0000-4000-8000-000000000001", "web-01", "web")
+	now := time.Now().Truncate(time.Second)
+	at := func(minutes int) string { return formatDateTime(now.Add(time.Duration(minutes) * time.Minute)) }
+
+	// Relatórios às -10h e -9h (online de -10h a -7h50) e às -5h (online de -5h a -3h50)
+	if _, err := s.db.Exec(`DELETE FROM metrics`); err != nil {
+		t.Fatal(err)
+	}
+	for _, minutes := range []int{-600, -540, -300} {
+		if _, err := s.db.Exec(`INSERT INTO metrics (machine_id, collected_at, cpu_percent, memory_percent, disk_percent) VALUES (?, ?, 0, 0, 0)`,
+			id, at(minutes)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.db.Exec(`INSERT INTO status_events (machine_id, from_status, to_status, created_at) VALUES (?, ?, ?, ?), (?, ?, ?, ?)`,
+		id, StatusOnline, StatusOffline, at(-470), id, StatusOffline, StatusOnline, at(-300)); err != nil {
+		t.Fatal(err)
+	}
+
+	machine := Machine{ID: id, FirstSeen: now.Add(-10 * time.Hour)}
+	bounds := []time.Time{now.Add(-12 * time.Hour), now.Add(-6 * time.Hour), now}
+	result, err := s.Uptime([]Machine{machine}, bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows := result[id]
+	if len(windows) != 2 {
+		t.Fatalf("%d períodos, esperado 2", len(windows))
+	}
+
+	tests := []struct {
+		name      string
+		window    UptimeWindow
+		monitored time.Duration
+		up        time.Duration
+		longest   time.Duration
+		incidents int
+	}{
+		// Antes de first_seen não há tempo monitorado
+		{"primeiro período", windows[0], 4 * time.Hour, 130 * time.Minute, 110 * time.Minute, 1},
+		{"segundo período", windows[1], 6 * time.Hour, 70 * time.Minute, 230 * time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.window
+			if w.Monitored != tt.monitored || w.Up != tt.up || w.LongestDown != tt.longest || w.Incidents != tt.incidents {
+				t.Errorf("período = monitorado %v, online %v, maior queda %v, %d incidentes; esperado %v, %v, %v, %d",
+					w.Monitored, w.Up, w.LongestDown, w.Incidents, tt.monitored, tt.up, tt.longest, tt.incidents)
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User representa um usuário do dashboard e da API
+type User struct {
+	ID          int64      `json:"id"`
+	Username    string     `json:"username"`
+	Role        string     `json:"role"`
+	Groups      []string   `json:"groups"`             // vazio = nenhum grupo (exceto admin)
+	Language    string     `json:"language,omitempty"` // idioma preferido do dashboard e da API (vazio = negociado)
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// Scope retorna o escopo de grupos do usuário: nil (todos os grupos) apenas para administradores.
+// Usuários sem grupos não veem nenhuma máquina.
+func (u *User) Scope() *Scope {
+	if u.Role == RoleAdmin {
+		return nil
+	}
+	return &Scope{Groups: u.Groups}
+}
+
+// APIKey representa uma chave de API (apenas o prefixo é exposto)
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     *int64     `json:"user_id,omitempty"`
+	Username   string     `json:"username,omitempty"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// userColumns são as colunas lidas por scanUser (alias u); os grupos vêm separados por quebra de linha
+const userColumns = `
+	u.id, u.username, u.role, u.created_at, u.last_login_at, COALESCE(u.language, ''),
+	COALESCE((SELECT group_concat(group_name, char(10)) FROM user_groups WHERE user_id = u.id), '')
+`
+
+// scanUser lê uma linha com as colunas de userColumns
+func scanUser(row rowScanner) (*User, error) {
+	var u User
+	var createdAt, groups string
+	var lastLoginAt sql.NullString
+
+	if err := row.Scan(&u.ID, &u.Username, &u.Role, &createdAt, &lastLoginAt, &u.Language, &groups); err != nil {
+		return nil, err
+	}
+
+	u.CreatedAt = parseDateTime(createdAt)
+	u.LastLoginAt = parseNullDateTime(lastLoginAt)
+	u.Groups = []string{}
+	if groups != "" {
+		u.Groups = strings.Split(groups, "\n")
+	}
+	return &u, nil
+}
+
+// apiKeyColumns são as colunas lidas por scanAPIKey (alias k)
+const apiKeyColumns = `
+	k.id, k.user_id, COALESCE((SELECT username FROM users WHERE id = k.user_id), ''),
+	k.name, k.key_prefix, k.created_at, k.last_used_at, k.revoked_at
+`
+
+// scanAPIKey lê uma linha com as colunas de apiKeyColumns
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	var k APIKey
+	var userID sql.NullInt64
+	var createdAt string
+	var lastUsedAt, revokedAt sql.NullString
+
+	err := row.Scan(&k.ID, &userID, &k.Username, &k.Name, &k.KeyPrefix, &createdAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		k.UserID = &userID.Int64
+	}
+	k.CreatedAt = parseDateTime(createdAt)
+	k.LastUsedAt = parseNullDateTime(lastUsedAt)
+	k.RevokedAt = parseNullDateTime(revokedAt)
+	return &k, nil
+}
+
+// CountUsers retorna a quantidade de usuários cadastrados
+func (s *Storage) CountUsers() (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("erro ao contar usuários: %w", err)
+	}
+	return count, nil
+}
+
+// CountAdmins retorna a quantidade de administradores
+func (s *Storage) CountAdmins() (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM users WHERE role = ?`, RoleAdmin).Scan(&count); err != nil {
+		return 0, fmt.Errorf("erro ao contar administradores: %w", err)
+	}
+	return count, nil
+}
+
+// CreateUser cadastra um usuário com a senha já em hash, o papel e os grupos visíveis
+func (s *Storage) CreateUser(username, passwordHash, role string, groups []string) (*User, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO users (username, password_hash, role) VALUES (?, ?, ?)
+	`, username, passwordHash, role)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar usuário %s: %w", username, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setUserGroups(tx, id, groups); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(id)
+}
+
+// UpdateUserAccess altera o papel e os grupos de um usuário
+func (s *Storage) UpdateUserAccess(id int64, role string, groups []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, id)
+	if err != nil {
+		return fmt.Errorf("erro ao alterar usuário %d: %w", id, err)
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	if err := setUserGroups(tx, id, groups); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setUserGroups substitui os grupos visíveis de um usuário
+func setUserGroups(ex execer, userID int64, groups []string) error {
+	if _, err := ex.Exec(`DELETE FROM user_groups WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("erro ao alterar grupos do usuário %d: %w", userID, err)
+	}
+
+	for _, group := range groups {
+		if _, err := ex.Exec(`
+			INSERT OR IGNORE INTO user_groups (user_id, group_name) VALUES (?, ?)
+		`, userID, group); err != nil {
+			return fmt.Errorf("erro ao alterar grupos do usuário %d: %w", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetUser retorna um usuário pelo ID (nil se não existir)
+func (s *Storage) GetUser(id int64) (*User, error) {
+	u, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users u WHERE u.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	return u, nil
+}
+
+// GetUserCredentials retorna o usuário e o hash da senha pelo username (nil se não existir)
+func (s *Storage) GetUserCredentials(username string) (*User, string, error) {
+	var id int64
+	var passwordHash string
+
+	err := s.db.QueryRow(`
+		SELECT id, password_hash FROM users WHERE username = ?
+	`, username).Scan(&id, &passwordHash)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+
+	u, err := s.GetUser(id)
+	if err != nil || u == nil {
+		return nil, "", err
+	}
+	return u, passwordHash, nil
+}
+
+// ListUsers retorna todos os usuários
+func (s *Storage) ListUsers() ([]User, error) {
+	rows, err := s.db.Query(`SELECT ` + userColumns + ` FROM users u ORDER BY u.username`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar usuários: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear usuário: %w", err)
+		}
+		users = append(users, *u)
+	}
+
+	return users, rows.Err()
+}
+
+// SetUserLanguage altera o idioma preferido do usuário (vazio volta à negociação pelo navegador)
+func (s *Storage) SetUserLanguage(id int64, language string) error {
+	result, err := s.db.Exec(`UPDATE users SET language = NULLIF(?, '') WHERE id = ?`, language, id)
+	if err != nil {
+		return fmt.Errorf("erro ao alterar idioma do usuário %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
+
+// SetUserPassword troca a senha de um usuário e encerra suas sessões, exceto keepSession
+// (hash da sessão de quem trocou a própria senha; vazio encerra todas)
+func (s *Storage) SetUserPassword(id int64, passwordHash, keepSession string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("erro ao trocar senha do usuário %d: %w", id, err)
+	}
+	if err := requireAffected(result); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE user_id = ? AND token_hash != ?`, id, keepSession); err != nil {
+		return fmt.Errorf("erro ao encerrar sessões do usuário %d: %w", id, err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteUser remove um usuário (sessões e chaves de API são removidas em cascata)
+func (s *Storage) DeleteUser(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao remover usuário %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
+
+// CreateSession registra uma sessão de login e atualiza o último acesso do usuário
+func (s *Storage) CreateSession(tokenHash string, userID int64, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)
+	`, tokenHash, userID, formatDateTime(expiresAt)); err != nil {
+		return fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET last_login_at = CURRENT_TIMESTAMP WHERE id = ?`, userID); err != nil {
+		return fmt.Errorf("erro ao registrar login: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetSessionUser retorna o usuário de uma sessão válida (nil se não existir ou expirou)
+func (s *Storage) GetSessionUser(tokenHash string) (*User, error) {
+	u, err := scanUser(s.db.QueryRow(`
+		SELECT `+userColumns+` FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > datetime('now')
+	`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar sessão: %w", err)
+	}
+	return u, nil
+}
+
+// DeleteSession encerra uma sessão
+func (s *Storage) DeleteSession(tokenHash string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	return err
+}
+
+// CleanupExpiredSessions remove sessões expiradas
+func (s *Storage) CleanupExpiredSessions() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at <= datetime('now')`)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao limpar sessões: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// CreateAPIKey registra uma chave de API; userID é opcional (chaves criadas com o AUTH_TOKEN)
+func (s *Storage) CreateAPIKey(userID *int64, name, keyHash, keyPrefix string) (*APIKey, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO api_keys (user_id, name, key_hash, key_prefix) VALUES (?, ?, ?, ?)
+	`, userID, name, keyHash, keyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar chave de API: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := scanAPIKey(s.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys k WHERE k.id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chave de API: %w", err)
+	}
+	return k, nil
+}
+
+// FindAPIKeyByHash retorna a chave ativa (não revogada) com o hash informado
+func (s *Storage) FindAPIKeyByHash(keyHash string) (*APIKey, error) {
+	k, err := scanAPIKey(s.db.QueryRow(`
+		SELECT `+apiKeyColumns+` FROM api_keys k WHERE k.key_hash = ? AND k.revoked_at IS NULL
+	`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chave de API: %w", err)
+	}
+	return k, nil
+}
+
+// ListAPIKeys retorna todas as chaves de API
+func (s *Storage) ListAPIKeys() ([]APIKey, error) {
+	rows, err := s.db.Query(`SELECT ` + apiKeyColumns + ` FROM api_keys k ORDER BY k.id`)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar chaves de API: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear chave de API: %w", err)
+		}
+		keys = append(keys, *k)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revoga uma chave de API
+func (s *Storage) RevokeAPIKey(id int64) error {
+	result, err := s.db.Exec(`
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = ?
+	`, id)
+	if err != nil {
+		return fmt.Errorf("erro ao revogar chave de API %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
+
+// TouchAPIKey registra o último uso de uma chave de API
+func (s *Storage) TouchAPIKey(id int64) error {
+	_, err := s.db.Exec(`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
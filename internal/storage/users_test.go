@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	s := newTestStorage(t)
+	user, err := s.CreateUser("ana", "hash", RoleViewer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		hash    string
+		expires time.Time
+		valid   bool
+	}{
+		{"sessão ativa", "sessao-ativa", time.Now().Add(time.Hour), true},
+		{"sessão expirada", "sessao-expirada", time.Now().Add(-time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.CreateSession(tt.hash, user.ID, tt.expires); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.GetSessionUser(tt.hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got != nil) != tt.valid {
+				t.Errorf("sessão válida = %v, esperado %v", got != nil, tt.valid)
+			}
+		})
+	}
+
+	removed, err := s.CleanupExpiredSessions()
+	if err != nil || removed != 1 {
+		t.Errorf("CleanupExpiredSessions = %d, %v; esperado 1", removed, err)
+	}
+}
+
+func TestSetUserPasswordSessions(t *testing.T) {
+	tests := []struct {
+		name        string
+		keepSession string
+		remaining   int
+	}{
+		{"mantém a sessão de quem trocou", "sessao-1", 1},
+		{"encerra todas", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			user, err := s.CreateUser("ana", "hash", RoleViewer, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, hash := range []string{"sessao-1", "sessao-2", "sessao-3"} {
+				if err := s.CreateSession(hash, user.ID, time.Now().Add(time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := s.SetUserPassword(user.ID, "novo-hash", tt.keepSession); err != nil {
+				t.Fatal(err)
+			}
+			if n := countRows(t, s, "sessions", "user_id = ?", user.ID); n != tt.remaining {
+				t.Errorf("sessões restantes = %d, esperado %d", n, tt.remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SavedView é uma visão nomeada do dashboard: busca, filtros e ordenação.
+// Visões compartilhadas aparecem para todos os usuários; as demais, só para o dono.
+type SavedView struct {
+	ID        int64       `json:"id"`
+	Name      string      `json:"name"`
+	UserID    *int64      `json:"user_id,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Shared    bool        `json:"shared"`
+	Filters   ViewFilters `json:"filters"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// ViewFilters são os critérios de uma visão; campos vazios não filtram
+type ViewFilters struct {
+	Search          string `json:"search,omitempty"`     // hostname, nome de exibição ou IP
+	Status          string `json:"status,omitempty"`     // online, warning, critical ou offline
+	Group           string `json:"group,omitempty"`      // grupo exato
+	SwarmRole       string `json:"swarm_role,omitempty"` // none, manager ou worker
+	Selector        string `json:"selector,omitempty"`   // seletor de labels (ver ParseLabelSelector)
+	Sort            string `json:"sort,omitempty"`       // name, cpu, memory, disk, containers ou last_seen; "-" inverte
+	IncludeArchived bool   `json:"include_archived,omitempty"`
+}
+
+// viewColumns são as colunas lidas por scanView (alias v)
+const viewColumns = `
+	v.id, v.name, v.user_id, COALESCE((SELECT username FROM users WHERE id = v.user_id), ''),
+	v.shared, v.filters, v.created_at, v.updated_at
+`
+
+// scanView lê uma linha com as colunas de viewColumns
+func scanView(row rowScanner) (*SavedView, error) {
+	var v SavedView
+	var userID sql.NullInt64
+	var filters, createdAt, updatedAt string
+
+	err := row.Scan(&v.ID, &v.Name, &userID, &v.Username, &v.Shared, &filters, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		v.UserID = &userID.Int64
+	}
+	if err := json.Unmarshal([]byte(filters), &v.Filters); err != nil {
+		return nil, fmt.Errorf("filtros inválidos na visão %d: %w", v.ID, err)
+	}
+	v.CreatedAt = parseDateTime(createdAt)
+	v.UpdatedAt = parseDateTime(updatedAt)
+	return &v, nil
+}
+
+// SaveView cria a visão ou, se o usuário já tem uma com o mesmo nome, substitui seus filtros.
+// userID nil identifica visões criadas com o AUTH_TOKEN ou no modo aberto.
+func (s *Storage) SaveView(userID *int64, name string, shared bool, filters ViewFilters) (*SavedView, error) {
+	data, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int64
+	err = s.db.QueryRow(`SELECT id FROM saved_views WHERE name = ? AND user_id IS ?`, name, userID).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := s.db.Exec(`
+			INSERT INTO saved_views (name, user_id, shared, filters) VALUES (?, ?, ?, ?)
+		`, name, userID, shared, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao salvar visão: %w", err)
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("erro ao buscar visão: %w", err)
+	default:
+		_, err := s.db.Exec(`
+			UPDATE saved_views SET shared = ?, filters = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+		`, shared, string(data), id)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao salvar visão %d: %w", id, err)
+		}
+	}
+
+	v, err := scanView(s.db.QueryRow(`SELECT `+viewColumns+` FROM saved_views v WHERE v.id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar visão: %w", err)
+	}
+	return v, nil
+}
+
+// ListViews retorna as visões do usuário e as compartilhadas, por nome
+func (s *Storage) ListViews(userID *int64) ([]SavedView, error) {
+	rows, err := s.db.Query(`
+		SELECT `+viewColumns+` FROM saved_views v
+		WHERE v.shared OR v.user_id IS ?
+		ORDER BY v.name, v.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar visões: %w", err)
+	}
+	defer rows.Close()
+
+	views := []SavedView{}
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao escanear visão: %w", err)
+		}
+		views = append(views, *v)
+	}
+
+	return views, rows.Err()
+}
+
+// DeleteView remove uma visão do usuário (ou qualquer visão, com anyOwner);
+// retorna ErrNotFound se ela não existe ou pertence a outro usuário
+func (s *Storage) DeleteView(id int64, userID *int64, anyOwner bool) error {
+	result, err := s.db.Exec(`DELETE FROM saved_views WHERE id = ? AND (? OR user_id IS ?)`, id, anyOwner, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover visão %d: %w", id, err)
+	}
+	return requireAffected(result)
+}
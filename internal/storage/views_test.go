@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSavedViews(t *testing.T) {
+	s := newTestStorage(t)
+	ana, _ := s.CreateUser("ana", "hash", RoleViewer, nil)
+	bia, _ := s.CreateUser("bia", "hash", RoleViewer, nil)
+
+	if _, err := s.SaveView(&ana.ID, "offline", false, ViewFilters{Status: StatusOffline}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveView(&ana.ID, "discos", true, ViewFilters{Sort: "-disk"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveView(&bia.ID, "offline", false, ViewFilters{Status: StatusOffline, Group: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	// Mesmo nome do mesmo usuário substitui os filtros
+	replaced, err := s.SaveView(&ana.ID, "offline", false, ViewFilters{Status: StatusWarning})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Filters.Status != StatusWarning || countRows(t, s, "saved_views", "1") != 3 {
+		t.Errorf("visão não substituída: %+v", replaced)
+	}
+
+	tests := []struct {
+		name   string
+		userID *int64
+		want   []string // nome/dono das visões visíveis, na ordem
+	}{
+		{"dono vê as suas e as compartilhadas", &ana.ID, []string{"discos/ana", "offline/ana"}},
+		{"outro usuário vê só as compartilhadas de terceiros", &bia.ID, []string{"discos/ana", "offline/bia"}},
+		{"AUTH_TOKEN vê as compartilhadas", nil, []string{"discos/ana"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			views, err := s.ListViews(tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range views {
+				got = append(got, v.Name+"/"+v.Username)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("visões = %v, esperado %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("visões = %v, esperado %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteView(t *testing.T) {
+	tests := []struct {
+		name     string
+		owner    bool // quem remove é o dono
+		anyOwner bool
+		deleted  bool
+	}{
+		{"dono", true, false, true},
+		{"outro usuário", false, false, false},
+		{"administrador", false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t)
+			ana, _ := s.CreateUser("ana", "hash", RoleViewer, nil)
+			bia, _ := s.CreateUser("bia", "hash", RoleViewer, nil)
+			view, err := s.SaveView(&ana.ID, "offline", true, ViewFilters{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			userID := &bia.ID
+			if tt.owner {
+				userID = &ana.ID
+			}
+			err = s.DeleteView(view.ID, userID, tt.anyOwner)
+			if tt.deleted && err != nil {
+				t.Fatal(err)
+			}
+			if !tt.deleted && !errors.Is(err, ErrNotFound) {
+				t.Errorf("erro = %v, esperado ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -17,8 +17,14 @@ SERVICE_NAME="monitor-agent"
 
 SERVER_URL=""
 AUTH_TOKEN=""
+ENROLL_TOKEN=""
+TLS_CA_CERT=""
+TLS_CLIENT_CERT=""
+TLS_CLIENT_KEY=""
+SIGNING_KEY=""
 MACHINE_NAME=""
 GROUP_NAME="default"
+LABELS=""
 INTERVAL_MINUTES=60
 
 print_header() {
@@ -42,8 +48,14 @@ show_help() {
     echo ""
     echo "Opcoes:"
     echo "  --token TOKEN     Token de autenticacao"
+    echo "  --enroll-token T  Token de inscricao (agent obtem credencial propria)"
+    echo "  --ca-cert ARQ     CA para verificar o certificado do servidor"
+    echo "  --client-cert ARQ Certificado de cliente do agent (mTLS)"
+    echo "  --client-key ARQ  Chave do certificado de cliente"
+    echo "  --signing-key K   Chave HMAC para assinar os envios"
     echo "  --name NOME       Nome da maquina (default: hostname)"
     echo "  --group GRUPO     Grupo (default: default)"
+    echo "  --labels L        Labels chave=valor separados por virgula (ex: env=prod,role=web)"
     echo "  --interval MIN    Intervalo em minutos (default: 60)"
     echo "  -h, --help        Mostra esta ajuda"
 }
@@ -53,8 +65,14 @@ parse_args() {
         case $1 in
             --server) SERVER_URL="$2"; shift 2 ;;
             --token) AUTH_TOKEN="$2"; shift 2 ;;
+            --enroll-token) ENROLL_TOKEN="$2"; shift 2 ;;
+            --ca-cert) TLS_CA_CERT="$2"; shift 2 ;;
+            --client-cert) TLS_CLIENT_CERT="$2"; shift 2 ;;
+            --client-key) TLS_CLIENT_KEY="$2"; shift 2 ;;
+            --signing-key) SIGNING_KEY="$2"; shift 2 ;;
             --name) MACHINE_NAME="$2"; shift 2 ;;
             --group) GROUP_NAME="$2"; shift 2 ;;
+            --labels) LABELS="$2"; shift 2 ;;
             --interval) INTERVAL_MINUTES="$2"; shift 2 ;;
             -h|--help) show_help; exit 0 ;;
             *) print_error "Argumento desconhecido: $1"; show_help; exit 1 ;;
@@ -124,8 +142,14 @@ create_config() {
     cat > "$INSTALL_DIR/config.env" << EOF
 SERVER_URL=${SERVER_URL}
 AUTH_TOKEN=${AUTH_TOKEN}
+ENROLL_TOKEN=${ENROLL_TOKEN}
+TLS_CA_CERT=${TLS_CA_CERT}
+TLS_CLIENT_CERT=${TLS_CLIENT_CERT}
+TLS_CLIENT_KEY=${TLS_CLIENT_KEY}
+SIGNING_KEY=${SIGNING_KEY}
 MACHINE_NAME=${MACHINE_NAME}
 GROUP_NAME=${GROUP_NAME}
+LABELS=${LABELS}
 INTERVAL_MINUTES=${INTERVAL_MINUTES}
 EOF
 
@@ -147,7 +171,7 @@ Type=simple
 EnvironmentFile=${INSTALL_DIR}/config.env
 ExecStart=${INSTALL_DIR}/monitor-agent \
     --server \${SERVER_URL} \
-    --token \${AUTH_TOKEN} \
+    --token=\${AUTH_TOKEN} \
     --name \${MACHINE_NAME} \
     --group \${GROUP_NAME} \
     --interval \${INTERVAL_MINUTES}